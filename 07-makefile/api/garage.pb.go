@@ -10,7 +10,10 @@ import (
 	context "context"
 	proto "github.com/golang/protobuf/proto"
 	empty "github.com/golang/protobuf/ptypes/empty"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	wrappers "github.com/golang/protobuf/ptypes/wrappers"
 	_ "google.golang.org/genproto/googleapis/api/annotations"
+	field_mask "google.golang.org/genproto/protobuf/field_mask"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
@@ -80,6 +83,223 @@ func (CarBody) EnumDescriptor() ([]byte, []int) {
 	return file_api_garage_proto_rawDescGZIP(), []int{0, 0}
 }
 
+type CarLifecycle int32
+
+const (
+	Car_UNKNOWN         CarLifecycle = 0
+	Car_ACCEPTED        CarLifecycle = 1
+	Car_PAINT_REQUESTED CarLifecycle = 2
+	Car_PAINTING        CarLifecycle = 3
+	Car_PAINTED         CarLifecycle = 4
+	Car_RETRIEVED       CarLifecycle = 5
+)
+
+// Enum value maps for CarLifecycle.
+var (
+	CarLifecycle_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "ACCEPTED",
+		2: "PAINT_REQUESTED",
+		3: "PAINTING",
+		4: "PAINTED",
+		5: "RETRIEVED",
+	}
+	CarLifecycle_value = map[string]int32{
+		"UNKNOWN":         0,
+		"ACCEPTED":        1,
+		"PAINT_REQUESTED": 2,
+		"PAINTING":        3,
+		"PAINTED":         4,
+		"RETRIEVED":       5,
+	}
+)
+
+func (x CarLifecycle) Enum() *CarLifecycle {
+	p := new(CarLifecycle)
+	*p = x
+	return p
+}
+
+func (x CarLifecycle) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CarLifecycle) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_garage_proto_enumTypes[1].Descriptor()
+}
+
+func (CarLifecycle) Type() protoreflect.EnumType {
+	return &file_api_garage_proto_enumTypes[1]
+}
+
+func (x CarLifecycle) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CarLifecycle.Descriptor instead.
+func (CarLifecycle) EnumDescriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{0, 1}
+}
+
+type CarEventType int32
+
+const (
+	CarEvent_UNKNOWN         CarEventType = 0
+	CarEvent_ACCEPTED        CarEventType = 1
+	CarEvent_PAINT_REQUESTED CarEventType = 2
+	CarEvent_PAINTED         CarEventType = 3
+	CarEvent_RETRIEVED       CarEventType = 4
+)
+
+// Enum value maps for CarEventType.
+var (
+	CarEventType_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "ACCEPTED",
+		2: "PAINT_REQUESTED",
+		3: "PAINTED",
+		4: "RETRIEVED",
+	}
+	CarEventType_value = map[string]int32{
+		"UNKNOWN":         0,
+		"ACCEPTED":        1,
+		"PAINT_REQUESTED": 2,
+		"PAINTED":         3,
+		"RETRIEVED":       4,
+	}
+)
+
+func (x CarEventType) Enum() *CarEventType {
+	p := new(CarEventType)
+	*p = x
+	return p
+}
+
+func (x CarEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CarEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_garage_proto_enumTypes[2].Descriptor()
+}
+
+func (CarEventType) Type() protoreflect.EnumType {
+	return &file_api_garage_proto_enumTypes[2]
+}
+
+func (x CarEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CarEventType.Descriptor instead.
+func (CarEventType) EnumDescriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{11, 0}
+}
+
+type PaintJobStatus int32
+
+const (
+	PaintJob_UNKNOWN   PaintJobStatus = 0
+	PaintJob_QUEUED    PaintJobStatus = 1
+	PaintJob_RUNNING   PaintJobStatus = 2
+	PaintJob_SUCCEEDED PaintJobStatus = 3
+	PaintJob_FAILED    PaintJobStatus = 4
+	PaintJob_CANCELLED PaintJobStatus = 5
+)
+
+// Enum value maps for PaintJobStatus.
+var (
+	PaintJobStatus_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "QUEUED",
+		2: "RUNNING",
+		3: "SUCCEEDED",
+		4: "FAILED",
+		5: "CANCELLED",
+	}
+	PaintJobStatus_value = map[string]int32{
+		"UNKNOWN":   0,
+		"QUEUED":    1,
+		"RUNNING":   2,
+		"SUCCEEDED": 3,
+		"FAILED":    4,
+		"CANCELLED": 5,
+	}
+)
+
+func (x PaintJobStatus) Enum() *PaintJobStatus {
+	p := new(PaintJobStatus)
+	*p = x
+	return p
+}
+
+func (x PaintJobStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PaintJobStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_garage_proto_enumTypes[3].Descriptor()
+}
+
+func (PaintJobStatus) Type() protoreflect.EnumType {
+	return &file_api_garage_proto_enumTypes[3]
+}
+
+func (x PaintJobStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PaintJobStatus.Descriptor instead.
+func (PaintJobStatus) EnumDescriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{12, 0}
+}
+
+type SubWorkshopRegistrationTransport int32
+
+const (
+	SubWorkshopRegistration_REST SubWorkshopRegistrationTransport = 0
+	SubWorkshopRegistration_GRPC SubWorkshopRegistrationTransport = 1
+)
+
+// Enum value maps for SubWorkshopRegistrationTransport.
+var (
+	SubWorkshopRegistrationTransport_name = map[int32]string{
+		0: "REST",
+		1: "GRPC",
+	}
+	SubWorkshopRegistrationTransport_value = map[string]int32{
+		"REST": 0,
+		"GRPC": 1,
+	}
+)
+
+func (x SubWorkshopRegistrationTransport) Enum() *SubWorkshopRegistrationTransport {
+	p := new(SubWorkshopRegistrationTransport)
+	*p = x
+	return p
+}
+
+func (x SubWorkshopRegistrationTransport) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SubWorkshopRegistrationTransport) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_garage_proto_enumTypes[4].Descriptor()
+}
+
+func (SubWorkshopRegistrationTransport) Type() protoreflect.EnumType {
+	return &file_api_garage_proto_enumTypes[4]
+}
+
+func (x SubWorkshopRegistrationTransport) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SubWorkshopRegistrationTransport.Descriptor instead.
+func (SubWorkshopRegistrationTransport) EnumDescriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{17, 0}
+}
+
 type Car struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -89,6 +309,12 @@ type Car struct {
 	Owner     string  `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
 	BodyStyle CarBody `protobuf:"varint,3,opt,name=body_style,json=bodyStyle,proto3,enum=tutorial.workshop.CarBody" json:"body_style,omitempty"`
 	Color     string  `protobuf:"bytes,4,opt,name=color,proto3" json:"color,omitempty"`
+	// set by the workshop, ignored when accepting a car
+	State CarLifecycle `protobuf:"varint,5,opt,name=state,proto3,enum=tutorial.workshop.CarLifecycle" json:"state,omitempty"`
+	// set by the workshop, changes with every change of the car, also sent in the ETag header.
+	// When updating a car the update fails with ABORTED unless the car still has this etag, any etag if not set.
+	// PaintCar, RetrieveCar and UpdateCar fail the same way when the etag in the If-Match header is outdated.
+	Etag string `protobuf:"bytes,6,opt,name=etag,proto3" json:"etag,omitempty"`
 }
 
 func (x *Car) Reset() {
@@ -151,6 +377,20 @@ func (x *Car) GetColor() string {
 	return ""
 }
 
+func (x *Car) GetState() CarLifecycle {
+	if x != nil {
+		return x.State
+	}
+	return Car_UNKNOWN
+}
+
+func (x *Car) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
 type PaintCarRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -213,6 +453,10 @@ type PaintFinishedRequest struct {
 
 	CarNumber    string `protobuf:"bytes,1,opt,name=car_number,json=carNumber,proto3" json:"car_number,omitempty"`
 	DesiredColor string `protobuf:"bytes,2,opt,name=desired_color,json=desiredColor,proto3" json:"desired_color,omitempty"`
+	// id of the paint job, as sent to the sub workshop
+	PaintJobId string `protobuf:"bytes,3,opt,name=paint_job_id,json=paintJobId,proto3" json:"paint_job_id,omitempty"`
+	// callback_token as sent to the sub workshop, proves the workshop asked to paint this car
+	CallbackToken string `protobuf:"bytes,4,opt,name=callback_token,json=callbackToken,proto3" json:"callback_token,omitempty"`
 }
 
 func (x *PaintFinishedRequest) Reset() {
@@ -261,6 +505,20 @@ func (x *PaintFinishedRequest) GetDesiredColor() string {
 	return ""
 }
 
+func (x *PaintFinishedRequest) GetPaintJobId() string {
+	if x != nil {
+		return x.PaintJobId
+	}
+	return ""
+}
+
+func (x *PaintFinishedRequest) GetCallbackToken() string {
+	if x != nil {
+		return x.CallbackToken
+	}
+	return ""
+}
+
 type RetrieveCarRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -308,18 +566,19 @@ func (x *RetrieveCarRequest) GetCarNumber() string {
 	return ""
 }
 
-type SubPaintCarRequest struct {
+type UpdateCarRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Car                    *Car   `protobuf:"bytes,1,opt,name=car,proto3" json:"car,omitempty"`
-	DesiredColor           string `protobuf:"bytes,2,opt,name=desired_color,json=desiredColor,proto3" json:"desired_color,omitempty"`
-	CallbackServiceAddress string `protobuf:"bytes,3,opt,name=callback_service_address,json=callbackServiceAddress,proto3" json:"callback_service_address,omitempty"`
+	// car.number selects the car, the fields in update_mask are replaced
+	Car *Car `protobuf:"bytes,1,opt,name=car,proto3" json:"car,omitempty"`
+	// owner and body_style can be updated, the fields set in car if empty
+	UpdateMask *field_mask.FieldMask `protobuf:"bytes,2,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
 }
 
-func (x *SubPaintCarRequest) Reset() {
-	*x = SubPaintCarRequest{}
+func (x *UpdateCarRequest) Reset() {
+	*x = UpdateCarRequest{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_api_garage_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -327,13 +586,13 @@ func (x *SubPaintCarRequest) Reset() {
 	}
 }
 
-func (x *SubPaintCarRequest) String() string {
+func (x *UpdateCarRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SubPaintCarRequest) ProtoMessage() {}
+func (*UpdateCarRequest) ProtoMessage() {}
 
-func (x *SubPaintCarRequest) ProtoReflect() protoreflect.Message {
+func (x *UpdateCarRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_api_garage_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -345,180 +604,1573 @@ func (x *SubPaintCarRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SubPaintCarRequest.ProtoReflect.Descriptor instead.
-func (*SubPaintCarRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpdateCarRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCarRequest) Descriptor() ([]byte, []int) {
 	return file_api_garage_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *SubPaintCarRequest) GetCar() *Car {
+func (x *UpdateCarRequest) GetCar() *Car {
 	if x != nil {
 		return x.Car
 	}
 	return nil
 }
 
-func (x *SubPaintCarRequest) GetDesiredColor() string {
+func (x *UpdateCarRequest) GetUpdateMask() *field_mask.FieldMask {
 	if x != nil {
-		return x.DesiredColor
+		return x.UpdateMask
 	}
-	return ""
+	return nil
 }
 
-func (x *SubPaintCarRequest) GetCallbackServiceAddress() string {
+// All filters are optional, cars must match every filter that is set
+type ListCarsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Owner      string    `protobuf:"bytes,1,opt,name=owner,proto3" json:"owner,omitempty"`
+	BodyStyles []CarBody `protobuf:"varint,2,rep,packed,name=body_styles,json=bodyStyles,proto3,enum=tutorial.workshop.CarBody" json:"body_styles,omitempty"`
+	// current color of the car
+	Color   string              `protobuf:"bytes,3,opt,name=color,proto3" json:"color,omitempty"`
+	Painted *wrappers.BoolValue `protobuf:"bytes,4,opt,name=painted,proto3" json:"painted,omitempty"`
+	// maximum number of cars to return, server default is used if not set
+	PageSize int32 `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token of the previous response
+	PageToken string `protobuf:"bytes,6,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+}
+
+func (x *ListCarsRequest) Reset() {
+	*x = ListCarsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListCarsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCarsRequest) ProtoMessage() {}
+
+func (x *ListCarsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCarsRequest.ProtoReflect.Descriptor instead.
+func (*ListCarsRequest) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListCarsRequest) GetOwner() string {
 	if x != nil {
-		return x.CallbackServiceAddress
+		return x.Owner
 	}
 	return ""
 }
 
-var File_api_garage_proto protoreflect.FileDescriptor
+func (x *ListCarsRequest) GetBodyStyles() []CarBody {
+	if x != nil {
+		return x.BodyStyles
+	}
+	return nil
+}
 
-var file_api_garage_proto_rawDesc = []byte{
-	0x0a, 0x10, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x61, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x12, 0x11, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72,
-	0x6b, 0x73, 0x68, 0x6f, 0x70, 0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x61, 0x70,
-	0x69, 0x2f, 0x61, 0x6e, 0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x22, 0xb4, 0x01, 0x0a, 0x03, 0x43, 0x61, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x75, 0x6d, 0x62,
-	0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72,
-	0x12, 0x14, 0x0a, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x12, 0x3a, 0x0a, 0x0a, 0x62, 0x6f, 0x64, 0x79, 0x5f, 0x73,
-	0x74, 0x79, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1b, 0x2e, 0x74, 0x75, 0x74,
-	0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43,
-	0x61, 0x72, 0x2e, 0x62, 0x6f, 0x64, 0x79, 0x52, 0x09, 0x62, 0x6f, 0x64, 0x79, 0x53, 0x74, 0x79,
-	0x6c, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x22, 0x2d, 0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79,
-	0x12, 0x09, 0x0a, 0x05, 0x53, 0x45, 0x44, 0x41, 0x4e, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x50,
-	0x48, 0x41, 0x45, 0x54, 0x4f, 0x4e, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09, 0x48, 0x41, 0x54, 0x43,
-	0x48, 0x42, 0x41, 0x43, 0x4b, 0x10, 0x02, 0x22, 0x55, 0x0a, 0x0f, 0x50, 0x61, 0x69, 0x6e, 0x74,
-	0x43, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x61,
-	0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
-	0x63, 0x61, 0x72, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x73,
-	0x69, 0x72, 0x65, 0x64, 0x5f, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x0c, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x22, 0x5a,
-	0x0a, 0x14, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x61, 0x72, 0x5f, 0x6e, 0x75,
-	0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x61, 0x72, 0x4e,
-	0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64,
-	0x5f, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65,
-	0x73, 0x69, 0x72, 0x65, 0x64, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x22, 0x33, 0x0a, 0x12, 0x52, 0x65,
-	0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x43, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x61, 0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x61, 0x72, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x22,
-	0x9d, 0x01, 0x0a, 0x12, 0x53, 0x75, 0x62, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x28, 0x0a, 0x03, 0x63, 0x61, 0x72, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77,
-	0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72, 0x52, 0x03, 0x63, 0x61, 0x72,
-	0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64, 0x5f, 0x63, 0x6f, 0x6c, 0x6f,
-	0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64,
-	0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x38, 0x0a, 0x18, 0x63, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63,
-	0x6b, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73,
-	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x16, 0x63, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63,
-	0x6b, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x32,
-	0xa3, 0x03, 0x0a, 0x08, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x12, 0x59, 0x0a, 0x09,
-	0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x43, 0x61, 0x72, 0x12, 0x16, 0x2e, 0x74, 0x75, 0x74, 0x6f,
-	0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61,
-	0x72, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x1c, 0x82, 0xd3, 0xe4, 0x93, 0x02,
-	0x16, 0x22, 0x11, 0x2f, 0x76, 0x31, 0x2f, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f,
-	0x63, 0x61, 0x72, 0x73, 0x3a, 0x01, 0x2a, 0x12, 0x77, 0x0a, 0x08, 0x50, 0x61, 0x69, 0x6e, 0x74,
-	0x43, 0x61, 0x72, 0x12, 0x22, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77,
-	0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22,
-	0x2f, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x29, 0x1a, 0x24, 0x2f, 0x76, 0x31, 0x2f, 0x77, 0x6f, 0x72,
-	0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f, 0x63, 0x61, 0x72, 0x73, 0x2f, 0x7b, 0x63, 0x61, 0x72, 0x5f,
-	0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x7d, 0x2f, 0x70, 0x61, 0x69, 0x6e, 0x74, 0x3a, 0x01, 0x2a,
-	0x12, 0x74, 0x0a, 0x0b, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x43, 0x61, 0x72, 0x12,
-	0x25, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73,
-	0x68, 0x6f, 0x70, 0x2e, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x43, 0x61, 0x72, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61,
-	0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72, 0x22, 0x26,
-	0x82, 0xd3, 0xe4, 0x93, 0x02, 0x20, 0x12, 0x1e, 0x2f, 0x76, 0x31, 0x2f, 0x77, 0x6f, 0x72, 0x6b,
-	0x73, 0x68, 0x6f, 0x70, 0x2f, 0x63, 0x61, 0x72, 0x73, 0x2f, 0x7b, 0x63, 0x61, 0x72, 0x5f, 0x6e,
-	0x75, 0x6d, 0x62, 0x65, 0x72, 0x7d, 0x12, 0x4d, 0x0a, 0x0a, 0x43, 0x61, 0x72, 0x50, 0x61, 0x69,
-	0x6e, 0x74, 0x65, 0x64, 0x12, 0x27, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e,
-	0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x46, 0x69,
-	0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e,
-	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
-	0x45, 0x6d, 0x70, 0x74, 0x79, 0x32, 0x7a, 0x0a, 0x0b, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b,
-	0x73, 0x68, 0x6f, 0x70, 0x12, 0x6b, 0x0a, 0x08, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72,
-	0x12, 0x25, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b,
-	0x73, 0x68, 0x6f, 0x70, 0x2e, 0x53, 0x75, 0x62, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22,
-	0x20, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1a, 0x22, 0x15, 0x2f, 0x76, 0x31, 0x2f, 0x73, 0x75, 0x62,
-	0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f, 0x70, 0x61, 0x69, 0x6e, 0x74, 0x3a, 0x01,
-	0x2a, 0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x3b, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x62,
-	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+func (x *ListCarsRequest) GetColor() string {
+	if x != nil {
+		return x.Color
+	}
+	return ""
 }
 
-var (
-	file_api_garage_proto_rawDescOnce sync.Once
-	file_api_garage_proto_rawDescData = file_api_garage_proto_rawDesc
-)
+func (x *ListCarsRequest) GetPainted() *wrappers.BoolValue {
+	if x != nil {
+		return x.Painted
+	}
+	return nil
+}
 
-func file_api_garage_proto_rawDescGZIP() []byte {
-	file_api_garage_proto_rawDescOnce.Do(func() {
-		file_api_garage_proto_rawDescData = protoimpl.X.CompressGZIP(file_api_garage_proto_rawDescData)
-	})
-	return file_api_garage_proto_rawDescData
+func (x *ListCarsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
 }
 
-var file_api_garage_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_api_garage_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
-var file_api_garage_proto_goTypes = []interface{}{
-	(CarBody)(0),                 // 0: tutorial.workshop.Car.body
-	(*Car)(nil),                  // 1: tutorial.workshop.Car
-	(*PaintCarRequest)(nil),      // 2: tutorial.workshop.PaintCarRequest
-	(*PaintFinishedRequest)(nil), // 3: tutorial.workshop.PaintFinishedRequest
-	(*RetrieveCarRequest)(nil),   // 4: tutorial.workshop.RetrieveCarRequest
-	(*SubPaintCarRequest)(nil),   // 5: tutorial.workshop.SubPaintCarRequest
-	(*empty.Empty)(nil),          // 6: google.protobuf.Empty
+func (x *ListCarsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
 }
-var file_api_garage_proto_depIdxs = []int32{
-	0, // 0: tutorial.workshop.Car.body_style:type_name -> tutorial.workshop.Car.body
-	1, // 1: tutorial.workshop.SubPaintCarRequest.car:type_name -> tutorial.workshop.Car
-	1, // 2: tutorial.workshop.Workshop.AcceptCar:input_type -> tutorial.workshop.Car
-	2, // 3: tutorial.workshop.Workshop.PaintCar:input_type -> tutorial.workshop.PaintCarRequest
-	4, // 4: tutorial.workshop.Workshop.RetrieveCar:input_type -> tutorial.workshop.RetrieveCarRequest
-	3, // 5: tutorial.workshop.Workshop.CarPainted:input_type -> tutorial.workshop.PaintFinishedRequest
-	5, // 6: tutorial.workshop.SubWorkshop.PaintCar:input_type -> tutorial.workshop.SubPaintCarRequest
-	6, // 7: tutorial.workshop.Workshop.AcceptCar:output_type -> google.protobuf.Empty
-	6, // 8: tutorial.workshop.Workshop.PaintCar:output_type -> google.protobuf.Empty
-	1, // 9: tutorial.workshop.Workshop.RetrieveCar:output_type -> tutorial.workshop.Car
-	6, // 10: tutorial.workshop.Workshop.CarPainted:output_type -> google.protobuf.Empty
-	6, // 11: tutorial.workshop.SubWorkshop.PaintCar:output_type -> google.protobuf.Empty
-	7, // [7:12] is the sub-list for method output_type
-	2, // [2:7] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+
+type ListCarsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cars []*Car `protobuf:"bytes,1,rep,name=cars,proto3" json:"cars,omitempty"`
+	// empty when there are no more cars
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
 }
 
-func init() { file_api_garage_proto_init() }
-func file_api_garage_proto_init() {
-	if File_api_garage_proto != nil {
-		return
+func (x *ListCarsResponse) Reset() {
+	*x = ListCarsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_api_garage_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Car); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
+}
+
+func (x *ListCarsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCarsResponse) ProtoMessage() {}
+
+func (x *ListCarsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
 		}
-		file_api_garage_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PaintCarRequest); i {
-			case 0:
-				return &v.state
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCarsResponse.ProtoReflect.Descriptor instead.
+func (*ListCarsResponse) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListCarsResponse) GetCars() []*Car {
+	if x != nil {
+		return x.Cars
+	}
+	return nil
+}
+
+func (x *ListCarsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type GetCarHistoryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CarNumber string `protobuf:"bytes,1,opt,name=car_number,json=carNumber,proto3" json:"car_number,omitempty"`
+	// maximum number of changes to return, server default is used if not set
+	PageSize int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token of the previous response
+	PageToken string `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+}
+
+func (x *GetCarHistoryRequest) Reset() {
+	*x = GetCarHistoryRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCarHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCarHistoryRequest) ProtoMessage() {}
+
+func (x *GetCarHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCarHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetCarHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetCarHistoryRequest) GetCarNumber() string {
+	if x != nil {
+		return x.CarNumber
+	}
+	return ""
+}
+
+func (x *GetCarHistoryRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *GetCarHistoryRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// A change of the state of a car
+type CarHistoryEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// UNKNOWN when the car was accepted
+	OldState CarLifecycle `protobuf:"varint,1,opt,name=old_state,json=oldState,proto3,enum=tutorial.workshop.CarLifecycle" json:"old_state,omitempty"`
+	NewState CarLifecycle `protobuf:"varint,2,opt,name=new_state,json=newState,proto3,enum=tutorial.workshop.CarLifecycle" json:"new_state,omitempty"`
+	OldColor string       `protobuf:"bytes,3,opt,name=old_color,json=oldColor,proto3" json:"old_color,omitempty"`
+	NewColor string       `protobuf:"bytes,4,opt,name=new_color,json=newColor,proto3" json:"new_color,omitempty"`
+	// who made the change, the workshop itself for changes it makes in the background, empty without authentication
+	Actor string `protobuf:"bytes,5,opt,name=actor,proto3" json:"actor,omitempty"`
+	// name of the sub workshop painting the car, if any
+	SubWorkshop string `protobuf:"bytes,6,opt,name=sub_workshop,json=subWorkshop,proto3" json:"sub_workshop,omitempty"`
+	// trace of the request that made the change, if it was traced
+	TraceId string               `protobuf:"bytes,7,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	Time    *timestamp.Timestamp `protobuf:"bytes,8,opt,name=time,proto3" json:"time,omitempty"`
+	// etag of the car after the change
+	Etag string `protobuf:"bytes,9,opt,name=etag,proto3" json:"etag,omitempty"`
+}
+
+func (x *CarHistoryEntry) Reset() {
+	*x = CarHistoryEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CarHistoryEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CarHistoryEntry) ProtoMessage() {}
+
+func (x *CarHistoryEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CarHistoryEntry.ProtoReflect.Descriptor instead.
+func (*CarHistoryEntry) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CarHistoryEntry) GetOldState() CarLifecycle {
+	if x != nil {
+		return x.OldState
+	}
+	return Car_UNKNOWN
+}
+
+func (x *CarHistoryEntry) GetNewState() CarLifecycle {
+	if x != nil {
+		return x.NewState
+	}
+	return Car_UNKNOWN
+}
+
+func (x *CarHistoryEntry) GetOldColor() string {
+	if x != nil {
+		return x.OldColor
+	}
+	return ""
+}
+
+func (x *CarHistoryEntry) GetNewColor() string {
+	if x != nil {
+		return x.NewColor
+	}
+	return ""
+}
+
+func (x *CarHistoryEntry) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *CarHistoryEntry) GetSubWorkshop() string {
+	if x != nil {
+		return x.SubWorkshop
+	}
+	return ""
+}
+
+func (x *CarHistoryEntry) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *CarHistoryEntry) GetTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *CarHistoryEntry) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+type GetCarHistoryResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// oldest change first, the history of a car is kept after it's retrieved
+	Entries []*CarHistoryEntry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	// empty when there are no more changes
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+}
+
+func (x *GetCarHistoryResponse) Reset() {
+	*x = GetCarHistoryResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCarHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCarHistoryResponse) ProtoMessage() {}
+
+func (x *GetCarHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCarHistoryResponse.ProtoReflect.Descriptor instead.
+func (*GetCarHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetCarHistoryResponse) GetEntries() []*CarHistoryEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *GetCarHistoryResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type WatchCarsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// only events of this car, events of all cars if not set
+	CarNumber string `protobuf:"bytes,1,opt,name=car_number,json=carNumber,proto3" json:"car_number,omitempty"`
+	// resume after this sequence number, when not set only new events are sent.
+	// OUT_OF_RANGE when the events after it are no longer kept, e.g. after a restart
+	AfterSequence uint64 `protobuf:"varint,2,opt,name=after_sequence,json=afterSequence,proto3" json:"after_sequence,omitempty"`
+}
+
+func (x *WatchCarsRequest) Reset() {
+	*x = WatchCarsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchCarsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchCarsRequest) ProtoMessage() {}
+
+func (x *WatchCarsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchCarsRequest.ProtoReflect.Descriptor instead.
+func (*WatchCarsRequest) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *WatchCarsRequest) GetCarNumber() string {
+	if x != nil {
+		return x.CarNumber
+	}
+	return ""
+}
+
+func (x *WatchCarsRequest) GetAfterSequence() uint64 {
+	if x != nil {
+		return x.AfterSequence
+	}
+	return 0
+}
+
+type CarEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// increases by one with every event, use it to resume watching
+	Sequence  uint64               `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	EventType CarEventType         `protobuf:"varint,2,opt,name=event_type,json=eventType,proto3,enum=tutorial.workshop.CarEventType" json:"event_type,omitempty"`
+	Car       *Car                 `protobuf:"bytes,3,opt,name=car,proto3" json:"car,omitempty"`
+	Time      *timestamp.Timestamp `protobuf:"bytes,4,opt,name=time,proto3" json:"time,omitempty"`
+}
+
+func (x *CarEvent) Reset() {
+	*x = CarEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CarEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CarEvent) ProtoMessage() {}
+
+func (x *CarEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CarEvent.ProtoReflect.Descriptor instead.
+func (*CarEvent) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CarEvent) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *CarEvent) GetEventType() CarEventType {
+	if x != nil {
+		return x.EventType
+	}
+	return CarEvent_UNKNOWN
+}
+
+func (x *CarEvent) GetCar() *Car {
+	if x != nil {
+		return x.Car
+	}
+	return nil
+}
+
+func (x *CarEvent) GetTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+type PaintJob struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id           string         `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	CarNumber    string         `protobuf:"bytes,2,opt,name=car_number,json=carNumber,proto3" json:"car_number,omitempty"`
+	DesiredColor string         `protobuf:"bytes,3,opt,name=desired_color,json=desiredColor,proto3" json:"desired_color,omitempty"`
+	JobStatus    PaintJobStatus `protobuf:"varint,4,opt,name=job_status,json=jobStatus,proto3,enum=tutorial.workshop.PaintJobStatus" json:"job_status,omitempty"`
+	// why the job failed
+	Error      string               `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	CreateTime *timestamp.Timestamp `protobuf:"bytes,6,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	UpdateTime *timestamp.Timestamp `protobuf:"bytes,7,opt,name=update_time,json=updateTime,proto3" json:"update_time,omitempty"`
+	// set once the job reaches SUCCEEDED, FAILED or CANCELLED
+	EndTime *timestamp.Timestamp `protobuf:"bytes,8,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+}
+
+func (x *PaintJob) Reset() {
+	*x = PaintJob{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PaintJob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PaintJob) ProtoMessage() {}
+
+func (x *PaintJob) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PaintJob.ProtoReflect.Descriptor instead.
+func (*PaintJob) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PaintJob) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PaintJob) GetCarNumber() string {
+	if x != nil {
+		return x.CarNumber
+	}
+	return ""
+}
+
+func (x *PaintJob) GetDesiredColor() string {
+	if x != nil {
+		return x.DesiredColor
+	}
+	return ""
+}
+
+func (x *PaintJob) GetJobStatus() PaintJobStatus {
+	if x != nil {
+		return x.JobStatus
+	}
+	return PaintJob_UNKNOWN
+}
+
+func (x *PaintJob) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *PaintJob) GetCreateTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.CreateTime
+	}
+	return nil
+}
+
+func (x *PaintJob) GetUpdateTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.UpdateTime
+	}
+	return nil
+}
+
+func (x *PaintJob) GetEndTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.EndTime
+	}
+	return nil
+}
+
+type GetPaintJobRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GetPaintJobRequest) Reset() {
+	*x = GetPaintJobRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetPaintJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPaintJobRequest) ProtoMessage() {}
+
+func (x *GetPaintJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPaintJobRequest.ProtoReflect.Descriptor instead.
+func (*GetPaintJobRequest) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetPaintJobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListPaintJobsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// only jobs of this car, jobs of all cars if not set
+	CarNumber string `protobuf:"bytes,1,opt,name=car_number,json=carNumber,proto3" json:"car_number,omitempty"`
+	// maximum number of jobs to return, server default is used if not set
+	PageSize int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token of the previous response
+	PageToken string `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+}
+
+func (x *ListPaintJobsRequest) Reset() {
+	*x = ListPaintJobsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListPaintJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaintJobsRequest) ProtoMessage() {}
+
+func (x *ListPaintJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaintJobsRequest.ProtoReflect.Descriptor instead.
+func (*ListPaintJobsRequest) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListPaintJobsRequest) GetCarNumber() string {
+	if x != nil {
+		return x.CarNumber
+	}
+	return ""
+}
+
+func (x *ListPaintJobsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListPaintJobsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListPaintJobsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// oldest job first
+	Jobs []*PaintJob `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	// empty when there are no more jobs
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+}
+
+func (x *ListPaintJobsResponse) Reset() {
+	*x = ListPaintJobsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListPaintJobsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaintJobsResponse) ProtoMessage() {}
+
+func (x *ListPaintJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaintJobsResponse.ProtoReflect.Descriptor instead.
+func (*ListPaintJobsResponse) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListPaintJobsResponse) GetJobs() []*PaintJob {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+func (x *ListPaintJobsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type CancelPaintJobRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *CancelPaintJobRequest) Reset() {
+	*x = CancelPaintJobRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CancelPaintJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelPaintJobRequest) ProtoMessage() {}
+
+func (x *CancelPaintJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelPaintJobRequest.ProtoReflect.Descriptor instead.
+func (*CancelPaintJobRequest) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CancelPaintJobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// A sub workshop cars are sent to be painted at
+type SubWorkshopRegistration struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// registering a sub workshop with the same name replaces it
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// host:port, the REST or gRPC port depending on the transport
+	Address       string                           `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	TransportType SubWorkshopRegistrationTransport `protobuf:"varint,3,opt,name=transport_type,json=transportType,proto3,enum=tutorial.workshop.SubWorkshopRegistrationTransport" json:"transport_type,omitempty"`
+	// colors it can paint, any color if empty
+	Colors []string `protobuf:"bytes,4,rep,name=colors,proto3" json:"colors,omitempty"`
+	// body styles it can paint, any body style if empty
+	BodyStyles []CarBody `protobuf:"varint,5,rep,packed,name=body_styles,json=bodyStyles,proto3,enum=tutorial.workshop.CarBody" json:"body_styles,omitempty"`
+}
+
+func (x *SubWorkshopRegistration) Reset() {
+	*x = SubWorkshopRegistration{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubWorkshopRegistration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubWorkshopRegistration) ProtoMessage() {}
+
+func (x *SubWorkshopRegistration) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubWorkshopRegistration.ProtoReflect.Descriptor instead.
+func (*SubWorkshopRegistration) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SubWorkshopRegistration) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SubWorkshopRegistration) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *SubWorkshopRegistration) GetTransportType() SubWorkshopRegistrationTransport {
+	if x != nil {
+		return x.TransportType
+	}
+	return SubWorkshopRegistration_REST
+}
+
+func (x *SubWorkshopRegistration) GetColors() []string {
+	if x != nil {
+		return x.Colors
+	}
+	return nil
+}
+
+func (x *SubWorkshopRegistration) GetBodyStyles() []CarBody {
+	if x != nil {
+		return x.BodyStyles
+	}
+	return nil
+}
+
+type SubPaintCarRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Car                    *Car   `protobuf:"bytes,1,opt,name=car,proto3" json:"car,omitempty"`
+	DesiredColor           string `protobuf:"bytes,2,opt,name=desired_color,json=desiredColor,proto3" json:"desired_color,omitempty"`
+	CallbackServiceAddress string `protobuf:"bytes,3,opt,name=callback_service_address,json=callbackServiceAddress,proto3" json:"callback_service_address,omitempty"`
+	// should be sent back in PaintFinishedRequest
+	PaintJobId string `protobuf:"bytes,4,opt,name=paint_job_id,json=paintJobId,proto3" json:"paint_job_id,omitempty"`
+	// signed by the workshop, should be sent back in PaintFinishedRequest as is
+	CallbackToken string `protobuf:"bytes,5,opt,name=callback_token,json=callbackToken,proto3" json:"callback_token,omitempty"`
+}
+
+func (x *SubPaintCarRequest) Reset() {
+	*x = SubPaintCarRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubPaintCarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubPaintCarRequest) ProtoMessage() {}
+
+func (x *SubPaintCarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubPaintCarRequest.ProtoReflect.Descriptor instead.
+func (*SubPaintCarRequest) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SubPaintCarRequest) GetCar() *Car {
+	if x != nil {
+		return x.Car
+	}
+	return nil
+}
+
+func (x *SubPaintCarRequest) GetDesiredColor() string {
+	if x != nil {
+		return x.DesiredColor
+	}
+	return ""
+}
+
+func (x *SubPaintCarRequest) GetCallbackServiceAddress() string {
+	if x != nil {
+		return x.CallbackServiceAddress
+	}
+	return ""
+}
+
+func (x *SubPaintCarRequest) GetPaintJobId() string {
+	if x != nil {
+		return x.PaintJobId
+	}
+	return ""
+}
+
+func (x *SubPaintCarRequest) GetCallbackToken() string {
+	if x != nil {
+		return x.CallbackToken
+	}
+	return ""
+}
+
+var File_api_garage_proto protoreflect.FileDescriptor
+
+var file_api_garage_proto_rawDesc = []byte{
+	0x0a, 0x10, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x61, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x11, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72,
+	0x6b, 0x73, 0x68, 0x6f, 0x70, 0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x61, 0x70,
+	0x69, 0x2f, 0x61, 0x6e, 0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x1a, 0x20, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2f, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x5f, 0x6d, 0x61, 0x73, 0x6b, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x77, 0x72, 0x61, 0x70, 0x70, 0x65, 0x72, 0x73, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x22, 0xe7, 0x02, 0x0a, 0x03, 0x43, 0x61, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x6e,
+	0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6e, 0x75, 0x6d,
+	0x62, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x12, 0x3a, 0x0a, 0x0a, 0x62, 0x6f, 0x64,
+	0x79, 0x5f, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1b, 0x2e,
+	0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f,
+	0x70, 0x2e, 0x43, 0x61, 0x72, 0x2e, 0x62, 0x6f, 0x64, 0x79, 0x52, 0x09, 0x62, 0x6f, 0x64, 0x79,
+	0x53, 0x74, 0x79, 0x6c, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x36, 0x0a, 0x05, 0x73,
+	0x74, 0x61, 0x74, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x20, 0x2e, 0x74, 0x75, 0x74,
+	0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43,
+	0x61, 0x72, 0x2e, 0x6c, 0x69, 0x66, 0x65, 0x63, 0x79, 0x63, 0x6c, 0x65, 0x52, 0x05, 0x73, 0x74,
+	0x61, 0x74, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x65, 0x74, 0x61, 0x67, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x65, 0x74, 0x61, 0x67, 0x22, 0x2d, 0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x12,
+	0x09, 0x0a, 0x05, 0x53, 0x45, 0x44, 0x41, 0x4e, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x50, 0x48,
+	0x41, 0x45, 0x54, 0x4f, 0x4e, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09, 0x48, 0x41, 0x54, 0x43, 0x48,
+	0x42, 0x41, 0x43, 0x4b, 0x10, 0x02, 0x22, 0x65, 0x0a, 0x09, 0x6c, 0x69, 0x66, 0x65, 0x63, 0x79,
+	0x63, 0x6c, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00,
+	0x12, 0x0c, 0x0a, 0x08, 0x41, 0x43, 0x43, 0x45, 0x50, 0x54, 0x45, 0x44, 0x10, 0x01, 0x12, 0x13,
+	0x0a, 0x0f, 0x50, 0x41, 0x49, 0x4e, 0x54, 0x5f, 0x52, 0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x45,
+	0x44, 0x10, 0x02, 0x12, 0x0c, 0x0a, 0x08, 0x50, 0x41, 0x49, 0x4e, 0x54, 0x49, 0x4e, 0x47, 0x10,
+	0x03, 0x12, 0x0b, 0x0a, 0x07, 0x50, 0x41, 0x49, 0x4e, 0x54, 0x45, 0x44, 0x10, 0x04, 0x12, 0x0d,
+	0x0a, 0x09, 0x52, 0x45, 0x54, 0x52, 0x49, 0x45, 0x56, 0x45, 0x44, 0x10, 0x05, 0x22, 0x55, 0x0a,
+	0x0f, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x61, 0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x61, 0x72, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12,
+	0x23, 0x0a, 0x0d, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64, 0x5f, 0x63, 0x6f, 0x6c, 0x6f, 0x72,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64, 0x43,
+	0x6f, 0x6c, 0x6f, 0x72, 0x22, 0xa3, 0x01, 0x0a, 0x14, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x46, 0x69,
+	0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a,
+	0x0a, 0x63, 0x61, 0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x63, 0x61, 0x72, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d,
+	0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64, 0x5f, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64, 0x43, 0x6f, 0x6c, 0x6f,
+	0x72, 0x12, 0x20, 0x0a, 0x0c, 0x70, 0x61, 0x69, 0x6e, 0x74, 0x5f, 0x6a, 0x6f, 0x62, 0x5f, 0x69,
+	0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x70, 0x61, 0x69, 0x6e, 0x74, 0x4a, 0x6f,
+	0x62, 0x49, 0x64, 0x12, 0x25, 0x0a, 0x0e, 0x63, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x5f,
+	0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x63, 0x61, 0x6c,
+	0x6c, 0x62, 0x61, 0x63, 0x6b, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x33, 0x0a, 0x12, 0x52, 0x65,
+	0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x43, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x61, 0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x61, 0x72, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x22,
+	0x79, 0x0a, 0x10, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x28, 0x0a, 0x03, 0x63, 0x61, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x16, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b,
+	0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72, 0x52, 0x03, 0x63, 0x61, 0x72, 0x12, 0x3b, 0x0a,
+	0x0b, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x5f, 0x6d, 0x61, 0x73, 0x6b, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4d, 0x61, 0x73, 0x6b, 0x52, 0x0a,
+	0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4d, 0x61, 0x73, 0x6b, 0x22, 0xed, 0x01, 0x0a, 0x0f, 0x4c,
+	0x69, 0x73, 0x74, 0x43, 0x61, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14,
+	0x0a, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6f,
+	0x77, 0x6e, 0x65, 0x72, 0x12, 0x3c, 0x0a, 0x0b, 0x62, 0x6f, 0x64, 0x79, 0x5f, 0x73, 0x74, 0x79,
+	0x6c, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x1b, 0x2e, 0x74, 0x75, 0x74, 0x6f,
+	0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61,
+	0x72, 0x2e, 0x62, 0x6f, 0x64, 0x79, 0x52, 0x0a, 0x62, 0x6f, 0x64, 0x79, 0x53, 0x74, 0x79, 0x6c,
+	0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x34, 0x0a, 0x07, 0x70, 0x61, 0x69, 0x6e,
+	0x74, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x42, 0x6f, 0x6f, 0x6c,
+	0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x07, 0x70, 0x61, 0x69, 0x6e, 0x74, 0x65, 0x64, 0x12, 0x1b,
+	0x0a, 0x09, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70,
+	0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x70, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x66, 0x0a, 0x10, 0x4c, 0x69,
+	0x73, 0x74, 0x43, 0x61, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2a,
+	0x0a, 0x04, 0x63, 0x61, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x74,
+	0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70,
+	0x2e, 0x43, 0x61, 0x72, 0x52, 0x04, 0x63, 0x61, 0x72, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6e, 0x65,
+	0x78, 0x74, 0x5f, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0d, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b,
+	0x65, 0x6e, 0x22, 0x71, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x43, 0x61, 0x72, 0x48, 0x69, 0x73, 0x74,
+	0x6f, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x61,
+	0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x63, 0x61, 0x72, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x67,
+	0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x61,
+	0x67, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74,
+	0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x61, 0x67, 0x65,
+	0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0xe1, 0x02, 0x0a, 0x0f, 0x43, 0x61, 0x72, 0x48, 0x69, 0x73,
+	0x74, 0x6f, 0x72, 0x79, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x3d, 0x0a, 0x09, 0x6f, 0x6c, 0x64,
+	0x5f, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x20, 0x2e, 0x74,
+	0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70,
+	0x2e, 0x43, 0x61, 0x72, 0x2e, 0x6c, 0x69, 0x66, 0x65, 0x63, 0x79, 0x63, 0x6c, 0x65, 0x52, 0x08,
+	0x6f, 0x6c, 0x64, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x3d, 0x0a, 0x09, 0x6e, 0x65, 0x77, 0x5f,
+	0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x20, 0x2e, 0x74, 0x75,
+	0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e,
+	0x43, 0x61, 0x72, 0x2e, 0x6c, 0x69, 0x66, 0x65, 0x63, 0x79, 0x63, 0x6c, 0x65, 0x52, 0x08, 0x6e,
+	0x65, 0x77, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x6f, 0x6c, 0x64, 0x5f, 0x63,
+	0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6f, 0x6c, 0x64, 0x43,
+	0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x1b, 0x0a, 0x09, 0x6e, 0x65, 0x77, 0x5f, 0x63, 0x6f, 0x6c, 0x6f,
+	0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6e, 0x65, 0x77, 0x43, 0x6f, 0x6c, 0x6f,
+	0x72, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x75, 0x62, 0x5f, 0x77,
+	0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x73,
+	0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x72,
+	0x61, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x74, 0x72,
+	0x61, 0x63, 0x65, 0x49, 0x64, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52,
+	0x04, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x65, 0x74, 0x61, 0x67, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x65, 0x74, 0x61, 0x67, 0x22, 0x7d, 0x0a, 0x15, 0x47, 0x65, 0x74,
+	0x43, 0x61, 0x72, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x3c, 0x0a, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77,
+	0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72, 0x48, 0x69, 0x73, 0x74, 0x6f,
+	0x72, 0x79, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73,
+	0x12, 0x26, 0x0a, 0x0f, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f,
+	0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6e, 0x65, 0x78, 0x74, 0x50,
+	0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x58, 0x0a, 0x10, 0x57, 0x61, 0x74, 0x63,
+	0x68, 0x43, 0x61, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a,
+	0x63, 0x61, 0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x63, 0x61, 0x72, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x25, 0x0a, 0x0e, 0x61,
+	0x66, 0x74, 0x65, 0x72, 0x5f, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0d, 0x61, 0x66, 0x74, 0x65, 0x72, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e,
+	0x63, 0x65, 0x22, 0x95, 0x02, 0x0a, 0x08, 0x43, 0x61, 0x72, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12,
+	0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x3f, 0x0a, 0x0a, 0x65,
+	0x76, 0x65, 0x6e, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x20, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73,
+	0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x2e, 0x74, 0x79, 0x70,
+	0x65, 0x52, 0x09, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x28, 0x0a, 0x03,
+	0x63, 0x61, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x74, 0x75, 0x74, 0x6f,
+	0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61,
+	0x72, 0x52, 0x03, 0x63, 0x61, 0x72, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x22, 0x52, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x0b,
+	0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x41,
+	0x43, 0x43, 0x45, 0x50, 0x54, 0x45, 0x44, 0x10, 0x01, 0x12, 0x13, 0x0a, 0x0f, 0x50, 0x41, 0x49,
+	0x4e, 0x54, 0x5f, 0x52, 0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x45, 0x44, 0x10, 0x02, 0x12, 0x0b,
+	0x0a, 0x07, 0x50, 0x41, 0x49, 0x4e, 0x54, 0x45, 0x44, 0x10, 0x03, 0x12, 0x0d, 0x0a, 0x09, 0x52,
+	0x45, 0x54, 0x52, 0x49, 0x45, 0x56, 0x45, 0x44, 0x10, 0x04, 0x22, 0xc2, 0x03, 0x0a, 0x08, 0x50,
+	0x61, 0x69, 0x6e, 0x74, 0x4a, 0x6f, 0x62, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x61, 0x72, 0x5f, 0x6e,
+	0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x61, 0x72,
+	0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65,
+	0x64, 0x5f, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64,
+	0x65, 0x73, 0x69, 0x72, 0x65, 0x64, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x41, 0x0a, 0x0a, 0x6a,
+	0x6f, 0x62, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x22, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73,
+	0x68, 0x6f, 0x70, 0x2e, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x4a, 0x6f, 0x62, 0x2e, 0x73, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x52, 0x09, 0x6a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x14,
+	0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x12, 0x3b, 0x0a, 0x0b, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x5f, 0x74,
+	0x69, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x54, 0x69, 0x6d,
+	0x65, 0x12, 0x3b, 0x0a, 0x0b, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x0a, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x35,
+	0x0a, 0x08, 0x65, 0x6e, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x07, 0x65, 0x6e,
+	0x64, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x58, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
+	0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06,
+	0x51, 0x55, 0x45, 0x55, 0x45, 0x44, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x52, 0x55, 0x4e, 0x4e,
+	0x49, 0x4e, 0x47, 0x10, 0x02, 0x12, 0x0d, 0x0a, 0x09, 0x53, 0x55, 0x43, 0x43, 0x45, 0x45, 0x44,
+	0x45, 0x44, 0x10, 0x03, 0x12, 0x0a, 0x0a, 0x06, 0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x04,
+	0x12, 0x0d, 0x0a, 0x09, 0x43, 0x41, 0x4e, 0x43, 0x45, 0x4c, 0x4c, 0x45, 0x44, 0x10, 0x05, 0x22,
+	0x24, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x4a, 0x6f, 0x62, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x02, 0x69, 0x64, 0x22, 0x71, 0x0a, 0x14, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x61, 0x69,
+	0x6e, 0x74, 0x4a, 0x6f, 0x62, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a,
+	0x0a, 0x63, 0x61, 0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x63, 0x61, 0x72, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x1b, 0x0a, 0x09,
+	0x70, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x08, 0x70, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x61, 0x67,
+	0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70,
+	0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x70, 0x0a, 0x15, 0x4c, 0x69, 0x73, 0x74,
+	0x50, 0x61, 0x69, 0x6e, 0x74, 0x4a, 0x6f, 0x62, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x2f, 0x0a, 0x04, 0x6a, 0x6f, 0x62, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x1b, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73,
+	0x68, 0x6f, 0x70, 0x2e, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x4a, 0x6f, 0x62, 0x52, 0x04, 0x6a, 0x6f,
+	0x62, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70, 0x61, 0x67, 0x65, 0x5f,
+	0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6e, 0x65, 0x78,
+	0x74, 0x50, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x27, 0x0a, 0x15, 0x43, 0x61,
+	0x6e, 0x63, 0x65, 0x6c, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x02, 0x69, 0x64, 0x22, 0x9b, 0x02, 0x0a, 0x17, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b, 0x73,
+	0x68, 0x6f, 0x70, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x5b, 0x0a,
+	0x0e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x34, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c,
+	0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72,
+	0x6b, 0x73, 0x68, 0x6f, 0x70, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x0d, 0x74, 0x72, 0x61,
+	0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6f,
+	0x6c, 0x6f, 0x72, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x63, 0x6f, 0x6c, 0x6f,
+	0x72, 0x73, 0x12, 0x3c, 0x0a, 0x0b, 0x62, 0x6f, 0x64, 0x79, 0x5f, 0x73, 0x74, 0x79, 0x6c, 0x65,
+	0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x1b, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69,
+	0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72, 0x2e,
+	0x62, 0x6f, 0x64, 0x79, 0x52, 0x0a, 0x62, 0x6f, 0x64, 0x79, 0x53, 0x74, 0x79, 0x6c, 0x65, 0x73,
+	0x22, 0x1f, 0x0a, 0x09, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x08, 0x0a,
+	0x04, 0x52, 0x45, 0x53, 0x54, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x47, 0x52, 0x50, 0x43, 0x10,
+	0x01, 0x22, 0xe6, 0x01, 0x0a, 0x12, 0x53, 0x75, 0x62, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61,
+	0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x28, 0x0a, 0x03, 0x63, 0x61, 0x72, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c,
+	0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72, 0x52, 0x03, 0x63,
+	0x61, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64, 0x5f, 0x63, 0x6f,
+	0x6c, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65, 0x73, 0x69, 0x72,
+	0x65, 0x64, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x38, 0x0a, 0x18, 0x63, 0x61, 0x6c, 0x6c, 0x62,
+	0x61, 0x63, 0x6b, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x61, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x16, 0x63, 0x61, 0x6c, 0x6c, 0x62,
+	0x61, 0x63, 0x6b, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73,
+	0x73, 0x12, 0x20, 0x0a, 0x0c, 0x70, 0x61, 0x69, 0x6e, 0x74, 0x5f, 0x6a, 0x6f, 0x62, 0x5f, 0x69,
+	0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x70, 0x61, 0x69, 0x6e, 0x74, 0x4a, 0x6f,
+	0x62, 0x49, 0x64, 0x12, 0x25, 0x0a, 0x0e, 0x63, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x5f,
+	0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x63, 0x61, 0x6c,
+	0x6c, 0x62, 0x61, 0x63, 0x6b, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x32, 0x91, 0x0b, 0x0a, 0x08, 0x57,
+	0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x12, 0x59, 0x0a, 0x09, 0x41, 0x63, 0x63, 0x65, 0x70,
+	0x74, 0x43, 0x61, 0x72, 0x12, 0x16, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e,
+	0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72, 0x1a, 0x16, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x22, 0x1c, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x16, 0x22, 0x11, 0x2f, 0x76,
+	0x31, 0x2f, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f, 0x63, 0x61, 0x72, 0x73, 0x3a,
+	0x01, 0x2a, 0x12, 0x7c, 0x0a, 0x08, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72, 0x12, 0x22,
+	0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68,
+	0x6f, 0x70, 0x2e, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f,
+	0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x4a, 0x6f, 0x62, 0x22,
+	0x2f, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x29, 0x1a, 0x24, 0x2f, 0x76, 0x31, 0x2f, 0x77, 0x6f, 0x72,
+	0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f, 0x63, 0x61, 0x72, 0x73, 0x2f, 0x7b, 0x63, 0x61, 0x72, 0x5f,
+	0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x7d, 0x2f, 0x70, 0x61, 0x69, 0x6e, 0x74, 0x3a, 0x01, 0x2a,
+	0x12, 0x74, 0x0a, 0x0b, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x43, 0x61, 0x72, 0x12,
+	0x25, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73,
+	0x68, 0x6f, 0x70, 0x2e, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x43, 0x61, 0x72, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61,
+	0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72, 0x22, 0x26,
+	0x82, 0xd3, 0xe4, 0x93, 0x02, 0x20, 0x12, 0x1e, 0x2f, 0x76, 0x31, 0x2f, 0x77, 0x6f, 0x72, 0x6b,
+	0x73, 0x68, 0x6f, 0x70, 0x2f, 0x63, 0x61, 0x72, 0x73, 0x2f, 0x7b, 0x63, 0x61, 0x72, 0x5f, 0x6e,
+	0x75, 0x6d, 0x62, 0x65, 0x72, 0x7d, 0x12, 0x75, 0x0a, 0x09, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x43, 0x61, 0x72, 0x12, 0x23, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77,
+	0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x61,
+	0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72,
+	0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72,
+	0x22, 0x2b, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x25, 0x32, 0x1e, 0x2f, 0x76, 0x31, 0x2f, 0x77, 0x6f,
+	0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f, 0x63, 0x61, 0x72, 0x73, 0x2f, 0x7b, 0x63, 0x61, 0x72,
+	0x2e, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x7d, 0x3a, 0x03, 0x63, 0x61, 0x72, 0x12, 0x92, 0x01,
+	0x0a, 0x0d, 0x47, 0x65, 0x74, 0x43, 0x61, 0x72, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x12,
+	0x27, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73,
+	0x68, 0x6f, 0x70, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x61, 0x72, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72,
+	0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x28, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72,
+	0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x47, 0x65, 0x74,
+	0x43, 0x61, 0x72, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x22, 0x2e, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x28, 0x12, 0x26, 0x2f, 0x76, 0x31, 0x2f,
+	0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f, 0x63, 0x61, 0x72, 0x73, 0x2f, 0x7b, 0x63,
+	0x61, 0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x7d, 0x2f, 0x68, 0x69, 0x73, 0x74, 0x6f,
+	0x72, 0x79, 0x12, 0x6e, 0x0a, 0x08, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x61, 0x72, 0x73, 0x12, 0x22,
+	0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68,
+	0x6f, 0x70, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x61, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x23, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f,
+	0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x61, 0x72, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x19, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x13, 0x12,
+	0x11, 0x2f, 0x76, 0x31, 0x2f, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f, 0x63, 0x61,
+	0x72, 0x73, 0x12, 0x6c, 0x0a, 0x09, 0x57, 0x61, 0x74, 0x63, 0x68, 0x43, 0x61, 0x72, 0x73, 0x12,
+	0x23, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73,
+	0x68, 0x6f, 0x70, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x43, 0x61, 0x72, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e,
+	0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x22, 0x1b, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x15, 0x12, 0x13, 0x2f, 0x76, 0x31, 0x2f, 0x77,
+	0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x30, 0x01,
+	0x12, 0x71, 0x0a, 0x0b, 0x47, 0x65, 0x74, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x4a, 0x6f, 0x62, 0x12,
+	0x25, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73,
+	0x68, 0x6f, 0x70, 0x2e, 0x47, 0x65, 0x74, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x4a, 0x6f, 0x62, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61,
+	0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x50, 0x61, 0x69, 0x6e, 0x74,
+	0x4a, 0x6f, 0x62, 0x22, 0x1e, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x18, 0x12, 0x16, 0x2f, 0x76, 0x31,
+	0x2f, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f, 0x6a, 0x6f, 0x62, 0x73, 0x2f, 0x7b,
+	0x69, 0x64, 0x7d, 0x12, 0x7d, 0x0a, 0x0d, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x61, 0x69, 0x6e, 0x74,
+	0x4a, 0x6f, 0x62, 0x73, 0x12, 0x27, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e,
+	0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x61, 0x69,
+	0x6e, 0x74, 0x4a, 0x6f, 0x62, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x28, 0x2e,
+	0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f,
+	0x70, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x4a, 0x6f, 0x62, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x19, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x13, 0x12,
+	0x11, 0x2f, 0x76, 0x31, 0x2f, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f, 0x6a, 0x6f,
+	0x62, 0x73, 0x12, 0x81, 0x01, 0x0a, 0x0e, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x50, 0x61, 0x69,
+	0x6e, 0x74, 0x4a, 0x6f, 0x62, 0x12, 0x28, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c,
+	0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c,
+	0x50, 0x61, 0x69, 0x6e, 0x74, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1b, 0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73,
+	0x68, 0x6f, 0x70, 0x2e, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x4a, 0x6f, 0x62, 0x22, 0x28, 0x82, 0xd3,
+	0xe4, 0x93, 0x02, 0x22, 0x22, 0x1d, 0x2f, 0x76, 0x31, 0x2f, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68,
+	0x6f, 0x70, 0x2f, 0x6a, 0x6f, 0x62, 0x73, 0x2f, 0x7b, 0x69, 0x64, 0x7d, 0x2f, 0x63, 0x61, 0x6e,
+	0x63, 0x65, 0x6c, 0x3a, 0x01, 0x2a, 0x12, 0x86, 0x01, 0x0a, 0x13, 0x52, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x65, 0x72, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x12, 0x2a,
+	0x2e, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68,
+	0x6f, 0x70, 0x2e, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x52, 0x65,
+	0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x22, 0x2b, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x25, 0x1a, 0x20, 0x2f, 0x76, 0x31, 0x2f,
+	0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f, 0x73, 0x75, 0x62, 0x77, 0x6f, 0x72, 0x6b,
+	0x73, 0x68, 0x6f, 0x70, 0x73, 0x2f, 0x7b, 0x6e, 0x61, 0x6d, 0x65, 0x7d, 0x3a, 0x01, 0x2a, 0x12,
+	0x4d, 0x0a, 0x0a, 0x43, 0x61, 0x72, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x65, 0x64, 0x12, 0x27, 0x2e,
+	0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f,
+	0x70, 0x2e, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x32, 0x7a,
+	0x0a, 0x0b, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x12, 0x6b, 0x0a,
+	0x08, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72, 0x12, 0x25, 0x2e, 0x74, 0x75, 0x74, 0x6f,
+	0x72, 0x69, 0x61, 0x6c, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x53, 0x75,
+	0x62, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x20, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1a,
+	0x22, 0x15, 0x2f, 0x76, 0x31, 0x2f, 0x73, 0x75, 0x62, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f,
+	0x70, 0x2f, 0x70, 0x61, 0x69, 0x6e, 0x74, 0x3a, 0x01, 0x2a, 0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x3b,
+	0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_api_garage_proto_rawDescOnce sync.Once
+	file_api_garage_proto_rawDescData = file_api_garage_proto_rawDesc
+)
+
+func file_api_garage_proto_rawDescGZIP() []byte {
+	file_api_garage_proto_rawDescOnce.Do(func() {
+		file_api_garage_proto_rawDescData = protoimpl.X.CompressGZIP(file_api_garage_proto_rawDescData)
+	})
+	return file_api_garage_proto_rawDescData
+}
+
+var file_api_garage_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_api_garage_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_api_garage_proto_goTypes = []interface{}{
+	(CarBody)(0),                          // 0: tutorial.workshop.Car.body
+	(CarLifecycle)(0),                     // 1: tutorial.workshop.Car.lifecycle
+	(CarEventType)(0),                     // 2: tutorial.workshop.CarEvent.type
+	(PaintJobStatus)(0),                   // 3: tutorial.workshop.PaintJob.status
+	(SubWorkshopRegistrationTransport)(0), // 4: tutorial.workshop.SubWorkshopRegistration.transport
+	(*Car)(nil),                           // 5: tutorial.workshop.Car
+	(*PaintCarRequest)(nil),               // 6: tutorial.workshop.PaintCarRequest
+	(*PaintFinishedRequest)(nil),          // 7: tutorial.workshop.PaintFinishedRequest
+	(*RetrieveCarRequest)(nil),            // 8: tutorial.workshop.RetrieveCarRequest
+	(*UpdateCarRequest)(nil),              // 9: tutorial.workshop.UpdateCarRequest
+	(*ListCarsRequest)(nil),               // 10: tutorial.workshop.ListCarsRequest
+	(*ListCarsResponse)(nil),              // 11: tutorial.workshop.ListCarsResponse
+	(*GetCarHistoryRequest)(nil),          // 12: tutorial.workshop.GetCarHistoryRequest
+	(*CarHistoryEntry)(nil),               // 13: tutorial.workshop.CarHistoryEntry
+	(*GetCarHistoryResponse)(nil),         // 14: tutorial.workshop.GetCarHistoryResponse
+	(*WatchCarsRequest)(nil),              // 15: tutorial.workshop.WatchCarsRequest
+	(*CarEvent)(nil),                      // 16: tutorial.workshop.CarEvent
+	(*PaintJob)(nil),                      // 17: tutorial.workshop.PaintJob
+	(*GetPaintJobRequest)(nil),            // 18: tutorial.workshop.GetPaintJobRequest
+	(*ListPaintJobsRequest)(nil),          // 19: tutorial.workshop.ListPaintJobsRequest
+	(*ListPaintJobsResponse)(nil),         // 20: tutorial.workshop.ListPaintJobsResponse
+	(*CancelPaintJobRequest)(nil),         // 21: tutorial.workshop.CancelPaintJobRequest
+	(*SubWorkshopRegistration)(nil),       // 22: tutorial.workshop.SubWorkshopRegistration
+	(*SubPaintCarRequest)(nil),            // 23: tutorial.workshop.SubPaintCarRequest
+	(*field_mask.FieldMask)(nil),          // 24: google.protobuf.FieldMask
+	(*wrappers.BoolValue)(nil),            // 25: google.protobuf.BoolValue
+	(*timestamp.Timestamp)(nil),           // 26: google.protobuf.Timestamp
+	(*empty.Empty)(nil),                   // 27: google.protobuf.Empty
+}
+var file_api_garage_proto_depIdxs = []int32{
+	0,  // 0: tutorial.workshop.Car.body_style:type_name -> tutorial.workshop.Car.body
+	1,  // 1: tutorial.workshop.Car.state:type_name -> tutorial.workshop.Car.lifecycle
+	5,  // 2: tutorial.workshop.UpdateCarRequest.car:type_name -> tutorial.workshop.Car
+	24, // 3: tutorial.workshop.UpdateCarRequest.update_mask:type_name -> google.protobuf.FieldMask
+	0,  // 4: tutorial.workshop.ListCarsRequest.body_styles:type_name -> tutorial.workshop.Car.body
+	25, // 5: tutorial.workshop.ListCarsRequest.painted:type_name -> google.protobuf.BoolValue
+	5,  // 6: tutorial.workshop.ListCarsResponse.cars:type_name -> tutorial.workshop.Car
+	1,  // 7: tutorial.workshop.CarHistoryEntry.old_state:type_name -> tutorial.workshop.Car.lifecycle
+	1,  // 8: tutorial.workshop.CarHistoryEntry.new_state:type_name -> tutorial.workshop.Car.lifecycle
+	26, // 9: tutorial.workshop.CarHistoryEntry.time:type_name -> google.protobuf.Timestamp
+	13, // 10: tutorial.workshop.GetCarHistoryResponse.entries:type_name -> tutorial.workshop.CarHistoryEntry
+	2,  // 11: tutorial.workshop.CarEvent.event_type:type_name -> tutorial.workshop.CarEvent.type
+	5,  // 12: tutorial.workshop.CarEvent.car:type_name -> tutorial.workshop.Car
+	26, // 13: tutorial.workshop.CarEvent.time:type_name -> google.protobuf.Timestamp
+	3,  // 14: tutorial.workshop.PaintJob.job_status:type_name -> tutorial.workshop.PaintJob.status
+	26, // 15: tutorial.workshop.PaintJob.create_time:type_name -> google.protobuf.Timestamp
+	26, // 16: tutorial.workshop.PaintJob.update_time:type_name -> google.protobuf.Timestamp
+	26, // 17: tutorial.workshop.PaintJob.end_time:type_name -> google.protobuf.Timestamp
+	17, // 18: tutorial.workshop.ListPaintJobsResponse.jobs:type_name -> tutorial.workshop.PaintJob
+	4,  // 19: tutorial.workshop.SubWorkshopRegistration.transport_type:type_name -> tutorial.workshop.SubWorkshopRegistration.transport
+	0,  // 20: tutorial.workshop.SubWorkshopRegistration.body_styles:type_name -> tutorial.workshop.Car.body
+	5,  // 21: tutorial.workshop.SubPaintCarRequest.car:type_name -> tutorial.workshop.Car
+	5,  // 22: tutorial.workshop.Workshop.AcceptCar:input_type -> tutorial.workshop.Car
+	6,  // 23: tutorial.workshop.Workshop.PaintCar:input_type -> tutorial.workshop.PaintCarRequest
+	8,  // 24: tutorial.workshop.Workshop.RetrieveCar:input_type -> tutorial.workshop.RetrieveCarRequest
+	9,  // 25: tutorial.workshop.Workshop.UpdateCar:input_type -> tutorial.workshop.UpdateCarRequest
+	12, // 26: tutorial.workshop.Workshop.GetCarHistory:input_type -> tutorial.workshop.GetCarHistoryRequest
+	10, // 27: tutorial.workshop.Workshop.ListCars:input_type -> tutorial.workshop.ListCarsRequest
+	15, // 28: tutorial.workshop.Workshop.WatchCars:input_type -> tutorial.workshop.WatchCarsRequest
+	18, // 29: tutorial.workshop.Workshop.GetPaintJob:input_type -> tutorial.workshop.GetPaintJobRequest
+	19, // 30: tutorial.workshop.Workshop.ListPaintJobs:input_type -> tutorial.workshop.ListPaintJobsRequest
+	21, // 31: tutorial.workshop.Workshop.CancelPaintJob:input_type -> tutorial.workshop.CancelPaintJobRequest
+	22, // 32: tutorial.workshop.Workshop.RegisterSubWorkshop:input_type -> tutorial.workshop.SubWorkshopRegistration
+	7,  // 33: tutorial.workshop.Workshop.CarPainted:input_type -> tutorial.workshop.PaintFinishedRequest
+	23, // 34: tutorial.workshop.SubWorkshop.PaintCar:input_type -> tutorial.workshop.SubPaintCarRequest
+	27, // 35: tutorial.workshop.Workshop.AcceptCar:output_type -> google.protobuf.Empty
+	17, // 36: tutorial.workshop.Workshop.PaintCar:output_type -> tutorial.workshop.PaintJob
+	5,  // 37: tutorial.workshop.Workshop.RetrieveCar:output_type -> tutorial.workshop.Car
+	5,  // 38: tutorial.workshop.Workshop.UpdateCar:output_type -> tutorial.workshop.Car
+	14, // 39: tutorial.workshop.Workshop.GetCarHistory:output_type -> tutorial.workshop.GetCarHistoryResponse
+	11, // 40: tutorial.workshop.Workshop.ListCars:output_type -> tutorial.workshop.ListCarsResponse
+	16, // 41: tutorial.workshop.Workshop.WatchCars:output_type -> tutorial.workshop.CarEvent
+	17, // 42: tutorial.workshop.Workshop.GetPaintJob:output_type -> tutorial.workshop.PaintJob
+	20, // 43: tutorial.workshop.Workshop.ListPaintJobs:output_type -> tutorial.workshop.ListPaintJobsResponse
+	17, // 44: tutorial.workshop.Workshop.CancelPaintJob:output_type -> tutorial.workshop.PaintJob
+	27, // 45: tutorial.workshop.Workshop.RegisterSubWorkshop:output_type -> google.protobuf.Empty
+	27, // 46: tutorial.workshop.Workshop.CarPainted:output_type -> google.protobuf.Empty
+	27, // 47: tutorial.workshop.SubWorkshop.PaintCar:output_type -> google.protobuf.Empty
+	35, // [35:48] is the sub-list for method output_type
+	22, // [22:35] is the sub-list for method input_type
+	22, // [22:22] is the sub-list for extension type_name
+	22, // [22:22] is the sub-list for extension extendee
+	0,  // [0:22] is the sub-list for field type_name
+}
+
+func init() { file_api_garage_proto_init() }
+func file_api_garage_proto_init() {
+	if File_api_garage_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_api_garage_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Car); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PaintCarRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PaintFinishedRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RetrieveCarRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UpdateCarRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListCarsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListCarsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetCarHistoryRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CarHistoryEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetCarHistoryResponse); i {
+			case 0:
+				return &v.state
 			case 1:
 				return &v.sizeCache
 			case 2:
@@ -527,8 +2179,8 @@ func file_api_garage_proto_init() {
 				return nil
 			}
 		}
-		file_api_garage_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PaintFinishedRequest); i {
+		file_api_garage_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchCarsRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -539,8 +2191,8 @@ func file_api_garage_proto_init() {
 				return nil
 			}
 		}
-		file_api_garage_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RetrieveCarRequest); i {
+		file_api_garage_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CarEvent); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -551,7 +2203,79 @@ func file_api_garage_proto_init() {
 				return nil
 			}
 		}
-		file_api_garage_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+		file_api_garage_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PaintJob); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetPaintJobRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListPaintJobsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListPaintJobsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CancelPaintJobRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubWorkshopRegistration); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*SubPaintCarRequest); i {
 			case 0:
 				return &v.state
@@ -569,8 +2293,8 @@ func file_api_garage_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_api_garage_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   5,
+			NumEnums:      5,
+			NumMessages:   19,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
@@ -598,8 +2322,16 @@ const _ = grpc.SupportPackageIsVersion6
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type WorkshopClient interface {
 	AcceptCar(ctx context.Context, in *Car, opts ...grpc.CallOption) (*empty.Empty, error)
-	PaintCar(ctx context.Context, in *PaintCarRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	PaintCar(ctx context.Context, in *PaintCarRequest, opts ...grpc.CallOption) (*PaintJob, error)
 	RetrieveCar(ctx context.Context, in *RetrieveCarRequest, opts ...grpc.CallOption) (*Car, error)
+	UpdateCar(ctx context.Context, in *UpdateCarRequest, opts ...grpc.CallOption) (*Car, error)
+	GetCarHistory(ctx context.Context, in *GetCarHistoryRequest, opts ...grpc.CallOption) (*GetCarHistoryResponse, error)
+	ListCars(ctx context.Context, in *ListCarsRequest, opts ...grpc.CallOption) (*ListCarsResponse, error)
+	WatchCars(ctx context.Context, in *WatchCarsRequest, opts ...grpc.CallOption) (Workshop_WatchCarsClient, error)
+	GetPaintJob(ctx context.Context, in *GetPaintJobRequest, opts ...grpc.CallOption) (*PaintJob, error)
+	ListPaintJobs(ctx context.Context, in *ListPaintJobsRequest, opts ...grpc.CallOption) (*ListPaintJobsResponse, error)
+	CancelPaintJob(ctx context.Context, in *CancelPaintJobRequest, opts ...grpc.CallOption) (*PaintJob, error)
+	RegisterSubWorkshop(ctx context.Context, in *SubWorkshopRegistration, opts ...grpc.CallOption) (*empty.Empty, error)
 	CarPainted(ctx context.Context, in *PaintFinishedRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 }
 
@@ -620,8 +2352,8 @@ func (c *workshopClient) AcceptCar(ctx context.Context, in *Car, opts ...grpc.Ca
 	return out, nil
 }
 
-func (c *workshopClient) PaintCar(ctx context.Context, in *PaintCarRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
-	out := new(empty.Empty)
+func (c *workshopClient) PaintCar(ctx context.Context, in *PaintCarRequest, opts ...grpc.CallOption) (*PaintJob, error) {
+	out := new(PaintJob)
 	err := c.cc.Invoke(ctx, "/tutorial.workshop.Workshop/PaintCar", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -638,6 +2370,101 @@ func (c *workshopClient) RetrieveCar(ctx context.Context, in *RetrieveCarRequest
 	return out, nil
 }
 
+func (c *workshopClient) UpdateCar(ctx context.Context, in *UpdateCarRequest, opts ...grpc.CallOption) (*Car, error) {
+	out := new(Car)
+	err := c.cc.Invoke(ctx, "/tutorial.workshop.Workshop/UpdateCar", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *workshopClient) GetCarHistory(ctx context.Context, in *GetCarHistoryRequest, opts ...grpc.CallOption) (*GetCarHistoryResponse, error) {
+	out := new(GetCarHistoryResponse)
+	err := c.cc.Invoke(ctx, "/tutorial.workshop.Workshop/GetCarHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *workshopClient) ListCars(ctx context.Context, in *ListCarsRequest, opts ...grpc.CallOption) (*ListCarsResponse, error) {
+	out := new(ListCarsResponse)
+	err := c.cc.Invoke(ctx, "/tutorial.workshop.Workshop/ListCars", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *workshopClient) WatchCars(ctx context.Context, in *WatchCarsRequest, opts ...grpc.CallOption) (Workshop_WatchCarsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Workshop_serviceDesc.Streams[0], "/tutorial.workshop.Workshop/WatchCars", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &workshopWatchCarsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Workshop_WatchCarsClient interface {
+	Recv() (*CarEvent, error)
+	grpc.ClientStream
+}
+
+type workshopWatchCarsClient struct {
+	grpc.ClientStream
+}
+
+func (x *workshopWatchCarsClient) Recv() (*CarEvent, error) {
+	m := new(CarEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *workshopClient) GetPaintJob(ctx context.Context, in *GetPaintJobRequest, opts ...grpc.CallOption) (*PaintJob, error) {
+	out := new(PaintJob)
+	err := c.cc.Invoke(ctx, "/tutorial.workshop.Workshop/GetPaintJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *workshopClient) ListPaintJobs(ctx context.Context, in *ListPaintJobsRequest, opts ...grpc.CallOption) (*ListPaintJobsResponse, error) {
+	out := new(ListPaintJobsResponse)
+	err := c.cc.Invoke(ctx, "/tutorial.workshop.Workshop/ListPaintJobs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *workshopClient) CancelPaintJob(ctx context.Context, in *CancelPaintJobRequest, opts ...grpc.CallOption) (*PaintJob, error) {
+	out := new(PaintJob)
+	err := c.cc.Invoke(ctx, "/tutorial.workshop.Workshop/CancelPaintJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *workshopClient) RegisterSubWorkshop(ctx context.Context, in *SubWorkshopRegistration, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/tutorial.workshop.Workshop/RegisterSubWorkshop", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *workshopClient) CarPainted(ctx context.Context, in *PaintFinishedRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
 	out := new(empty.Empty)
 	err := c.cc.Invoke(ctx, "/tutorial.workshop.Workshop/CarPainted", in, out, opts...)
@@ -650,8 +2477,16 @@ func (c *workshopClient) CarPainted(ctx context.Context, in *PaintFinishedReques
 // WorkshopServer is the server API for Workshop service.
 type WorkshopServer interface {
 	AcceptCar(context.Context, *Car) (*empty.Empty, error)
-	PaintCar(context.Context, *PaintCarRequest) (*empty.Empty, error)
+	PaintCar(context.Context, *PaintCarRequest) (*PaintJob, error)
 	RetrieveCar(context.Context, *RetrieveCarRequest) (*Car, error)
+	UpdateCar(context.Context, *UpdateCarRequest) (*Car, error)
+	GetCarHistory(context.Context, *GetCarHistoryRequest) (*GetCarHistoryResponse, error)
+	ListCars(context.Context, *ListCarsRequest) (*ListCarsResponse, error)
+	WatchCars(*WatchCarsRequest, Workshop_WatchCarsServer) error
+	GetPaintJob(context.Context, *GetPaintJobRequest) (*PaintJob, error)
+	ListPaintJobs(context.Context, *ListPaintJobsRequest) (*ListPaintJobsResponse, error)
+	CancelPaintJob(context.Context, *CancelPaintJobRequest) (*PaintJob, error)
+	RegisterSubWorkshop(context.Context, *SubWorkshopRegistration) (*empty.Empty, error)
 	CarPainted(context.Context, *PaintFinishedRequest) (*empty.Empty, error)
 }
 
@@ -662,12 +2497,36 @@ type UnimplementedWorkshopServer struct {
 func (*UnimplementedWorkshopServer) AcceptCar(context.Context, *Car) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AcceptCar not implemented")
 }
-func (*UnimplementedWorkshopServer) PaintCar(context.Context, *PaintCarRequest) (*empty.Empty, error) {
+func (*UnimplementedWorkshopServer) PaintCar(context.Context, *PaintCarRequest) (*PaintJob, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PaintCar not implemented")
 }
 func (*UnimplementedWorkshopServer) RetrieveCar(context.Context, *RetrieveCarRequest) (*Car, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RetrieveCar not implemented")
 }
+func (*UnimplementedWorkshopServer) UpdateCar(context.Context, *UpdateCarRequest) (*Car, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCar not implemented")
+}
+func (*UnimplementedWorkshopServer) GetCarHistory(context.Context, *GetCarHistoryRequest) (*GetCarHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCarHistory not implemented")
+}
+func (*UnimplementedWorkshopServer) ListCars(context.Context, *ListCarsRequest) (*ListCarsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCars not implemented")
+}
+func (*UnimplementedWorkshopServer) WatchCars(*WatchCarsRequest, Workshop_WatchCarsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchCars not implemented")
+}
+func (*UnimplementedWorkshopServer) GetPaintJob(context.Context, *GetPaintJobRequest) (*PaintJob, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPaintJob not implemented")
+}
+func (*UnimplementedWorkshopServer) ListPaintJobs(context.Context, *ListPaintJobsRequest) (*ListPaintJobsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPaintJobs not implemented")
+}
+func (*UnimplementedWorkshopServer) CancelPaintJob(context.Context, *CancelPaintJobRequest) (*PaintJob, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelPaintJob not implemented")
+}
+func (*UnimplementedWorkshopServer) RegisterSubWorkshop(context.Context, *SubWorkshopRegistration) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterSubWorkshop not implemented")
+}
 func (*UnimplementedWorkshopServer) CarPainted(context.Context, *PaintFinishedRequest) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CarPainted not implemented")
 }
@@ -730,6 +2589,153 @@ func _Workshop_RetrieveCar_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Workshop_UpdateCar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkshopServer).UpdateCar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tutorial.workshop.Workshop/UpdateCar",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkshopServer).UpdateCar(ctx, req.(*UpdateCarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Workshop_GetCarHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCarHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkshopServer).GetCarHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tutorial.workshop.Workshop/GetCarHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkshopServer).GetCarHistory(ctx, req.(*GetCarHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Workshop_ListCars_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCarsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkshopServer).ListCars(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tutorial.workshop.Workshop/ListCars",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkshopServer).ListCars(ctx, req.(*ListCarsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Workshop_WatchCars_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchCarsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WorkshopServer).WatchCars(m, &workshopWatchCarsServer{stream})
+}
+
+type Workshop_WatchCarsServer interface {
+	Send(*CarEvent) error
+	grpc.ServerStream
+}
+
+type workshopWatchCarsServer struct {
+	grpc.ServerStream
+}
+
+func (x *workshopWatchCarsServer) Send(m *CarEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Workshop_GetPaintJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPaintJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkshopServer).GetPaintJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tutorial.workshop.Workshop/GetPaintJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkshopServer).GetPaintJob(ctx, req.(*GetPaintJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Workshop_ListPaintJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPaintJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkshopServer).ListPaintJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tutorial.workshop.Workshop/ListPaintJobs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkshopServer).ListPaintJobs(ctx, req.(*ListPaintJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Workshop_CancelPaintJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelPaintJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkshopServer).CancelPaintJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tutorial.workshop.Workshop/CancelPaintJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkshopServer).CancelPaintJob(ctx, req.(*CancelPaintJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Workshop_RegisterSubWorkshop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubWorkshopRegistration)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkshopServer).RegisterSubWorkshop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tutorial.workshop.Workshop/RegisterSubWorkshop",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkshopServer).RegisterSubWorkshop(ctx, req.(*SubWorkshopRegistration))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Workshop_CarPainted_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PaintFinishedRequest)
 	if err := dec(in); err != nil {
@@ -764,12 +2770,46 @@ var _Workshop_serviceDesc = grpc.ServiceDesc{
 			MethodName: "RetrieveCar",
 			Handler:    _Workshop_RetrieveCar_Handler,
 		},
+		{
+			MethodName: "UpdateCar",
+			Handler:    _Workshop_UpdateCar_Handler,
+		},
+		{
+			MethodName: "GetCarHistory",
+			Handler:    _Workshop_GetCarHistory_Handler,
+		},
+		{
+			MethodName: "ListCars",
+			Handler:    _Workshop_ListCars_Handler,
+		},
+		{
+			MethodName: "GetPaintJob",
+			Handler:    _Workshop_GetPaintJob_Handler,
+		},
+		{
+			MethodName: "ListPaintJobs",
+			Handler:    _Workshop_ListPaintJobs_Handler,
+		},
+		{
+			MethodName: "CancelPaintJob",
+			Handler:    _Workshop_CancelPaintJob_Handler,
+		},
+		{
+			MethodName: "RegisterSubWorkshop",
+			Handler:    _Workshop_RegisterSubWorkshop_Handler,
+		},
 		{
 			MethodName: "CarPainted",
 			Handler:    _Workshop_CarPainted_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchCars",
+			Handler:       _Workshop_WatchCars_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "api/garage.proto",
 }
 
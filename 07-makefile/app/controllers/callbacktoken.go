@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+)
+
+const (
+	// CallbackSecretKey signs the tokens sub workshops send back with painted cars, every workshop instance must use the same one.
+	// A random secret is used when it's not set, callbacks of cars sent before a restart are rejected then.
+	CallbackSecretKey = "custom.workshop.callback.secret"
+	// CallbackTokenTTLKey is how long after a car is sent to be painted the sub workshop may report it
+	CallbackTokenTTLKey = "custom.workshop.callback.ttl"
+	// CallbackReplayWindowKey is how many used tokens are remembered to reject callbacks that are sent again
+	CallbackReplayWindowKey = "custom.workshop.callback.replay"
+
+	defaultCallbackTokenTTL     = 24 * time.Hour
+	defaultCallbackReplayWindow = 4096
+)
+
+// callbackTokens signs the paint job, car and color sent to the sub workshop so that only the matching callback is accepted
+type callbackTokens struct {
+	secret []byte
+	ttl    time.Duration
+	used   *recentIDs
+}
+
+func newCallbackTokens(config cfg.Config) (*callbackTokens, error) {
+	c := &callbackTokens{
+		secret: []byte(config.Get(CallbackSecretKey).String()),
+		ttl:    defaultCallbackTokenTTL,
+	}
+	if len(c.secret) == 0 {
+		c.secret = make([]byte, sha256.Size)
+		if _, err := rand.Read(c.secret); err != nil {
+			return nil, fmt.Errorf("can't create a callback secret, %w", err)
+		}
+	}
+	if value := config.Get(CallbackTokenTTLKey); value.IsSet() {
+		c.ttl = value.Duration()
+	}
+	replayWindow := defaultCallbackReplayWindow
+	if value := config.Get(CallbackReplayWindowKey); value.IsSet() {
+		replayWindow = value.Int()
+	}
+	c.used = newRecentIDs(replayWindow)
+	return c, nil
+}
+
+// issue returns a token that expires after the configured time, it looks like <expiry unix time>.<signature>
+func (c *callbackTokens) issue(paintJobID, carNumber, color string, now time.Time) string {
+	expiry := strconv.FormatInt(now.Add(c.ttl).Unix(), 10)
+	return expiry + "." + c.sign(expiry, paintJobID, carNumber, color)
+}
+
+// verify fails if token wasn't issued for this paint job, car and color, or if it expired
+func (c *callbackTokens) verify(token, paintJobID, carNumber, color string, now time.Time) error {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("%w: malformed", ErrInvalidCallbackToken)
+	}
+	if !hmac.Equal([]byte(parts[1]), []byte(c.sign(parts[0], paintJobID, carNumber, color))) {
+		return fmt.Errorf("%w: it wasn't issued for car %s painted %s", ErrInvalidCallbackToken, carNumber, color)
+	}
+	expiry, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed expiry", ErrInvalidCallbackToken)
+	}
+	if now.After(time.Unix(expiry, 0)) {
+		return fmt.Errorf("%w: expired at %s", ErrInvalidCallbackToken, time.Unix(expiry, 0).UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// use fails if token was used already, forget must be called if the callback fails after all
+func (c *callbackTokens) use(token string) error {
+	if !c.used.add(token) {
+		return ErrCallbackReplayed
+	}
+	return nil
+}
+
+func (c *callbackTokens) forget(token string) {
+	c.used.forget(token)
+}
+
+func (c *callbackTokens) sign(expiry, paintJobID, carNumber, color string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	// lengths keep fields from running into each other
+	for _, field := range []string{expiry, paintJobID, carNumber, color} {
+		fmt.Fprintf(mac, "%d:%s", len(field), field)
+	}
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/http/client"
+	"github.com/go-masonry/mortar/interfaces/log"
+	"github.com/go-masonry/mortar/interfaces/monitor"
+	"github.com/go-masonry/tutorial/07-makefile/app/noop"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+const (
+	// CallbackConnectionIdleKey is how long a connection to a workshop stays open without callbacks
+	CallbackConnectionIdleKey = "custom.subworkshop.callback.connections.idle"
+	// CallbackConnectionCheckKey is how often idle and broken connections are looked for
+	CallbackConnectionCheckKey = "custom.subworkshop.callback.connections.check"
+
+	defaultCallbackConnectionIdle  = 5 * time.Minute
+	defaultCallbackConnectionCheck = 30 * time.Second
+)
+
+// grpcConnections keeps a connection per address, it's shared by all the callbacks to the same workshop
+type grpcConnections struct {
+	sync.Mutex
+	logger      log.Logger
+	builder     client.GRPCClientConnectionBuilder
+	dialOption  grpc.DialOption // plain text or TLS
+	idle        time.Duration
+	check       time.Duration
+	connections map[string]*cachedConnection
+	dialing     map[string]*pendingDial // by address, dialed without holding the lock
+	closed      bool
+	stopping    chan struct{}
+	done        chan struct{}
+
+	size       monitor.Gauge
+	dialErrors monitor.Counter
+}
+
+type cachedConnection struct {
+	conn     grpc.ClientConnInterface
+	lastUsed time.Time
+}
+
+// pendingDial is shared by the callers that need a connection to the same address while it's dialed
+type pendingDial struct {
+	done chan struct{}
+	conn grpc.ClientConnInterface
+	err  error
+}
+
+// stateful is implemented by *grpc.ClientConn
+type stateful interface {
+	GetState() connectivity.State
+}
+
+// newGRPCConnections checks connections in the background while the application runs and closes them all when it stops,
+// metrics are optional
+func newGRPCConnections(config cfg.Config, logger log.Logger, metrics monitor.Metrics, lifecycle fx.Lifecycle, builder client.GRPCClientConnectionBuilder, dialOption grpc.DialOption) *grpcConnections {
+	c := &grpcConnections{
+		logger:      logger,
+		builder:     builder,
+		dialOption:  dialOption,
+		idle:        defaultCallbackConnectionIdle,
+		check:       defaultCallbackConnectionCheck,
+		connections: make(map[string]*cachedConnection),
+		dialing:     make(map[string]*pendingDial),
+		stopping:    make(chan struct{}),
+		done:        make(chan struct{}),
+		size:        noop.Gauge{},
+		dialErrors:  noop.Counter{},
+	}
+	if value := config.Get(CallbackConnectionIdleKey); value.IsSet() {
+		c.idle = value.Duration()
+	}
+	if value := config.Get(CallbackConnectionCheckKey); value.IsSet() {
+		c.check = value.Duration()
+	}
+	if metrics != nil {
+		c.size = metrics.Gauge("subworkshop_callback_connections", "open connections to workshops")
+		c.dialErrors = metrics.Counter("subworkshop_callback_dial_errors", "failed dials to workshops")
+	}
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go c.run()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(c.stopping)
+			select {
+			case <-c.done:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			c.closeAll(ctx)
+			return nil
+		},
+	})
+	return c
+}
+
+// get returns the connection to address, a new one is dialed if there is none or it's broken.
+// Dialing doesn't block callbacks to other addresses, callbacks to the same address wait for it.
+func (c *grpcConnections) get(ctx context.Context, address string) (grpc.ClientConnInterface, error) {
+	c.Lock()
+	if c.closed {
+		c.Unlock()
+		return nil, ErrSubWorkshopStopping
+	}
+	if cached, exists := c.connections[address]; exists {
+		if !broken(cached.conn) {
+			cached.lastUsed = time.Now()
+			c.Unlock()
+			return cached.conn, nil
+		}
+		c.remove(ctx, address, "it's broken")
+	}
+	if pending, exists := c.dialing[address]; exists {
+		c.Unlock()
+		select {
+		case <-pending.done:
+			return pending.conn, pending.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	pending := &pendingDial{done: make(chan struct{})}
+	c.dialing[address] = pending
+	c.Unlock()
+
+	conn, err := c.builder.Build().Dial(ctx, address, c.dialOption)
+
+	c.Lock()
+	defer c.Unlock()
+	defer close(pending.done)
+	delete(c.dialing, address)
+	if err != nil {
+		c.dialErrors.Inc()
+		pending.err = err
+		return nil, err
+	}
+	if c.closed {
+		// stopped while dialing
+		if closer, ok := conn.(io.Closer); ok {
+			closer.Close()
+		}
+		pending.err = ErrSubWorkshopStopping
+		return nil, pending.err
+	}
+	c.connections[address] = &cachedConnection{conn: conn, lastUsed: time.Now()}
+	c.size.Inc()
+	pending.conn = conn
+	return conn, nil
+}
+
+// evict closes the connection to address, the next get dials again
+func (c *grpcConnections) evict(ctx context.Context, address string, reason string) {
+	c.Lock()
+	defer c.Unlock()
+	c.remove(ctx, address, reason)
+}
+
+func (c *grpcConnections) run() {
+	defer close(c.done)
+	ticker := time.NewTicker(c.check)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopping:
+			return
+		case <-ticker.C:
+			c.removeIdleAndBroken(context.Background())
+		}
+	}
+}
+
+func (c *grpcConnections) removeIdleAndBroken(ctx context.Context) {
+	c.Lock()
+	defer c.Unlock()
+	now := time.Now()
+	for address, cached := range c.connections {
+		if now.Sub(cached.lastUsed) >= c.idle {
+			c.remove(ctx, address, "it's idle")
+		} else if broken(cached.conn) {
+			c.remove(ctx, address, "it's broken")
+		}
+	}
+}
+
+func (c *grpcConnections) closeAll(ctx context.Context) {
+	c.Lock()
+	defer c.Unlock()
+	c.closed = true
+	for address := range c.connections {
+		c.remove(ctx, address, "the application is stopping")
+	}
+}
+
+// remove must be called with the lock held
+func (c *grpcConnections) remove(ctx context.Context, address string, reason string) {
+	cached, exists := c.connections[address]
+	if !exists {
+		return
+	}
+	delete(c.connections, address)
+	c.size.Dec()
+	var err error
+	if closer, ok := cached.conn.(io.Closer); ok {
+		err = closer.Close()
+	}
+	c.logger.WithError(err).Debug(ctx, "connection to %s closed, %s", address, reason)
+}
+
+// broken is true once a connection failed or was closed, it only looks at the connectivity state and doesn't ask the
+// workshop anything. Connections that don't report their state are never broken.
+func broken(conn grpc.ClientConnInterface) bool {
+	if s, ok := conn.(stateful); ok {
+		switch s.GetState() {
+		case connectivity.TransientFailure, connectivity.Shutdown:
+			return true
+		}
+	}
+	return false
+}
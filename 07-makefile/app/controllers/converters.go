@@ -1,8 +1,15 @@
 package controllers
 
 import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+
 	workshop "github.com/go-masonry/tutorial/07-makefile/api"
 	"github.com/go-masonry/tutorial/07-makefile/app/data"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
 )
 
 // FromProtoCarToModelCar converts workshop proto model to our data Entity
@@ -29,5 +36,101 @@ func FromModelCarToProtoCar(car *data.CarEntity) *workshop.Car {
 		Owner:     car.Owner,
 		BodyStyle: workshop.CarBody(workshop.CarBody_value[car.BodyStyle]),
 		Color:     car.CurrentColor,
+		State:     workshop.CarLifecycle(workshop.CarLifecycle_value[car.State]),
+		Etag:      strconv.FormatInt(car.Version, 10),
+	}
+}
+
+// FromProtoEtagToModelVersion converts the etag of a car to its version, 0 (any version) if it's not set
+func FromProtoEtagToModelVersion(etag string) int64 {
+	version, _ := strconv.ParseInt(etag, 10, 64)
+	return version
+}
+
+// FromModelCarHistoryToProtoCarHistoryEntry converts our data Entity to workshop proto model
+func FromModelCarHistoryToProtoCarHistoryEntry(history *data.CarHistoryEntity) *workshop.CarHistoryEntry {
+	if history == nil {
+		return nil
+	}
+	return &workshop.CarHistoryEntry{
+		OldState:    workshop.CarLifecycle(workshop.CarLifecycle_value[history.OldState]),
+		NewState:    workshop.CarLifecycle(workshop.CarLifecycle_value[history.NewState]),
+		OldColor:    history.OldColor,
+		NewColor:    history.NewColor,
+		Actor:       history.Actor,
+		SubWorkshop: history.SubWorkshop,
+		TraceId:     history.TraceID,
+		Time:        toProtoTimestamp(history.Time),
+		Etag:        strconv.FormatInt(history.Version, 10),
+	}
+}
+
+// FromProtoListCarsRequestToCarFilter converts ListCars filters to our data filter
+func FromProtoListCarsRequestToCarFilter(request *workshop.ListCarsRequest) data.CarFilter {
+	filter := data.CarFilter{
+		Owner:        request.GetOwner(),
+		CurrentColor: request.GetColor(),
+	}
+	for _, bodyStyle := range request.GetBodyStyles() {
+		filter.BodyStyles = append(filter.BodyStyles, workshop.CarBody_name[int32(bodyStyle)])
+	}
+	if painted := request.GetPainted(); painted != nil {
+		value := painted.GetValue()
+		filter.Painted = &value
+	}
+	return filter
+}
+
+// FromModelPaintJobToProtoPaintJob converts our data Entity to workshop proto model
+func FromModelPaintJobToProtoPaintJob(job *data.PaintJobEntity) *workshop.PaintJob {
+	if job == nil {
+		return nil
+	}
+	return &workshop.PaintJob{
+		Id:           job.ID,
+		CarNumber:    job.CarNumber,
+		DesiredColor: job.DesiredColor,
+		JobStatus:    workshop.PaintJobStatus(workshop.PaintJobStatus_value[job.Status]),
+		Error:        job.Error,
+		CreateTime:   toProtoTimestamp(job.CreateTime),
+		UpdateTime:   toProtoTimestamp(job.UpdateTime),
+		EndTime:      toProtoTimestamp(job.EndTime),
+	}
+}
+
+// toProtoTimestamp leaves zero times unset
+func toProtoTimestamp(t time.Time) *timestamp.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	ts, err := ptypes.TimestampProto(t)
+	if err != nil {
+		return nil
+	}
+	return ts
+}
+
+// Page tokens are opaque to clients, they hold the last key (car number, job id) of the previous page
+func encodePageToken(lastKey string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastKey))
+}
+
+func decodePageToken(token string) (string, error) {
+	lastKey, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPageToken, token)
+	}
+	return string(lastKey), nil
+}
+
+// pageSize applies the default and maximum page sizes
+func pageSize(requested int32) int {
+	switch {
+	case requested <= 0:
+		return defaultPageSize
+	case requested > MaxPageSize:
+		return MaxPageSize
+	default:
+		return int(requested)
 	}
 }
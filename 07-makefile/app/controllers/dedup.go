@@ -0,0 +1,54 @@
+package controllers
+
+import "sync"
+
+const (
+	// SubWorkshopDedupSizeKey is how many recent paint job ids the sub workshop remembers to ignore paint requests it already got
+	SubWorkshopDedupSizeKey = "custom.subworkshop.dedup.size"
+
+	defaultSubWorkshopDedupSize = 1024
+)
+
+// recentIDs remembers the last ids it was given, older ones are forgotten
+type recentIDs struct {
+	sync.Mutex
+	ids   map[string]int // slot of every id in order
+	order []string       // ring of ids by the time they were added, empty once forgotten
+	next  int
+}
+
+func newRecentIDs(size int) *recentIDs {
+	return &recentIDs{
+		ids:   make(map[string]int, size),
+		order: make([]string, size),
+	}
+}
+
+// add returns false if id was added recently
+func (r *recentIDs) add(id string) bool {
+	r.Lock()
+	defer r.Unlock()
+	if _, exists := r.ids[id]; exists {
+		return false
+	}
+	if len(r.order) == 0 {
+		return true
+	}
+	if oldest := r.order[r.next]; len(oldest) > 0 {
+		delete(r.ids, oldest)
+	}
+	r.order[r.next] = id
+	r.ids[id] = r.next
+	r.next = (r.next + 1) % len(r.order)
+	return true
+}
+
+// forget removes id so adding it again succeeds, its slot is freed so the id added again isn't evicted early
+func (r *recentIDs) forget(id string) {
+	r.Lock()
+	defer r.Unlock()
+	if slot, exists := r.ids[id]; exists {
+		r.order[slot] = ""
+		delete(r.ids, id)
+	}
+}
@@ -0,0 +1,40 @@
+package controllers
+
+import "errors"
+
+var (
+	// ErrCarNotPainted is returned when retrieving a car that wasn't painted yet
+	ErrCarNotPainted = errors.New("car is not painted")
+	// ErrCarNotPaintable is returned when painting a car that is already being painted or was retrieved
+	ErrCarNotPaintable = errors.New("car can't be painted")
+	// ErrCarNotBeingPainted is returned when the sub workshop reports a car that wasn't sent to be painted
+	ErrCarNotBeingPainted = errors.New("car is not being painted")
+	// ErrInvalidPageToken is returned when a page token wasn't issued by ListCars
+	ErrInvalidPageToken = errors.New("invalid page token")
+	// ErrEventsExpired is returned when resuming a watch from an event that is no longer kept
+	ErrEventsExpired = errors.New("events expired")
+	// ErrWatcherTooSlow is returned when a watcher doesn't keep up with new events, it should resume from its last sequence
+	ErrWatcherTooSlow = errors.New("watcher is too slow")
+	// ErrPaintJobDone is returned when changing a paint job that already reached a final status
+	ErrPaintJobDone = errors.New("paint job is done")
+	// ErrPaintQueueFull is returned by the sub workshop when too many cars wait to be painted
+	ErrPaintQueueFull = errors.New("paint queue is full")
+	// ErrSubWorkshopStopping is returned by the sub workshop once it stopped accepting cars
+	ErrSubWorkshopStopping = errors.New("sub workshop is stopping")
+	// ErrNoSubWorkshop is returned when none of the registered sub workshops can paint a car
+	ErrNoSubWorkshop = errors.New("no sub workshop can paint")
+	// ErrInvalidCallbackToken is returned when a sub workshop reports a car with a token the workshop didn't issue for it
+	ErrInvalidCallbackToken = errors.New("invalid callback token")
+	// ErrCallbackReplayed is returned when a sub workshop reports a car with a token that was already used
+	ErrCallbackReplayed = errors.New("callback token was already used")
+	// ErrCallbackNotAllowed is returned by the sub workshop when asked to call back an address that isn't allowed
+	ErrCallbackNotAllowed = errors.New("callback address is not allowed")
+	// ErrInvalidETag is returned when the If-Match header has an etag that wasn't issued by the workshop
+	ErrInvalidETag = errors.New("invalid etag")
+	// ErrInvalidIdempotencyKey is returned when an idempotency key is too long
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+	// ErrIdempotencyKeyReused is returned when an idempotency key is sent again with a different request
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used for another request")
+	// ErrIdempotencyKeyInUse is returned when an idempotency key is sent again before the first request finished
+	ErrIdempotencyKeyInUse = errors.New("request with this idempotency key is in progress")
+)
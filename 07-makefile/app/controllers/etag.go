@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-masonry/tutorial/07-makefile/app/data"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// IfMatchHeader is the gRPC metadata, or HTTP header, with the etag the car must still have for a request to change it
+	IfMatchHeader = "if-match"
+	// ETagHeader is the gRPC metadata, or HTTP header, with the etag of the car a request returned or changed
+	ETagHeader = "etag"
+)
+
+// ifMatch returns the version the request expects the car to be at, 0 when any version will do
+func ifMatch(ctx context.Context) (int64, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(IfMatchHeader)
+	if len(values) == 0 || values[0] == "*" {
+		return 0, nil
+	}
+	etag := values[0]
+	version, err := strconv.ParseInt(strings.Trim(etag, `"`), 10, 64)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidETag, etag)
+	}
+	return version, nil
+}
+
+// setETag sends the etag of car with the response headers, quoted as HTTP expects it
+func setETag(ctx context.Context, car *data.CarEntity) {
+	// fails only when not called by a gRPC server, there are no headers to send then
+	grpc.SetHeader(ctx, metadata.Pairs(ETagHeader, strconv.Quote(strconv.FormatInt(car.Version, 10))))
+}
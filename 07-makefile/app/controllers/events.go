@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	workshop "github.com/go-masonry/tutorial/07-makefile/api"
+	"github.com/go-masonry/tutorial/07-makefile/app/data"
+	"github.com/golang/protobuf/ptypes"
+	"go.uber.org/fx"
+)
+
+const (
+	// EventsHistoryKey is how many of the latest events are kept to let watchers resume
+	EventsHistoryKey = "custom.events.history"
+	// EventsWatcherBufferKey is how many events a watcher may fall behind before it's disconnected
+	EventsWatcherBufferKey = "custom.events.watcher.buffer"
+
+	defaultEventsHistory       = 1024
+	defaultEventsWatcherBuffer = 64
+)
+
+// CarEvents delivers car events to watchers, every event gets the next sequence number
+type CarEvents interface {
+	Publish(ctx context.Context, eventType workshop.CarEventType, car *data.CarEntity)
+	// Watch sends events of carNumber (all cars if empty) that come after afterSequence (only new events if 0)
+	// until ctx is done or send fails
+	Watch(ctx context.Context, carNumber string, afterSequence uint64, send func(*workshop.CarEvent) error) error
+}
+
+type carEventsDeps struct {
+	fx.In
+
+	Config cfg.Config
+}
+
+type carEvents struct {
+	sync.Mutex
+	sequence      uint64
+	history       []*workshop.CarEvent // oldest first
+	historySize   int
+	watcherBuffer int
+	watchers      map[*carEventsWatcher]struct{}
+}
+
+type carEventsWatcher struct {
+	carNumber string
+	events    chan *workshop.CarEvent
+}
+
+func (w *carEventsWatcher) wants(event *workshop.CarEvent) bool {
+	return len(w.carNumber) == 0 || w.carNumber == event.GetCar().GetNumber()
+}
+
+// CreateCarEvents is a constructor for Fx, events are kept in memory and lost on restart
+func CreateCarEvents(deps carEventsDeps) CarEvents {
+	events := &carEvents{
+		historySize:   defaultEventsHistory,
+		watcherBuffer: defaultEventsWatcherBuffer,
+		watchers:      make(map[*carEventsWatcher]struct{}),
+	}
+	if value := deps.Config.Get(EventsHistoryKey); value.IsSet() {
+		events.historySize = value.Int()
+	}
+	if value := deps.Config.Get(EventsWatcherBufferKey); value.IsSet() {
+		events.watcherBuffer = value.Int()
+	}
+	return events
+}
+
+func (c *carEvents) Publish(ctx context.Context, eventType workshop.CarEventType, car *data.CarEntity) {
+	c.Lock()
+	defer c.Unlock()
+	c.sequence++
+	event := &workshop.CarEvent{
+		Sequence:  c.sequence,
+		EventType: eventType,
+		Car:       FromModelCarToProtoCar(car),
+		Time:      ptypes.TimestampNow(),
+	}
+	c.history = append(c.history, event)
+	if len(c.history) > c.historySize {
+		c.history = c.history[len(c.history)-c.historySize:]
+	}
+	for watcher := range c.watchers {
+		if !watcher.wants(event) {
+			continue
+		}
+		select {
+		case watcher.events <- event:
+		default:
+			// too slow, closing makes Watch return and the client can resume from its last sequence
+			delete(c.watchers, watcher)
+			close(watcher.events)
+		}
+	}
+}
+
+func (c *carEvents) Watch(ctx context.Context, carNumber string, afterSequence uint64, send func(*workshop.CarEvent) error) error {
+	backlog, watcher, err := c.subscribe(carNumber, afterSequence)
+	if err != nil {
+		return err
+	}
+	defer c.unsubscribe(watcher)
+	for _, event := range backlog {
+		if err := send(event); err != nil {
+			return err
+		}
+		afterSequence = event.GetSequence()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, open := <-watcher.events:
+			if !open {
+				return fmt.Errorf("%w: last sent event %d", ErrWatcherTooSlow, afterSequence)
+			}
+			if event.GetSequence() <= afterSequence {
+				// already sent with the backlog
+				continue
+			}
+			if err := send(event); err != nil {
+				return err
+			}
+			afterSequence = event.GetSequence()
+		}
+	}
+}
+
+// subscribe registers a watcher and returns the events it missed, both under the same lock so nothing falls in between
+func (c *carEvents) subscribe(carNumber string, afterSequence uint64) (backlog []*workshop.CarEvent, watcher *carEventsWatcher, err error) {
+	c.Lock()
+	defer c.Unlock()
+	if afterSequence > c.sequence {
+		// events are lost on restart and numbered from 1 again, the ones after afterSequence will never be sent
+		return nil, nil, fmt.Errorf("%w: event %d is after the last event %d", ErrEventsExpired, afterSequence, c.sequence)
+	}
+	if afterSequence > 0 && afterSequence < c.sequence {
+		if len(c.history) == 0 || c.history[0].GetSequence() > afterSequence+1 {
+			return nil, nil, fmt.Errorf("%w: event %d", ErrEventsExpired, afterSequence+1)
+		}
+	}
+	watcher = &carEventsWatcher{carNumber: carNumber, events: make(chan *workshop.CarEvent, c.watcherBuffer)}
+	if afterSequence > 0 {
+		for _, event := range c.history {
+			if event.GetSequence() > afterSequence && watcher.wants(event) {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+	c.watchers[watcher] = struct{}{}
+	return backlog, watcher, nil
+}
+
+func (c *carEvents) unsubscribe(watcher *carEventsWatcher) {
+	c.Lock()
+	defer c.Unlock()
+	if _, exists := c.watchers[watcher]; exists {
+		delete(c.watchers, watcher)
+		close(watcher.events)
+	}
+}
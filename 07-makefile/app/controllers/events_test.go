@@ -0,0 +1,175 @@
+package controllers_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	workshop "github.com/go-masonry/tutorial/07-makefile/api"
+	"github.com/go-masonry/tutorial/07-makefile/app/controllers"
+	"github.com/go-masonry/tutorial/07-makefile/app/data"
+	"github.com/go-masonry/tutorial/07-makefile/app/mortar"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type eventsSuite struct {
+	suite.Suite
+	pwd    string
+	app    *fxtest.App
+	events controllers.CarEvents
+}
+
+func TestCarEvents(t *testing.T) {
+	suite.Run(t, new(eventsSuite))
+}
+
+func (s *eventsSuite) TestWatchNewEventsOnly() {
+	s.publish(workshop.CarEvent_ACCEPTED, "11111111")
+	received := s.watch("", 0, 1)
+	var events []*workshop.CarEvent
+	s.Eventually(func() bool {
+		s.publish(workshop.CarEvent_PAINTED, "22222222")
+		select {
+		case events = <-received:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, time.Millisecond)
+	s.Require().Len(events, 1)
+	// the event published before watching is not sent
+	s.Greater(events[0].GetSequence(), uint64(1))
+	s.Equal(workshop.CarEvent_PAINTED, events[0].GetEventType())
+	s.Equal("22222222", events[0].GetCar().GetNumber())
+	s.NotNil(events[0].GetTime())
+}
+
+func (s *eventsSuite) TestResumeAndFilterByCar() {
+	s.publish(workshop.CarEvent_ACCEPTED, "11111111")
+	s.publish(workshop.CarEvent_ACCEPTED, "22222222")
+	s.publish(workshop.CarEvent_PAINT_REQUESTED, "11111111")
+	// resume after the first event, missed events of the car are replayed before new ones
+	received := s.watch("11111111", 1, 2)
+	s.publish(workshop.CarEvent_PAINT_REQUESTED, "22222222")
+	s.publish(workshop.CarEvent_PAINTED, "11111111")
+	events := <-received
+	s.Require().Len(events, 2)
+	s.Equal(uint64(3), events[0].GetSequence())
+	s.Equal(workshop.CarEvent_PAINT_REQUESTED, events[0].GetEventType())
+	s.Equal(uint64(5), events[1].GetSequence())
+	s.Equal(workshop.CarEvent_PAINTED, events[1].GetEventType())
+}
+
+func (s *eventsSuite) TestResumeFromExpiredEvent() {
+	// only the last 4 events are kept
+	for i := 0; i < 6; i++ {
+		s.publish(workshop.CarEvent_ACCEPTED, "11111111")
+	}
+	err := s.events.Watch(context.Background(), "", 1, func(*workshop.CarEvent) error { return nil })
+	s.True(errors.Is(err, controllers.ErrEventsExpired))
+	// oldest kept event is 3
+	ctx, cancel := context.WithCancel(context.Background())
+	var sequences []uint64
+	err = s.events.Watch(ctx, "", 2, func(event *workshop.CarEvent) error {
+		if sequences = append(sequences, event.GetSequence()); len(sequences) == 4 {
+			cancel()
+		}
+		return nil
+	})
+	s.NoError(err)
+	s.Equal([]uint64{3, 4, 5, 6}, sequences)
+}
+
+func (s *eventsSuite) TestResumeAfterRestart() {
+	// the watcher saw event 5 before the restart, the events numbered from 1 again since
+	s.publish(workshop.CarEvent_ACCEPTED, "11111111")
+	s.publish(workshop.CarEvent_ACCEPTED, "22222222")
+	err := s.events.Watch(context.Background(), "", 5, func(*workshop.CarEvent) error { return nil })
+	s.True(errors.Is(err, controllers.ErrEventsExpired))
+}
+
+func (s *eventsSuite) TestSlowWatcherIsDisconnected() {
+	sending := make(chan struct{}, 1)
+	blocked := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- s.events.Watch(context.Background(), "", 0, func(*workshop.CarEvent) error {
+			select {
+			case sending <- struct{}{}:
+			default:
+			}
+			<-blocked
+			return nil
+		})
+	}()
+	s.Eventually(func() bool {
+		s.publish(workshop.CarEvent_ACCEPTED, "11111111")
+		select {
+		case <-sending:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, time.Millisecond)
+	// watcher is stuck sending, its buffer of 2 fills up and the third event disconnects it
+	for i := 0; i < 3; i++ {
+		s.publish(workshop.CarEvent_ACCEPTED, "11111111")
+	}
+	close(blocked)
+	err := <-done
+	s.True(errors.Is(err, controllers.ErrWatcherTooSlow))
+}
+
+func (s *eventsSuite) SetupSuite() {
+	var err error
+	s.pwd, err = os.Getwd()
+	s.Require().NoError(err)
+}
+
+func (s *eventsSuite) SetupTest() {
+	s.app = fxtest.New(s.T(),
+		fx.NopLogger, // remove fx debug prints
+		mortar.ViperFxOption(s.pwd+"/../../config/config.yml", s.pwd+"/../../config/config_test.yml"),
+		fx.Invoke(func(config cfg.Config) {
+			config.Set(controllers.EventsHistoryKey, 4)
+			config.Set(controllers.EventsWatcherBufferKey, 2)
+		}),
+		fx.Provide(controllers.CreateCarEvents),
+		fx.Populate(&s.events),
+	)
+	s.app.RequireStart()
+}
+
+func (s *eventsSuite) TearDownTest() {
+	s.app.RequireStop()
+}
+
+func (s *eventsSuite) publish(eventType workshop.CarEventType, carNumber string) {
+	s.events.Publish(context.Background(), eventType, &data.CarEntity{CarNumber: carNumber, BodyStyle: "SEDAN"})
+}
+
+// watch watches in the background and returns the first count events once they arrive
+func (s *eventsSuite) watch(carNumber string, afterSequence uint64, count int) <-chan []*workshop.CarEvent {
+	received := make(chan []*workshop.CarEvent, 1)
+	go func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var events []*workshop.CarEvent
+		s.events.Watch(ctx, carNumber, afterSequence, func(event *workshop.CarEvent) error {
+			if len(events) < count {
+				events = append(events, event)
+			}
+			if len(events) == count {
+				cancel()
+			}
+			return nil
+		})
+		received <- events
+	}()
+	return received
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-masonry/tutorial/07-makefile/app/data"
+	"github.com/go-masonry/tutorial/07-makefile/app/security"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// workshopActor is the actor of the changes the workshop makes in the background, e.g. once a car was sent to be painted
+const workshopActor = "workshop"
+
+// changedBy saves the car changes made with the returned context as made by the caller of ctx, it's empty when
+// authentication is off
+func changedBy(ctx context.Context, subWorkshop string) context.Context {
+	principal, _ := security.PrincipalFromContext(ctx)
+	return changedAs(ctx, principal.Subject, subWorkshop)
+}
+
+func changedAs(ctx context.Context, actor, subWorkshop string) context.Context {
+	return data.WithChangeSource(ctx, data.ChangeSource{
+		Actor:       actor,
+		SubWorkshop: subWorkshop,
+		TraceID:     traceID(ctx),
+	})
+}
+
+// traceID returns the id of the trace ctx is part of, empty when it's not traced
+func traceID(ctx context.Context) string {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return ""
+	}
+	// Jaeger span contexts print as trace-id:span-id:parent-id:flags
+	if spanContext, ok := span.Context().(fmt.Stringer); ok {
+		return strings.SplitN(spanContext.String(), ":", 2)[0]
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/log"
+	"github.com/go-masonry/tutorial/07-makefile/app/data"
+	"github.com/go-masonry/tutorial/07-makefile/app/security"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// IdempotencyKeyHeader is the gRPC metadata, or HTTP header, with the idempotency key of AcceptCar and PaintCar
+	IdempotencyKeyHeader = "idempotency-key"
+	// IdempotencyWindowKey is how long a request made with an idempotency key is remembered, repeating it gets the
+	// original response
+	IdempotencyWindowKey = "custom.workshop.idempotency.window"
+
+	defaultIdempotencyWindow = 24 * time.Hour
+	maxIdempotencyKeyLength  = 256
+)
+
+// idempotency runs a request once per idempotency key, repeated requests get the response of the first one.
+// Failed requests aren't remembered, repeating them runs them again. Keys are remembered by data.IdempotencyDB,
+// which keeps them in memory: they are lost on restart and not shared between instances of the workshop.
+type idempotency struct {
+	db     data.IdempotencyDB
+	logger log.Logger
+	window time.Duration
+}
+
+func newIdempotency(config cfg.Config, logger log.Logger, db data.IdempotencyDB) *idempotency {
+	i := &idempotency{
+		db:     db,
+		logger: logger,
+		window: defaultIdempotencyWindow,
+	}
+	if value := config.Get(IdempotencyWindowKey); value.IsSet() {
+		i.window = value.Duration()
+	}
+	return i
+}
+
+// do calls handle unless request was made with the same idempotency key before, response is filled with the
+// original response then. Requests without a key are always handled.
+func (i *idempotency) do(ctx context.Context, method string, request, response proto.Message, handle func() (proto.Message, error)) (proto.Message, error) {
+	key := idempotencyKey(ctx)
+	if len(key) == 0 {
+		return handle()
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, fmt.Errorf("%w: it's longer than %d characters", ErrInvalidIdempotencyKey, maxIdempotencyKeyLength)
+	}
+	hash, err := requestHash(request)
+	if err != nil {
+		return nil, err
+	}
+	// keys of different methods and callers never collide
+	principal, _ := security.PrincipalFromContext(ctx)
+	scopedKey := fmt.Sprintf("%s/%d:%s/%s", method, len(principal.Subject), principal.Subject, key)
+	existing, err := i.db.Reserve(ctx, &data.IdempotencyEntity{
+		Key:         scopedKey,
+		RequestHash: hash,
+		ExpireTime:  time.Now().Add(i.window),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return i.replay(ctx, method, key, hash, existing, response)
+	}
+	result, err := handle()
+	if err != nil {
+		i.release(ctx, method, key, scopedKey)
+		return nil, err
+	}
+	encoded, err := proto.Marshal(result)
+	if err == nil {
+		err = i.db.Complete(ctx, scopedKey, encoded)
+	}
+	if err != nil {
+		// the request succeeded but can't be replayed, its retries run it again instead of finding the key in use
+		i.logger.WithError(err).Warn(ctx, "failed to save the response of %s with idempotency key %s", method, key)
+		i.release(ctx, method, key, scopedKey)
+		return result, nil
+	}
+	i.logger.Debug(ctx, "%s with idempotency key %s done", method, key)
+	return result, nil
+}
+
+// release lets the request of scopedKey be made again
+func (i *idempotency) release(ctx context.Context, method, key, scopedKey string) {
+	if err := i.db.Release(ctx, scopedKey); err != nil {
+		i.logger.WithError(err).Warn(ctx, "failed to release idempotency key %s of %s", key, method)
+	}
+}
+
+func (i *idempotency) replay(ctx context.Context, method, key string, hash []byte, existing *data.IdempotencyEntity, response proto.Message) (proto.Message, error) {
+	if subtle.ConstantTimeCompare(existing.RequestHash, hash) != 1 {
+		return nil, fmt.Errorf("%w: %s", ErrIdempotencyKeyReused, key)
+	}
+	if !existing.Done {
+		return nil, fmt.Errorf("%w: %s", ErrIdempotencyKeyInUse, key)
+	}
+	if err := proto.Unmarshal(existing.Response, response); err != nil {
+		return nil, err
+	}
+	i.logger.Debug(ctx, "%s with idempotency key %s repeated, returning the original response", method, key)
+	return response, nil
+}
+
+func idempotencyKey(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(IdempotencyKeyHeader); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func requestHash(request proto.Message) ([]byte, error) {
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(encoded)
+	return hash[:], nil
+}
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-masonry/tutorial/07-makefile/app/data"
+	"go.uber.org/fx"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// OutboxRetryKey holds the retry policy of paint requests the sub workshop didn't accept, see newRetryPolicy for the keys under it
+	OutboxRetryKey = "custom.workshop.outbox.retry"
+	// OutboxIntervalKey is how often the outbox is checked for paint requests that are due
+	OutboxIntervalKey = "custom.workshop.outbox.interval"
+
+	defaultOutboxInterval = time.Second
+	outboxBatchSize       = 16
+)
+
+// outboxDispatcher delivers the paint requests saved by CarDB.RequestPaint in the background.
+// A request is sent at least once, the sub workshop ignores requests of paint jobs it already got.
+type outboxDispatcher struct {
+	retry    retryPolicy
+	interval time.Duration
+	wakeup   chan struct{}
+	stopping chan struct{}
+	done     chan struct{}
+}
+
+func (w *workshopController) startDispatcher(lifecycle fx.Lifecycle) {
+	w.dispatcher = outboxDispatcher{
+		retry:    newRetryPolicy(w.deps.Config, OutboxRetryKey),
+		interval: defaultOutboxInterval,
+		wakeup:   make(chan struct{}, 1),
+		stopping: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if value := w.deps.Config.Get(OutboxIntervalKey); value.IsSet() {
+		w.dispatcher.interval = value.Duration()
+	}
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go w.dispatch()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(w.dispatcher.stopping)
+			select {
+			case <-w.dispatcher.done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+}
+
+// dispatchNow wakes the dispatcher up without waiting for the next interval
+func (w *workshopController) dispatchNow() {
+	select {
+	case w.dispatcher.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func (w *workshopController) dispatch() {
+	defer close(w.dispatcher.done)
+	ticker := time.NewTicker(w.dispatcher.interval)
+	defer ticker.Stop()
+	ctx := context.Background()
+	for {
+		// messages left from a previous run are delivered as soon as we start
+		w.dispatchPending(ctx)
+		select {
+		case <-w.dispatcher.stopping:
+			return
+		case <-ticker.C:
+		case <-w.dispatcher.wakeup:
+		}
+	}
+}
+
+func (w *workshopController) dispatchPending(ctx context.Context) {
+	messages, err := w.deps.DB.PendingOutbox(ctx, time.Now(), outboxBatchSize)
+	if err != nil {
+		w.deps.Logger.WithError(err).Warn(ctx, "failed to load outbox")
+		return
+	}
+	for _, message := range messages {
+		w.deliver(ctx, message)
+	}
+	if len(messages) == outboxBatchSize {
+		w.dispatchNow()
+	}
+}
+
+// deliver sends a single paint request, it is removed from the outbox once delivered or when it can never be delivered.
+// A delivered request with a deadline stays in the outbox until its job is done, if the sub workshop didn't call back
+// by the deadline the job fails.
+func (w *workshopController) deliver(ctx context.Context, message *data.OutboxEntity) {
+	job, err := w.deps.DB.GetPaintJob(ctx, message.PaintJobID)
+	if err == nil && job.Done() {
+		// cancelled before it was delivered
+		w.removeFromOutbox(ctx, message.ID)
+		return
+	}
+	if err == nil && job.Status == data.PaintJobRunning && !message.Deadline.IsZero() && !time.Now().Before(message.Deadline) {
+		w.paintRequestExpired(ctx, message, job)
+		return
+	}
+	car, err := w.deps.DB.GetCar(ctx, message.CarNumber)
+	subWorkshop := ""
+	if err == nil {
+		subWorkshop, err = w.sendBeforeDeadline(ctx, car, message)
+	}
+	if err == nil {
+		w.paintRequestDelivered(ctx, message, subWorkshop)
+		w.awaitCallback(ctx, message)
+		return
+	}
+	message.Attempts++
+	message.LastError = err.Error()
+	nextAttempt := time.Now().Add(w.dispatcher.retry.backoff(message.Attempts))
+	if retryable(err) && !errors.Is(err, data.ErrCarNotFound) && !errors.Is(err, ErrNoSubWorkshop) && message.Attempts < w.dispatcher.retry.attempts &&
+		(message.Deadline.IsZero() || nextAttempt.Before(message.Deadline)) {
+		message.NextAttempt = nextAttempt
+		if err = w.deps.DB.RescheduleOutbox(ctx, message); err != nil {
+			w.deps.Logger.WithError(err).Warn(ctx, "failed to reschedule paint request of car %s", message.CarNumber)
+		}
+		return
+	}
+	w.deps.Logger.WithError(err).Warn(ctx, "giving up painting car %s after %d attempts", message.CarNumber, message.Attempts)
+	if _, finishErr := w.finishPaintJob(ctx, message.PaintJobID, data.PaintJobFailed, err); finishErr == nil && car != nil {
+		w.undoPaintRequest(changedAs(ctx, workshopActor, subWorkshop), car.CarNumber)
+	}
+	w.removeFromOutbox(ctx, message.ID)
+}
+
+// sendBeforeDeadline sends the paint request within the deadline of the request that asked for it
+func (w *workshopController) sendBeforeDeadline(ctx context.Context, car *data.CarEntity, message *data.OutboxEntity) (string, error) {
+	if message.Deadline.IsZero() {
+		return w.sendToSubWorkshop(ctx, car, message)
+	}
+	if !time.Now().Before(message.Deadline) {
+		w.deps.Logger.Warn(ctx, "paint request of car %s missed its deadline %s", message.CarNumber, message.Deadline)
+		return "", status.Errorf(codes.DeadlineExceeded, "paint request of car %s missed its deadline", message.CarNumber)
+	}
+	ctx, cancel := context.WithDeadline(ctx, message.Deadline)
+	defer cancel()
+	return w.sendToSubWorkshop(ctx, car, message)
+}
+
+// paintRequestDelivered marks the car and its job as being painted by subWorkshop
+func (w *workshopController) paintRequestDelivered(ctx context.Context, message *data.OutboxEntity, subWorkshop string) {
+	// sub workshop may have called back already, in that case the car is no longer PAINT_REQUESTED
+	_, err := w.writeCar(ctx, message.CarNumber, func(car *data.CarEntity) (*data.CarEntity, error) {
+		return w.deps.DB.SetCarState(changedAs(ctx, workshopActor, subWorkshop), car.CarNumber, car.Version, data.CarPainting)
+	})
+	if err != nil && !errors.Is(err, data.ErrIllegalCarTransition) {
+		w.deps.Logger.WithError(err).Warn(ctx, "failed to mark car %s as painting", message.CarNumber)
+	}
+	_, err = w.deps.DB.UpdatePaintJob(ctx, message.PaintJobID, func(job *data.PaintJobEntity) error {
+		job.SubWorkshop = subWorkshop
+		if job.Status == data.PaintJobQueued {
+			job.Status = data.PaintJobRunning
+			job.UpdateTime = time.Now().UTC()
+		}
+		return nil
+	})
+	w.deps.Logger.WithError(err).Debug(ctx, "paint job %s delivered", message.PaintJobID)
+}
+
+// awaitCallback keeps a delivered message until its deadline, the message leaves the outbox once its job is done
+func (w *workshopController) awaitCallback(ctx context.Context, message *data.OutboxEntity) {
+	if message.Deadline.IsZero() {
+		w.removeFromOutbox(ctx, message.ID)
+		return
+	}
+	message.NextAttempt = message.Deadline
+	if err := w.deps.DB.RescheduleOutbox(ctx, message); err != nil {
+		w.deps.Logger.WithError(err).Warn(ctx, "failed to keep paint request of car %s until its deadline", message.CarNumber)
+	}
+}
+
+// paintRequestExpired fails a job the sub workshop didn't report back by the deadline of its paint request
+func (w *workshopController) paintRequestExpired(ctx context.Context, message *data.OutboxEntity, job *data.PaintJobEntity) {
+	cause := status.Errorf(codes.DeadlineExceeded, "car %s wasn't reported painted by %s", message.CarNumber, message.Deadline.Format(time.RFC3339Nano))
+	w.deps.Logger.WithError(cause).Warn(ctx, "paint job %s expired", job.ID)
+	if _, err := w.finishPaintJob(ctx, job.ID, data.PaintJobFailed, cause); err == nil {
+		w.undoPaintRequest(changedAs(ctx, workshopActor, job.SubWorkshop), message.CarNumber)
+	}
+	w.removeFromOutbox(ctx, message.ID)
+}
+
+func (w *workshopController) removeFromOutbox(ctx context.Context, id string) {
+	if err := w.deps.DB.RemoveOutbox(ctx, id); err != nil {
+		// it will be delivered again, the sub workshop ignores it
+		w.deps.Logger.WithError(err).Warn(ctx, "failed to remove %s from outbox", id)
+	}
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/monitor"
+	workshop "github.com/go-masonry/tutorial/07-makefile/api"
+	"github.com/go-masonry/tutorial/07-makefile/app/noop"
+	"go.uber.org/fx"
+)
+
+const (
+	// SubWorkshopWorkersKey is the number of cars painted at the same time
+	SubWorkshopWorkersKey = "custom.subworkshop.workers"
+	// SubWorkshopQueueSizeKey is how many paint requests may wait for a free worker, more are rejected
+	SubWorkshopQueueSizeKey = "custom.subworkshop.queue"
+	// SubWorkshopPaintTimeoutKey is how long a queued car may take to be painted and reported back to the workshop,
+	// it should be shorter than the deadline the workshop gives its paint jobs
+	SubWorkshopPaintTimeoutKey = "custom.subworkshop.paint.timeout"
+
+	defaultSubWorkshopWorkers      = 4
+	defaultSubWorkshopQueueSize    = 100
+	defaultSubWorkshopPaintTimeout = time.Minute
+)
+
+// paintWorkers passes queued paint requests to paint in the background
+type paintWorkers struct {
+	sync.RWMutex
+	stopped bool
+	queue   chan queuedPaint
+	workers int
+	timeout time.Duration
+	paint   func(ctx context.Context, request *workshop.SubPaintCarRequest)
+	wg      sync.WaitGroup
+
+	queueDepth monitor.Gauge
+	inFlight   monitor.Gauge
+}
+
+// queuedPaint is a paint request and when it must be painted and reported back by
+type queuedPaint struct {
+	request  *workshop.SubPaintCarRequest
+	deadline time.Time
+}
+
+// newPaintWorkers starts the workers with the application and drains the queue when it stops, metrics are optional
+func newPaintWorkers(config cfg.Config, metrics monitor.Metrics, lifecycle fx.Lifecycle, paint func(ctx context.Context, request *workshop.SubPaintCarRequest)) *paintWorkers {
+	workers := defaultSubWorkshopWorkers
+	if value := config.Get(SubWorkshopWorkersKey); value.IsSet() {
+		workers = value.Int()
+	}
+	queueSize := defaultSubWorkshopQueueSize
+	if value := config.Get(SubWorkshopQueueSizeKey); value.IsSet() {
+		queueSize = value.Int()
+	}
+	p := &paintWorkers{
+		queue:      make(chan queuedPaint, queueSize),
+		workers:    workers,
+		timeout:    defaultSubWorkshopPaintTimeout,
+		paint:      paint,
+		queueDepth: noop.Gauge{},
+		inFlight:   noop.Gauge{},
+	}
+	if value := config.Get(SubWorkshopPaintTimeoutKey); value.IsSet() {
+		p.timeout = value.Duration()
+	}
+	if metrics != nil {
+		p.queueDepth = metrics.Gauge("subworkshop_paint_queue_depth", "cars waiting to be painted")
+		p.inFlight = metrics.Gauge("subworkshop_paint_in_flight", "cars being painted")
+	}
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			p.start()
+			return nil
+		},
+		OnStop: p.drain,
+	})
+	return p
+}
+
+// enqueue never blocks, it fails if the queue is full or the workers are stopping.
+// The car must be painted and reported back within the paint timeout, the call that queued it returns right away.
+func (p *paintWorkers) enqueue(request *workshop.SubPaintCarRequest) error {
+	p.RLock()
+	defer p.RUnlock()
+	if p.stopped {
+		return ErrSubWorkshopStopping
+	}
+	select {
+	case p.queue <- queuedPaint{request: request, deadline: time.Now().Add(p.timeout)}:
+		p.queueDepth.Inc()
+		return nil
+	default:
+		return fmt.Errorf("%w: %d cars are waiting", ErrPaintQueueFull, cap(p.queue))
+	}
+}
+
+func (p *paintWorkers) start() {
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+}
+
+func (p *paintWorkers) work() {
+	defer p.wg.Done()
+	for queued := range p.queue {
+		p.queueDepth.Dec()
+		p.inFlight.Inc()
+		p.paintBefore(queued)
+		p.inFlight.Dec()
+	}
+}
+
+// paintBefore paints within the deadline of the queued car, the call that queued it is long gone
+func (p *paintWorkers) paintBefore(queued queuedPaint) {
+	ctx, cancel := context.WithDeadline(context.Background(), queued.deadline)
+	defer cancel()
+	p.paint(ctx, queued.request)
+}
+
+// drain stops accepting new cars and waits for the queued ones to be painted
+func (p *paintWorkers) drain(ctx context.Context) error {
+	p.Lock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.queue)
+	}
+	p.Unlock()
+	drained := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d cars were not painted, %w", len(p.queue), ctx.Err())
+	}
+}
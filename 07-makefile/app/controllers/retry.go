@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// CallbackRetryKey holds the sub workshop callback retry policy, see newRetryPolicy for the keys under it
+	CallbackRetryKey = "custom.subworkshop.callback.retry"
+	// CallbackRedeliveryIntervalKey is how often persisted callbacks are checked for ones that are due
+	CallbackRedeliveryIntervalKey = "custom.subworkshop.callback.queue.interval"
+
+	defaultCallbackRedeliveryInterval = 10 * time.Second
+)
+
+// retryPolicy is an exponential backoff with jitter
+type retryPolicy struct {
+	attempts   int
+	initial    time.Duration
+	max        time.Duration
+	multiplier float64
+	jitter     float64 // fraction of the delay that is randomized, 0.2 means +-20%
+}
+
+func newRetryPolicy(config cfg.Config, key string) retryPolicy {
+	policy := retryPolicy{
+		attempts:   5,
+		initial:    100 * time.Millisecond,
+		max:        10 * time.Second,
+		multiplier: 2,
+		jitter:     0.2,
+	}
+	if value := config.Get(key + ".attempts"); value.IsSet() {
+		policy.attempts = value.Int()
+	}
+	if value := config.Get(key + ".initial"); value.IsSet() {
+		policy.initial = value.Duration()
+	}
+	if value := config.Get(key + ".max"); value.IsSet() {
+		policy.max = value.Duration()
+	}
+	if value := config.Get(key + ".multiplier"); value.IsSet() {
+		policy.multiplier = value.Float64()
+	}
+	if value := config.Get(key + ".jitter"); value.IsSet() {
+		policy.jitter = value.Float64()
+	}
+	return policy
+}
+
+// backoff is how long to wait after the given (1 based) failed attempt
+func (r retryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(r.initial) * math.Pow(r.multiplier, float64(attempt-1))
+	if delay > float64(r.max) {
+		delay = float64(r.max)
+	}
+	delay += delay * r.jitter * (2*rand.Float64() - 1)
+	return time.Duration(delay)
+}
+
+// retryable tells if calling again may succeed, errors without a gRPC status (dial errors) are retryable
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied,
+		codes.FailedPrecondition, codes.OutOfRange, codes.Unimplemented, codes.Unauthenticated:
+		return false
+	default:
+		return true
+	}
+}
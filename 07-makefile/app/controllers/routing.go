@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/log"
+	workshop "github.com/go-masonry/tutorial/07-makefile/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// SubWorkshopsKey holds sub workshops by name, each with address, transport, colors and body_styles.
+	// When it isn't set the single sub workshop under SubWorkshopAddressKey is used.
+	SubWorkshopsKey = "custom.workshop.subworkshops"
+	// SubWorkshopRoutingKey selects how a sub workshop is picked among the ones that can paint a car,
+	// one of RoutingRoundRobin (default), RoutingLeastLoaded or RoutingColorAffinity
+	SubWorkshopRoutingKey = "custom.workshop.routing.strategy"
+	// SubWorkshopEjectionFailuresKey is how many failed calls in a row eject a sub workshop
+	SubWorkshopEjectionFailuresKey = "custom.workshop.routing.ejection.failures"
+	// SubWorkshopEjectionDurationKey is how long an ejected sub workshop isn't sent cars
+	SubWorkshopEjectionDurationKey = "custom.workshop.routing.ejection.duration"
+
+	// RoutingRoundRobin sends cars to sub workshops in turns
+	RoutingRoundRobin = "round_robin"
+	// RoutingLeastLoaded sends cars to the sub workshop with the fewest calls in flight
+	RoutingLeastLoaded = "least_loaded"
+	// RoutingColorAffinity sends every color to the same sub workshop, preferring the ones that list it
+	RoutingColorAffinity = "color_affinity"
+
+	defaultSubWorkshopName  = "default"
+	defaultEjectionFailures = 3
+	defaultEjectionDuration = 30 * time.Second
+)
+
+// subWorkshopEndpoint is a single sub workshop and what the router knows about it
+type subWorkshopEndpoint struct {
+	name       string
+	address    string
+	transport  string
+	colors     map[string]struct{} // any color if empty
+	bodyStyles map[string]struct{} // any body style if empty
+	client     subWorkshopClient
+	retired    bool // replaced by a registration at another address, its client is closed once inFlight is 0
+
+	inFlight     int
+	sent         int
+	failures     int // in a row
+	ejectedUntil time.Time
+}
+
+func (e *subWorkshopEndpoint) canPaint(request *workshop.SubPaintCarRequest) bool {
+	if _, ok := e.colors[strings.ToLower(request.GetDesiredColor())]; len(e.colors) > 0 && !ok {
+		return false
+	}
+	_, ok := e.bodyStyles[request.GetCar().GetBodyStyle().String()]
+	return len(e.bodyStyles) == 0 || ok
+}
+
+// routingStrategy picks one of the candidates, it is called with the router locked
+type routingStrategy interface {
+	pick(candidates []*subWorkshopEndpoint, request *workshop.SubPaintCarRequest) *subWorkshopEndpoint
+}
+
+type roundRobin struct {
+	next int
+}
+
+func (r *roundRobin) pick(candidates []*subWorkshopEndpoint, _ *workshop.SubPaintCarRequest) *subWorkshopEndpoint {
+	endpoint := candidates[r.next%len(candidates)]
+	r.next++
+	return endpoint
+}
+
+type leastLoaded struct{}
+
+func (leastLoaded) pick(candidates []*subWorkshopEndpoint, _ *workshop.SubPaintCarRequest) *subWorkshopEndpoint {
+	picked := candidates[0]
+	for _, endpoint := range candidates[1:] {
+		if endpoint.inFlight < picked.inFlight || (endpoint.inFlight == picked.inFlight && endpoint.sent < picked.sent) {
+			picked = endpoint
+		}
+	}
+	return picked
+}
+
+type colorAffinity struct{}
+
+func (colorAffinity) pick(candidates []*subWorkshopEndpoint, request *workshop.SubPaintCarRequest) *subWorkshopEndpoint {
+	color := strings.ToLower(request.GetDesiredColor())
+	var specialists []*subWorkshopEndpoint
+	for _, endpoint := range candidates {
+		if _, ok := endpoint.colors[color]; ok {
+			specialists = append(specialists, endpoint)
+		}
+	}
+	if len(specialists) > 0 {
+		candidates = specialists
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(color))
+	return candidates[hash.Sum32()%uint32(len(candidates))]
+}
+
+func newRoutingStrategy(name string) (routingStrategy, error) {
+	switch name {
+	case "", RoutingRoundRobin:
+		return new(roundRobin), nil
+	case RoutingLeastLoaded:
+		return leastLoaded{}, nil
+	case RoutingColorAffinity:
+		return colorAffinity{}, nil
+	default:
+		return nil, fmt.Errorf("unknown routing strategy %s", name)
+	}
+}
+
+// subWorkshopRouter sends every paint request to one of the sub workshops that can paint it.
+// Sub workshops failing too many calls in a row are ejected for a while, but when every one that can paint
+// a car is ejected they are tried anyway.
+type subWorkshopRouter struct {
+	sync.Mutex
+	endpoints        []*subWorkshopEndpoint // sorted by name
+	strategy         routingStrategy
+	ejectionFailures int
+	ejectionDuration time.Duration
+	newClient        func(transport, address string) (subWorkshopClient, error)
+	logger           log.Logger
+}
+
+func newSubWorkshopRouter(config cfg.Config, logger log.Logger, newClient func(transport, address string) (subWorkshopClient, error)) (*subWorkshopRouter, error) {
+	strategy, err := newRoutingStrategy(config.Get(SubWorkshopRoutingKey).String())
+	if err != nil {
+		return nil, err
+	}
+	router := &subWorkshopRouter{
+		strategy:         strategy,
+		ejectionFailures: defaultEjectionFailures,
+		ejectionDuration: defaultEjectionDuration,
+		newClient:        newClient,
+		logger:           logger,
+	}
+	if value := config.Get(SubWorkshopEjectionFailuresKey); value.IsSet() {
+		router.ejectionFailures = value.Int()
+	}
+	if value := config.Get(SubWorkshopEjectionDurationKey); value.IsSet() {
+		router.ejectionDuration = value.Duration()
+	}
+	names := config.Get(SubWorkshopsKey).StringMap()
+	if len(names) == 0 {
+		transport := config.Get(SubWorkshopTransportKey).String()
+		address := defaultSubWorkshopAddress(config, transport)
+		if value := config.Get(SubWorkshopAddressKey); value.IsSet() {
+			address = value.String()
+		}
+		return router, router.register(defaultSubWorkshopName, transport, address, nil, nil)
+	}
+	for name := range names {
+		key := SubWorkshopsKey + "." + name
+		address := config.Get(key + ".address").String()
+		if len(address) == 0 {
+			return nil, fmt.Errorf("sub workshop %s has no address", name)
+		}
+		if err = router.register(name, config.Get(key+".transport").String(), address,
+			config.Get(key+".colors").StringSlice(), config.Get(key+".body_styles").StringSlice()); err != nil {
+			return nil, err
+		}
+	}
+	return router, nil
+}
+
+// register adds a sub workshop, one with the same name is replaced.
+// The connection of the replaced one is reused when its address and transport didn't change, otherwise it's closed
+// once its calls in flight are done.
+func (r *subWorkshopRouter) register(name, transport, address string, colors, bodyStyles []string) error {
+	endpoint := &subWorkshopEndpoint{
+		name:       name,
+		address:    address,
+		transport:  transport,
+		colors:     make(map[string]struct{}, len(colors)),
+		bodyStyles: make(map[string]struct{}, len(bodyStyles)),
+	}
+	for _, color := range colors {
+		endpoint.colors[strings.ToLower(color)] = struct{}{}
+	}
+	for _, bodyStyle := range bodyStyles {
+		endpoint.bodyStyles[strings.ToUpper(bodyStyle)] = struct{}{}
+	}
+	r.Lock()
+	defer r.Unlock()
+	index := sort.Search(len(r.endpoints), func(i int) bool { return r.endpoints[i].name >= name })
+	if index < len(r.endpoints) && r.endpoints[index].name == name {
+		replaced := r.endpoints[index]
+		if replaced.address == address && replaced.transport == transport {
+			endpoint.client = replaced.client
+			r.endpoints[index] = endpoint
+			return nil
+		}
+		client, err := r.newClient(transport, address)
+		if err != nil {
+			return err
+		}
+		endpoint.client = client
+		r.endpoints[index] = endpoint
+		replaced.retired = true
+		r.closeIfDone(replaced)
+		return nil
+	}
+	client, err := r.newClient(transport, address)
+	if err != nil {
+		return err
+	}
+	endpoint.client = client
+	r.endpoints = append(r.endpoints, nil)
+	copy(r.endpoints[index+1:], r.endpoints[index:])
+	r.endpoints[index] = endpoint
+	return nil
+}
+
+// closeIfDone closes the client of a replaced sub workshop once it has no calls in flight, it is called with the router locked
+func (r *subWorkshopRouter) closeIfDone(endpoint *subWorkshopEndpoint) {
+	if !endpoint.retired || endpoint.inFlight > 0 {
+		return
+	}
+	err := endpoint.client.Close()
+	r.logger.WithError(err).Debug(context.Background(), "closed the connection to sub workshop %s at %s", endpoint.name, endpoint.address)
+}
+
+// close closes the connections to all the sub workshops, it's called when the application stops
+func (r *subWorkshopRouter) close(ctx context.Context) {
+	r.Lock()
+	defer r.Unlock()
+	for _, endpoint := range r.endpoints {
+		err := endpoint.client.Close()
+		r.logger.WithError(err).Debug(ctx, "closed the connection to sub workshop %s at %s", endpoint.name, endpoint.address)
+	}
+}
+
+// PaintCar sends the car to one of the sub workshops that can paint it and returns its name
+func (r *subWorkshopRouter) PaintCar(ctx context.Context, request *workshop.SubPaintCarRequest) (string, error) {
+	endpoint, err := r.acquire(request)
+	if err != nil {
+		return "", err
+	}
+	// not wrapped, the gRPC status tells if it should be retried
+	err = endpoint.client.PaintCar(ctx, request)
+	r.release(ctx, endpoint, err)
+	if status.Code(err) == codes.DeadlineExceeded {
+		r.logger.WithError(err).Warn(ctx, "sub workshop %s didn't answer about car %s in time", endpoint.name, request.GetCar().GetNumber())
+	}
+	r.logger.WithError(err).Debug(ctx, "car %s sent to sub workshop %s", request.GetCar().GetNumber(), endpoint.name)
+	return endpoint.name, err
+}
+
+func (r *subWorkshopRouter) acquire(request *workshop.SubPaintCarRequest) (*subWorkshopEndpoint, error) {
+	r.Lock()
+	defer r.Unlock()
+	now := time.Now()
+	var capable, healthy []*subWorkshopEndpoint
+	for _, endpoint := range r.endpoints {
+		if !endpoint.canPaint(request) {
+			continue
+		}
+		capable = append(capable, endpoint)
+		if !now.Before(endpoint.ejectedUntil) {
+			healthy = append(healthy, endpoint)
+		}
+	}
+	if len(capable) == 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoSubWorkshop, request.GetDesiredColor(), request.GetCar().GetBodyStyle())
+	}
+	if len(healthy) == 0 {
+		healthy = capable
+	}
+	endpoint := r.strategy.pick(healthy, request)
+	endpoint.inFlight++
+	endpoint.sent++
+	return endpoint, nil
+}
+
+func (r *subWorkshopRouter) release(ctx context.Context, endpoint *subWorkshopEndpoint, err error) {
+	r.Lock()
+	defer r.Unlock()
+	endpoint.inFlight--
+	r.closeIfDone(endpoint)
+	if err == nil {
+		endpoint.failures = 0
+		return
+	}
+	// refused or busy sub workshops are still healthy
+	if !retryable(err) || status.Code(err) == codes.ResourceExhausted {
+		return
+	}
+	if endpoint.failures++; endpoint.failures >= r.ejectionFailures {
+		endpoint.failures = 0
+		endpoint.ejectedUntil = time.Now().Add(r.ejectionDuration)
+		r.logger.WithError(err).Warn(ctx, "sub workshop %s at %s ejected for %s", endpoint.name, endpoint.address, r.ejectionDuration)
+	}
+}
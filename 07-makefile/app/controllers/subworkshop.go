@@ -3,13 +3,33 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"path"
+	"strings"
+	"time"
 
+	"github.com/go-masonry/mortar/interfaces/cfg"
 	"github.com/go-masonry/mortar/interfaces/http/client"
 	"github.com/go-masonry/mortar/interfaces/log"
+	"github.com/go-masonry/mortar/interfaces/monitor"
 	workshop "github.com/go-masonry/tutorial/07-makefile/api"
+	"github.com/go-masonry/tutorial/07-makefile/app/data"
+	"github.com/go-masonry/tutorial/07-makefile/app/security"
 	"github.com/golang/protobuf/ptypes/empty"
 	"go.uber.org/fx"
-	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// SubWorkshopPaintDurationKey is a map from lower case body style to how long painting it takes, no time by default
+	SubWorkshopPaintDurationKey = "custom.subworkshop.paint.duration"
+	// CallbackTimeoutKey limits every call back to the workshop, the deadline is sent along with the call
+	CallbackTimeoutKey = "custom.subworkshop.callback.timeout"
+	// CallbackAllowlistKey lists the addresses paint requests may ask to be called back at, as host:port patterns
+	// such as "workshop-*:5380". Only localhost is allowed when it's not set.
+	CallbackAllowlistKey = "custom.subworkshop.callback.allowed"
+
+	defaultCallbackTimeout = 5 * time.Second
 )
 
 // SubWorkshopController responsible for the business logic of our Sub Workshop
@@ -20,40 +40,266 @@ type SubWorkshopController interface {
 type subWorkshopControllerDeps struct {
 	fx.In
 
+	Config            cfg.Config
 	Logger            log.Logger
+	Metrics           monitor.Metrics `optional:"true"`
+	Lifecycle         fx.Lifecycle
 	GRPCClientBuilder client.GRPCClientConnectionBuilder
+	TLS               security.TLS
+	Callbacks         data.CallbackQueue
 }
 
 type subWorkshopController struct {
-	deps subWorkshopControllerDeps
+	deps               subWorkshopControllerDeps
+	paintDurations     map[string]time.Duration
+	workers            *paintWorkers
+	received           *recentIDs
+	retry              retryPolicy
+	redeliveryInterval time.Duration
+	callbackTimeout    time.Duration
+	callbackAllowlist  []string
+	connections        *grpcConnections
+	stopping           chan struct{}
+	redeliveryDone     chan struct{}
 }
 
 // CreateSubWorkshopController is a constructor to be used by Fx
 func CreateSubWorkshopController(deps subWorkshopControllerDeps) SubWorkshopController {
-	return &subWorkshopController{
-		deps: deps,
+	s := &subWorkshopController{
+		deps:               deps,
+		paintDurations:     make(map[string]time.Duration),
+		retry:              newRetryPolicy(deps.Config, CallbackRetryKey),
+		redeliveryInterval: defaultCallbackRedeliveryInterval,
+		callbackTimeout:    defaultCallbackTimeout,
+		callbackAllowlist:  []string{"localhost:*", "127.0.0.1:*"},
+		stopping:           make(chan struct{}),
+		redeliveryDone:     make(chan struct{}),
+	}
+	for bodyStyle := range deps.Config.Get(SubWorkshopPaintDurationKey).StringMap() {
+		s.paintDurations[strings.ToLower(bodyStyle)] = deps.Config.Get(SubWorkshopPaintDurationKey + "." + bodyStyle).Duration()
+	}
+	if value := deps.Config.Get(CallbackRedeliveryIntervalKey); value.IsSet() {
+		s.redeliveryInterval = value.Duration()
+	}
+	if value := deps.Config.Get(CallbackTimeoutKey); value.IsSet() {
+		s.callbackTimeout = value.Duration()
 	}
+	if value := deps.Config.Get(CallbackAllowlistKey); value.IsSet() {
+		s.callbackAllowlist = value.StringSlice()
+	}
+	dedupSize := defaultSubWorkshopDedupSize
+	if value := deps.Config.Get(SubWorkshopDedupSizeKey); value.IsSet() {
+		dedupSize = value.Int()
+	}
+	s.received = newRecentIDs(dedupSize)
+	// appended first to be closed last, after the workers made their callbacks
+	s.connections = newGRPCConnections(deps.Config, deps.Logger, deps.Metrics, deps.Lifecycle, deps.GRPCClientBuilder, deps.TLS.GRPCDialOption())
+	s.workers = newPaintWorkers(deps.Config, deps.Metrics, deps.Lifecycle, s.paintAndCallback)
+	// hooks are stopped in reverse order, pending retries are persisted before the workers drain
+	deps.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go s.redeliver()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(s.stopping)
+			select {
+			case <-s.redeliveryDone:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+	return s
 }
 
+// PaintCar only queues the car, it is painted and reported back to the caller later.
+// Workshop may send the same paint job more than once, repeated requests are acknowledged without painting again.
 func (s *subWorkshopController) PaintCar(ctx context.Context, request *workshop.SubPaintCarRequest) (*empty.Empty, error) {
-	// Paint car
-	if err := s.doActualPaint(ctx, request.GetCar()); err != nil {
+	if !s.callbackAllowed(request.GetCallbackServiceAddress()) {
+		s.deps.Logger.Warn(ctx, "refusing to paint car %s, callback address %s is not allowed", request.GetCar().GetNumber(), request.GetCallbackServiceAddress())
+		return nil, fmt.Errorf("%w: %s", ErrCallbackNotAllowed, request.GetCallbackServiceAddress())
+	}
+	jobID := request.GetPaintJobId()
+	if len(jobID) > 0 && !s.received.add(jobID) {
+		s.deps.Logger.Debug(ctx, "paint job %s already received", jobID)
+		return &empty.Empty{}, nil
+	}
+	if err := s.workers.enqueue(request); err != nil {
+		if len(jobID) > 0 {
+			// let the workshop try again
+			s.received.forget(jobID)
+		}
 		return nil, err
 	}
-	wrapper := s.deps.GRPCClientBuilder.Build()
-	// Dial back to caller
-	conn, err := wrapper.Dial(ctx, request.GetCallbackServiceAddress(), grpc.WithInsecure())
+	return &empty.Empty{}, nil
+}
+
+// paintAndCallback paints and calls back within the deadline of ctx, see SubWorkshopPaintTimeoutKey.
+// A car that isn't reported back in time is left to the workshop, which fails its paint job.
+func (s *subWorkshopController) paintAndCallback(ctx context.Context, request *workshop.SubPaintCarRequest) {
+	if err := s.doActualPaint(ctx, request.GetCar()); err != nil {
+		s.deps.Logger.WithError(err).Warn(ctx, "failed to paint car %s, the workshop fails its paint job", request.GetCar().GetNumber())
+		return
+	}
+	deadline, _ := ctx.Deadline()
+	s.deliver(ctx, &data.CallbackEntity{
+		ID:              newID(time.Now()),
+		CarNumber:       request.GetCar().GetNumber(),
+		DesiredColor:    request.GetDesiredColor(),
+		PaintJobID:      request.GetPaintJobId(),
+		CallbackAddress: request.GetCallbackServiceAddress(),
+		CallbackToken:   request.GetCallbackToken(),
+		Deadline:        deadline,
+	})
+}
+
+// deliver calls back with retries, callbacks that keep failing are persisted and redelivered later
+func (s *subWorkshopController) deliver(ctx context.Context, callback *data.CallbackEntity) {
+	for {
+		err := s.callback(ctx, callback)
+		if err == nil {
+			return
+		}
+		if !retryable(err) {
+			s.deps.Logger.WithError(err).Warn(ctx, "workshop refused painted car %s", callback.CarNumber)
+			return
+		}
+		if ctx.Err() != nil {
+			s.deps.Logger.WithError(err).Warn(ctx, "car %s painted but the deadline of calling back passed", callback.CarNumber)
+			return
+		}
+		callback.Attempts++
+		callback.LastError = err.Error()
+		if callback.Attempts >= s.retry.attempts || !s.wait(s.retry.backoff(callback.Attempts)) {
+			break
+		}
+	}
+	callback.NextAttempt = time.Now().Add(s.retry.backoff(callback.Attempts))
+	if err := s.deps.Callbacks.Push(ctx, callback); err != nil {
+		s.deps.Logger.WithError(err).Error(ctx, "car %s painted but the result is lost", callback.CarNumber)
+		return
+	}
+	s.deps.Logger.Warn(ctx, "car %s painted but we can't callback after %d attempts, will retry later", callback.CarNumber, callback.Attempts)
+}
+
+// wait returns false if the application is stopping before duration passes
+func (s *subWorkshopController) wait(duration time.Duration) bool {
+	select {
+	case <-time.After(duration):
+		return true
+	case <-s.stopping:
+		return false
+	}
+}
+
+// redeliver periodically retries persisted callbacks until the application stops
+func (s *subWorkshopController) redeliver() {
+	defer close(s.redeliveryDone)
+	ticker := time.NewTicker(s.redeliveryInterval)
+	defer ticker.Stop()
+	ctx := context.Background()
+	for {
+		select {
+		case <-s.stopping:
+			return
+		case <-ticker.C:
+		}
+		due, err := s.deps.Callbacks.Due(ctx, time.Now(), s.workers.workers)
+		if err != nil {
+			s.deps.Logger.WithError(err).Warn(ctx, "failed to load callbacks")
+			continue
+		}
+		for _, callback := range due {
+			s.redeliverOnce(ctx, callback)
+		}
+	}
+}
+
+// redeliverOnce retries a persisted callback, it stays queued until it is delivered, refused or its deadline passed
+func (s *subWorkshopController) redeliverOnce(ctx context.Context, callback *data.CallbackEntity) {
+	err := s.callbackBefore(ctx, callback)
+	if err != nil && retryable(err) && !callbackExpired(callback) {
+		callback.Attempts++
+		callback.LastError = err.Error()
+		callback.NextAttempt = time.Now().Add(s.retry.backoff(callback.Attempts))
+		if err = s.deps.Callbacks.Push(ctx, callback); err != nil {
+			s.deps.Logger.WithError(err).Warn(ctx, "failed to reschedule callback of car %s", callback.CarNumber)
+		}
+		return
+	}
+	switch {
+	case err != nil && callbackExpired(callback):
+		s.deps.Logger.WithError(err).Warn(ctx, "car %s painted but the deadline of calling back passed, the workshop fails its paint job", callback.CarNumber)
+	case err != nil:
+		s.deps.Logger.WithError(err).Warn(ctx, "workshop refused painted car %s", callback.CarNumber)
+	}
+	if err = s.deps.Callbacks.Remove(ctx, callback.ID); err != nil {
+		s.deps.Logger.WithError(err).Warn(ctx, "failed to remove callback of car %s", callback.CarNumber)
+	}
+}
+
+// callbackBefore calls back within the deadline of the paint request, it fails with DeadlineExceeded once the deadline passed
+func (s *subWorkshopController) callbackBefore(ctx context.Context, callback *data.CallbackEntity) error {
+	if callback.Deadline.IsZero() {
+		return s.callback(ctx, callback)
+	}
+	if callbackExpired(callback) {
+		return status.Errorf(codes.DeadlineExceeded, "callback of car %s missed its deadline", callback.CarNumber)
+	}
+	ctx, cancel := context.WithDeadline(ctx, callback.Deadline)
+	defer cancel()
+	return s.callback(ctx, callback)
+}
+
+// callbackExpired is true once the deadline of the paint request passed, the workshop no longer waits for the callback then
+func callbackExpired(callback *data.CallbackEntity) bool {
+	return !callback.Deadline.IsZero() && !time.Now().Before(callback.Deadline)
+}
+
+func (s *subWorkshopController) callback(ctx context.Context, callback *data.CallbackEntity) error {
+	ctx, cancel := context.WithTimeout(ctx, s.callbackTimeout)
+	defer cancel()
+	// Dial back to caller, or reuse the connection of previous callbacks
+	conn, err := s.connections.get(ctx, callback.CallbackAddress)
 	if err != nil {
-		return nil, fmt.Errorf("car painted but we can't callback to %s, %w", request.GetCallbackServiceAddress(), err)
+		return fmt.Errorf("car painted but we can't callback to %s, %w", callback.CallbackAddress, err)
 	}
 	// Make client and call method
 	workshopClient := workshop.NewWorkshopClient(conn)
-	return workshopClient.CarPainted(ctx, &workshop.PaintFinishedRequest{CarNumber: request.GetCar().GetNumber(), DesiredColor: request.GetDesiredColor()})
+	_, err = workshopClient.CarPainted(ctx, &workshop.PaintFinishedRequest{
+		CarNumber:     callback.CarNumber,
+		DesiredColor:  callback.DesiredColor,
+		PaintJobId:    callback.PaintJobID,
+		CallbackToken: callback.CallbackToken,
+	})
+	if status.Code(err) == codes.Unavailable {
+		s.connections.evict(ctx, callback.CallbackAddress, "the workshop is unavailable")
+	}
+	if status.Code(err) == codes.DeadlineExceeded {
+		s.deps.Logger.WithError(err).Warn(ctx, "workshop at %s didn't answer about painted car %s within %s", callback.CallbackAddress, callback.CarNumber, s.callbackTimeout)
+	}
+	return err
 }
 
+// callbackAllowed is true if address matches one of the allowed patterns, so that requests can't make us call arbitrary hosts
+func (s *subWorkshopController) callbackAllowed(address string) bool {
+	for _, pattern := range s.callbackAllowlist {
+		if matched, err := path.Match(pattern, address); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// doActualPaint pretends to paint, it takes as long as configured for the car body style
 func (s *subWorkshopController) doActualPaint(ctx context.Context, car *workshop.Car) error {
-	// here be paint logic...
-	// ...
-	// ...
-	return nil
+	duration := s.paintDurations[strings.ToLower(car.GetBodyStyle().String())]
+	select {
+	case <-time.After(duration):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
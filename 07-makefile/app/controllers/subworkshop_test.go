@@ -2,30 +2,43 @@ package controllers_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/go-masonry/mortar/interfaces/cfg"
 	"github.com/go-masonry/mortar/interfaces/http/client"
 	mock_client "github.com/go-masonry/mortar/interfaces/http/client/mock"
 	workshop "github.com/go-masonry/tutorial/07-makefile/api"
 	"github.com/go-masonry/tutorial/07-makefile/app/controllers"
+	"github.com/go-masonry/tutorial/07-makefile/app/data"
 	"github.com/go-masonry/tutorial/07-makefile/app/mortar"
+	"github.com/go-masonry/tutorial/07-makefile/app/security"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type subWorkshopSuite struct {
 	suite.Suite
 	pwd                 string
+	dbDir               string
 	ctrl                *gomock.Controller
 	app                 *fxtest.App
+	stopped             bool
 	grpcConnBuilderMock *mock_client.MockGRPCClientConnectionBuilder
 	gRPCWrapperMock     *mock_client.MockGRPCClientConnectionWrapper
 	subController       controllers.SubWorkshopController
+	callbacks           data.CallbackQueue
 }
 
 func TestSubWorkshop(t *testing.T) {
@@ -44,21 +57,341 @@ func (s *subWorkshopSuite) TestPaintCar() {
 		CallbackServiceAddress: "/dev/null",
 	})
 	s.NoError(err)
-	// It's not really necessary, but for the sake of the example you get see it's called
-	s.Equal(1, fakeConnection.callCounter)
+	// Car is painted in the background, it's not really necessary, but for the sake of the example you get see it's called
+	s.Eventually(func() bool { return fakeConnection.calls() == 1 }, time.Second, time.Millisecond)
 }
 
 func (s *subWorkshopSuite) TestPaintCarWithFailingDialer() {
 	// Prepare mocks
-	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("just void"))
+	dialed := make(chan struct{}, 1)
+	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, string, ...grpc.DialOption) (grpc.ClientConnInterface, error) {
+			select {
+			case dialed <- struct{}{}:
+			default:
+			}
+			return nil, fmt.Errorf("just void")
+		}).AnyTimes()
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock).AnyTimes()
+
+	// Callback fails long after the caller got its response, it is retried in the background
+	_, err := s.subController.PaintCar(context.Background(), &workshop.SubPaintCarRequest{
+		Car:                    &workshop.Car{Number: "1234"},
+		DesiredColor:           "black",
+		CallbackServiceAddress: "/dev/null",
+	})
+	s.NoError(err)
+	select {
+	case <-dialed:
+	case <-time.After(time.Second):
+		s.Fail("sub workshop never called back")
+	}
+}
+
+func (s *subWorkshopSuite) TestQueueIsDrainedOnStop() {
+	fakeConnection := new(fakeGRPCConnection)
+	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeConnection, nil).AnyTimes()
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock).AnyTimes()
+
+	// a single worker paints a hatchback in 50ms and only 2 more may wait
+	var queued int32
+	for i := 0; i < 4; i++ {
+		_, err := s.subController.PaintCar(context.Background(), &workshop.SubPaintCarRequest{
+			Car:                    &workshop.Car{Number: fmt.Sprintf("1234%d", i), BodyStyle: workshop.Car_HATCHBACK},
+			DesiredColor:           "black",
+			CallbackServiceAddress: "/dev/null",
+		})
+		if err != nil {
+			s.True(errors.Is(err, controllers.ErrPaintQueueFull))
+			continue
+		}
+		queued++
+	}
+	s.Less(queued, int32(4))
+	s.stopApp()
+	// every queued car was painted and reported before the application stopped
+	s.Equal(queued, fakeConnection.calls())
+	_, err := s.subController.PaintCar(context.Background(), &workshop.SubPaintCarRequest{
+		Car:                    &workshop.Car{Number: "12345"},
+		CallbackServiceAddress: "/dev/null",
+	})
+	s.True(errors.Is(err, controllers.ErrSubWorkshopStopping))
+}
+
+func (s *subWorkshopSuite) TestRepeatedPaintJobIsPaintedOnce() {
+	fakeConnection := new(fakeGRPCConnection)
+	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeConnection, nil)
 	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock)
 
+	// workshop sends the paint job again when it doesn't know the first request was received
+	for i := 0; i < 3; i++ {
+		_, err := s.subController.PaintCar(context.Background(), &workshop.SubPaintCarRequest{
+			Car:                    &workshop.Car{Number: "1234"},
+			DesiredColor:           "black",
+			CallbackServiceAddress: "/dev/null",
+			PaintJobId:             "job",
+		})
+		s.NoError(err)
+	}
+	s.stopApp()
+	s.Equal(int32(1), fakeConnection.calls())
+}
+
+func (s *subWorkshopSuite) TestCallbackIsRetried() {
+	fakeConnection := new(fakeGRPCConnection)
+	gomock.InOrder(
+		s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("not yet")).Times(2),
+		s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeConnection, nil),
+	)
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock).Times(3)
+
 	_, err := s.subController.PaintCar(context.Background(), &workshop.SubPaintCarRequest{
 		Car:                    &workshop.Car{Number: "1234"},
 		DesiredColor:           "black",
 		CallbackServiceAddress: "/dev/null",
 	})
-	s.EqualError(err, "car painted but we can't callback to /dev/null, just void")
+	s.NoError(err)
+	s.Eventually(func() bool { return fakeConnection.calls() == 1 }, time.Second, time.Millisecond)
+	s.Empty(s.dueCallbacks())
+}
+
+func (s *subWorkshopSuite) TestRefusedCallbackIsNotRetried() {
+	fakeConnection := &fakeGRPCConnection{err: status.Error(codes.FailedPrecondition, "car is not being painted")}
+	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeConnection, nil)
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock)
+
+	_, err := s.subController.PaintCar(context.Background(), &workshop.SubPaintCarRequest{
+		Car:                    &workshop.Car{Number: "1234"},
+		CallbackServiceAddress: "/dev/null",
+	})
+	s.NoError(err)
+	s.stopApp()
+	s.Equal(int32(1), fakeConnection.calls())
+}
+
+func (s *subWorkshopSuite) TestCallbackHasDeadline() {
+	fakeConnection := &fakeGRPCConnection{hang: true}
+	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeConnection, nil).AnyTimes()
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock).AnyTimes()
+
+	_, err := s.subController.PaintCar(context.Background(), &workshop.SubPaintCarRequest{
+		Car:                    &workshop.Car{Number: "1234"},
+		CallbackServiceAddress: "/dev/null",
+	})
+	s.NoError(err)
+	// every attempt runs out of time, after 3 of them the callback is persisted
+	s.Eventually(func() bool { return len(s.dueCallbacks()) == 1 }, time.Second, time.Millisecond)
+	s.stopApp()
+	callbacks := s.dueCallbacks()
+	s.Require().Len(callbacks, 1)
+	s.Contains(callbacks[0].LastError, codes.DeadlineExceeded.String())
+}
+
+func (s *subWorkshopSuite) TestCallbackHasPaintTimeout() {
+	s.stopApp()
+	s.startApp(fx.Invoke(func(config cfg.Config) {
+		config.Set(controllers.CallbackTimeoutKey, "1m")
+		config.Set(controllers.SubWorkshopPaintTimeoutKey, "100ms")
+	}))
+	fakeConnection := &fakeGRPCConnection{hang: true}
+	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeConnection, nil).AnyTimes()
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock).AnyTimes()
+
+	_, err := s.subController.PaintCar(context.Background(), &workshop.SubPaintCarRequest{
+		Car:                    &workshop.Car{Number: "1234"},
+		CallbackServiceAddress: "/dev/null",
+	})
+	s.NoError(err)
+	// the workshop stopped waiting, the callback is neither retried nor persisted
+	s.Eventually(func() bool { return fakeConnection.calls() == 1 }, time.Second, time.Millisecond)
+	s.stopApp()
+	s.Equal(int32(1), fakeConnection.calls())
+	s.Empty(s.dueCallbacks())
+}
+
+func (s *subWorkshopSuite) TestPaintingOutlivesThePaintRequest() {
+	fakeConnection := new(fakeGRPCConnection)
+	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeConnection, nil)
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock)
+
+	// the hatchback takes 50ms to paint, the workshop stops waiting for the paint request long before
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := s.subController.PaintCar(ctx, &workshop.SubPaintCarRequest{
+		Car:                    &workshop.Car{Number: "1234", BodyStyle: workshop.Car_HATCHBACK},
+		CallbackServiceAddress: "/dev/null",
+	})
+	s.NoError(err)
+	s.Eventually(func() bool { return fakeConnection.calls() == 1 }, time.Second, time.Millisecond)
+}
+
+func (s *subWorkshopSuite) TestFailingCallbackIsPersistedAndRedelivered() {
+	fakeConnection := new(fakeGRPCConnection)
+	var workshopIsUp int32
+	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, string, ...grpc.DialOption) (grpc.ClientConnInterface, error) {
+			if atomic.LoadInt32(&workshopIsUp) == 0 {
+				return nil, fmt.Errorf("workshop is down")
+			}
+			return fakeConnection, nil
+		}).AnyTimes()
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock).AnyTimes()
+
+	_, err := s.subController.PaintCar(context.Background(), &workshop.SubPaintCarRequest{
+		Car:                    &workshop.Car{Number: "1234"},
+		DesiredColor:           "black",
+		CallbackServiceAddress: "/dev/null",
+		PaintJobId:             "job",
+	})
+	s.NoError(err)
+	// after 3 attempts the callback is persisted
+	s.Eventually(func() bool { return len(s.dueCallbacks()) == 1 }, time.Second, time.Millisecond)
+	s.stopApp()
+	callbacks := s.dueCallbacks()
+	s.Require().Len(callbacks, 1)
+	s.Equal("1234", callbacks[0].CarNumber)
+	s.Equal("black", callbacks[0].DesiredColor)
+	s.Equal("job", callbacks[0].PaintJobID)
+	s.GreaterOrEqual(callbacks[0].Attempts, 3)
+	s.Equal("car painted but we can't callback to /dev/null, workshop is down", callbacks[0].LastError)
+
+	// the workshop is back after a restart
+	atomic.StoreInt32(&workshopIsUp, 1)
+	s.startApp()
+	s.Eventually(func() bool { return fakeConnection.calls() == 1 }, time.Second, time.Millisecond)
+	s.Eventually(func() bool { return len(s.dueCallbacks()) == 0 }, time.Second, time.Millisecond)
+}
+
+func (s *subWorkshopSuite) TestExpiredCallbackIsRemoved() {
+	var dials int32
+	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, string, ...grpc.DialOption) (grpc.ClientConnInterface, error) {
+			atomic.AddInt32(&dials, 1)
+			return nil, fmt.Errorf("workshop is down")
+		}).AnyTimes()
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock).AnyTimes()
+
+	s.Require().NoError(s.callbacks.Push(context.Background(), &data.CallbackEntity{
+		ID:              "expiring",
+		CarNumber:       "1234",
+		CallbackAddress: "/dev/null",
+		NextAttempt:     time.Now(),
+		Deadline:        time.Now().Add(50 * time.Millisecond),
+	}))
+	// redelivered while the workshop waits for it, then given up once the deadline passed
+	s.Eventually(func() bool { return atomic.LoadInt32(&dials) > 0 }, time.Second, time.Millisecond)
+	s.Eventually(func() bool { return len(s.dueCallbacks()) == 0 }, time.Second, time.Millisecond)
+	calls := atomic.LoadInt32(&dials)
+	time.Sleep(50 * time.Millisecond)
+	s.Equal(calls, atomic.LoadInt32(&dials))
+}
+
+func (s *subWorkshopSuite) TestCallbackAddressMustBeAllowed() {
+	_, err := s.subController.PaintCar(context.Background(), &workshop.SubPaintCarRequest{
+		Car:                    &workshop.Car{Number: "1234"},
+		CallbackServiceAddress: "attacker.example.com:22",
+	})
+	s.True(errors.Is(err, controllers.ErrCallbackNotAllowed))
+	// nothing is dialed, the mocks would fail otherwise
+	s.stopApp()
+}
+
+func (s *subWorkshopSuite) TestCallbackTokenIsSentBack() {
+	fakeConnection := new(fakeGRPCConnection)
+	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), "workshop:5380", gomock.Any()).Return(fakeConnection, nil)
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock)
+
+	_, err := s.subController.PaintCar(context.Background(), &workshop.SubPaintCarRequest{
+		Car:                    &workshop.Car{Number: "1234"},
+		DesiredColor:           "black",
+		CallbackServiceAddress: "workshop:5380",
+		PaintJobId:             "job",
+		CallbackToken:          "signed by the workshop",
+	})
+	s.NoError(err)
+	s.Eventually(func() bool { return fakeConnection.calls() == 1 }, time.Second, time.Millisecond)
+	request := fakeConnection.lastRequest.Load().(*workshop.PaintFinishedRequest)
+	s.Equal("1234", request.GetCarNumber())
+	s.Equal("black", request.GetDesiredColor())
+	s.Equal("job", request.GetPaintJobId())
+	s.Equal("signed by the workshop", request.GetCallbackToken())
+}
+
+func (s *subWorkshopSuite) TestCallbackConnectionIsReused() {
+	fakeConnection := new(fakeGRPCConnection)
+	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), "workshop:5380", gomock.Any()).Return(fakeConnection, nil).Times(1)
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock).Times(1)
+
+	for _, number := range []string{"1234", "5678"} {
+		_, err := s.subController.PaintCar(context.Background(), &workshop.SubPaintCarRequest{
+			Car:                    &workshop.Car{Number: number},
+			CallbackServiceAddress: "workshop:5380",
+		})
+		s.Require().NoError(err)
+	}
+	s.Eventually(func() bool { return fakeConnection.calls() == 2 }, time.Second, time.Millisecond)
+	s.False(fakeConnection.isClosed())
+	s.stopApp()
+	s.True(fakeConnection.isClosed())
+}
+
+func (s *subWorkshopSuite) TestSlowDialDoesNotBlockOtherWorkshops() {
+	s.stopApp()
+	s.startApp(fx.Invoke(func(config cfg.Config) {
+		config.Set(controllers.SubWorkshopWorkersKey, 3)
+		config.Set(controllers.SubWorkshopQueueSizeKey, 3)
+	}))
+	slow, fast := new(fakeGRPCConnection), new(fakeGRPCConnection)
+	release := make(chan struct{})
+	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), "workshop:5380", gomock.Any()).DoAndReturn(
+		func(context.Context, string, ...grpc.DialOption) (grpc.ClientConnInterface, error) {
+			<-release
+			return slow, nil
+		}).Times(1)
+	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), "workshop:5381", gomock.Any()).Return(fast, nil).Times(1)
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock).Times(2)
+
+	for i, address := range []string{"workshop:5380", "workshop:5380", "workshop:5381"} {
+		_, err := s.subController.PaintCar(context.Background(), &workshop.SubPaintCarRequest{
+			Car:                    &workshop.Car{Number: fmt.Sprintf("1234%d", i)},
+			CallbackServiceAddress: address,
+		})
+		s.Require().NoError(err)
+	}
+	s.Eventually(func() bool { return fast.calls() == 1 }, time.Second, time.Millisecond)
+	s.Equal(int32(0), slow.calls())
+	// both callbacks to the slow workshop share a single dial
+	close(release)
+	s.Eventually(func() bool { return slow.calls() == 2 }, time.Second, time.Millisecond)
+}
+
+func (s *subWorkshopSuite) TestIdleCallbackConnectionIsClosed() {
+	s.stopApp()
+	s.startApp(fx.Invoke(func(config cfg.Config) {
+		config.Set(controllers.CallbackConnectionIdleKey, "20ms")
+		config.Set(controllers.CallbackConnectionCheckKey, "5ms")
+	}))
+	first, second := new(fakeGRPCConnection), new(fakeGRPCConnection)
+	gomock.InOrder(
+		s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), "workshop:5380", gomock.Any()).Return(first, nil),
+		s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), "workshop:5380", gomock.Any()).Return(second, nil),
+	)
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock).Times(2)
+
+	_, err := s.subController.PaintCar(context.Background(), &workshop.SubPaintCarRequest{
+		Car:                    &workshop.Car{Number: "1234"},
+		CallbackServiceAddress: "workshop:5380",
+	})
+	s.Require().NoError(err)
+	s.Eventually(first.isClosed, time.Second, time.Millisecond)
+	// the next callback dials again
+	_, err = s.subController.PaintCar(context.Background(), &workshop.SubPaintCarRequest{
+		Car:                    &workshop.Car{Number: "5678"},
+		CallbackServiceAddress: "workshop:5380",
+	})
+	s.Require().NoError(err)
+	s.Eventually(func() bool { return second.calls() == 1 }, time.Second, time.Millisecond)
+	s.Equal(int32(1), first.calls())
 }
 
 func (s *subWorkshopSuite) SetupSuite() {
@@ -68,34 +401,110 @@ func (s *subWorkshopSuite) SetupSuite() {
 }
 
 func (s *subWorkshopSuite) SetupTest() {
+	var err error
+	s.dbDir, err = ioutil.TempDir("", "subworkshop")
+	s.Require().NoError(err)
 	s.ctrl = gomock.NewController(s.T())
 	s.grpcConnBuilderMock = mock_client.NewMockGRPCClientConnectionBuilder(s.ctrl)
 	s.gRPCWrapperMock = mock_client.NewMockGRPCClientConnectionWrapper(s.ctrl)
+	s.startApp()
+}
+
+// startApp applies options before the controller is created
+func (s *subWorkshopSuite) startApp(options ...fx.Option) {
 	s.app = fxtest.New(s.T(),
 		fx.NopLogger, // remove fx debug prints
 		mortar.ViperFxOption(s.pwd+"/../../config/config.yml", s.pwd+"/../../config/config_test.yml"),
 		mortar.LoggerFxOption(),
+		fx.Invoke(func(config cfg.Config) {
+			config.Set(controllers.SubWorkshopWorkersKey, 1)
+			config.Set(controllers.SubWorkshopQueueSizeKey, 2)
+			config.Set(controllers.SubWorkshopPaintDurationKey+".hatchback", "50ms")
+			config.Set(controllers.CallbackRetryKey+".attempts", 3)
+			config.Set(controllers.CallbackRetryKey+".initial", "1ms")
+			config.Set(controllers.CallbackRetryKey+".max", "5ms")
+			config.Set(controllers.CallbackRedeliveryIntervalKey, "10ms")
+			config.Set(controllers.CallbackTimeoutKey, "20ms")
+			config.Set(controllers.CallbackAllowlistKey, []string{"/dev/null", "workshop:*"})
+			config.Set(data.CallbackQueuePathKey, filepath.Join(s.dbDir, "callbacks.db"))
+		}),
+		fx.Options(options...),
 		fx.Provide(func() client.GRPCClientConnectionBuilder {
 			return s.grpcConnBuilderMock
 		}),
+		fx.Provide(security.CreateTLS),
+		fx.Provide(data.CreateCallbackQueue),
 		fx.Provide(controllers.CreateSubWorkshopController),
 		fx.Populate(&s.subController),
+		fx.Populate(&s.callbacks),
 	)
 	s.app.RequireStart()
+	s.stopped = false
 }
 
 func (s *subWorkshopSuite) TearDownTest() {
-	s.app.RequireStop()
+	s.stopApp()
 	s.ctrl.Finish()
+	os.RemoveAll(s.dbDir)
+}
+
+func (s *subWorkshopSuite) stopApp() {
+	if !s.stopped {
+		s.app.RequireStop()
+		s.stopped = true
+	}
+}
+
+// dueCallbacks opens the callbacks file if the application was stopped
+func (s *subWorkshopSuite) dueCallbacks() []*data.CallbackEntity {
+	callbacks := s.callbacks
+	if s.stopped {
+		app := fxtest.New(s.T(),
+			fx.NopLogger,
+			mortar.ViperFxOption(s.pwd+"/../../config/config.yml", s.pwd+"/../../config/config_test.yml"),
+			fx.Invoke(func(config cfg.Config) {
+				config.Set(data.CallbackQueuePathKey, filepath.Join(s.dbDir, "callbacks.db"))
+			}),
+			fx.Provide(data.CreateCallbackQueue),
+			fx.Populate(&callbacks),
+		)
+		app.RequireStart()
+		defer app.RequireStop()
+	}
+	due, err := callbacks.Due(context.Background(), time.Now().Add(time.Hour), 10)
+	s.Require().NoError(err)
+	return due
 }
 
 type fakeGRPCConnection struct {
-	callCounter int
+	callCounter int32
+	err         error
+	hang        bool // until the call deadline
+	closed      int32
+	lastRequest atomic.Value
+}
+
+func (f *fakeGRPCConnection) calls() int32 {
+	return atomic.LoadInt32(&f.callCounter)
 }
 
 func (f *fakeGRPCConnection) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
-	f.callCounter++
-	return nil // everything is great
+	atomic.AddInt32(&f.callCounter, 1)
+	f.lastRequest.Store(args)
+	if _, hasDeadline := ctx.Deadline(); f.hang && hasDeadline {
+		<-ctx.Done()
+		return status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+	}
+	return f.err // nil means everything is great
+}
+
+func (f *fakeGRPCConnection) Close() error {
+	atomic.StoreInt32(&f.closed, 1)
+	return nil
+}
+
+func (f *fakeGRPCConnection) isClosed() bool {
+	return atomic.LoadInt32(&f.closed) == 1
 }
 
 func (f *fakeGRPCConnection) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
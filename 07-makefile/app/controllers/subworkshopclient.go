@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/http/client"
+	"github.com/go-masonry/mortar/mortar"
+	workshop "github.com/go-masonry/tutorial/07-makefile/api"
+	"github.com/go-masonry/tutorial/07-makefile/app/security"
+	"github.com/golang/protobuf/jsonpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// SubWorkshopAddressKey is the host:port of the sub workshop, its REST or gRPC port depending on the transport.
+	// By default it's this application own port, where the sub workshop is served as well.
+	// Ignored when SubWorkshopsKey is set.
+	SubWorkshopAddressKey = "custom.workshop.subworkshop.address"
+	// SubWorkshopTransportKey selects how paint requests are sent, one of SubWorkshopTransportREST (default) or SubWorkshopTransportGRPC
+	SubWorkshopTransportKey = "custom.workshop.subworkshop.transport"
+	// SubWorkshopTimeoutKey limits every call to the sub workshop
+	SubWorkshopTimeoutKey = "custom.workshop.subworkshop.timeout"
+	// CallbackAddressKey is the gRPC address the sub workshop calls back when a car is painted,
+	// it must be reachable from the sub workshop. By default it's this application gRPC port on localhost.
+	CallbackAddressKey = "custom.workshop.callback.address"
+
+	// SubWorkshopTransportREST sends paint requests to the sub workshop gRPC gateway
+	SubWorkshopTransportREST = "rest"
+	// SubWorkshopTransportGRPC sends paint requests with the SubWorkshop gRPC client
+	SubWorkshopTransportGRPC = "grpc"
+
+	defaultSubWorkshopTimeout = 5 * time.Second
+	grpcTimeoutHeader         = "Grpc-Timeout"
+)
+
+// subWorkshopClient sends paint requests to the sub workshop
+type subWorkshopClient interface {
+	PaintCar(ctx context.Context, request *workshop.SubPaintCarRequest) error
+	// Close releases the connection to the sub workshop, calls made after it fail
+	Close() error
+}
+
+// newSubWorkshopClient creates the client of a single sub workshop, an empty transport means REST
+func newSubWorkshopClient(transport, address string, timeout time.Duration, httpClient *http.Client, grpcBuilder client.GRPCClientConnectionBuilder, tls security.TLS) (subWorkshopClient, error) {
+	switch transport {
+	case "", SubWorkshopTransportREST:
+		return &restSubWorkshopClient{
+			client:  httpClient,
+			encoder: &jsonpb.Marshaler{OrigName: true},
+			url:     fmt.Sprintf("%s://%s/v1/subworkshop/paint", tls.Scheme(), address),
+			timeout: timeout,
+		}, nil
+	case SubWorkshopTransportGRPC:
+		return &grpcSubWorkshopClient{
+			builder:    grpcBuilder,
+			address:    address,
+			timeout:    timeout,
+			dialOption: tls.GRPCDialOption(),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown sub workshop transport %s", transport)
+	}
+}
+
+// defaultSubWorkshopAddress is this application own port, where the sub workshop is served as well
+func defaultSubWorkshopAddress(config cfg.Config, transport string) string {
+	if transport == SubWorkshopTransportGRPC {
+		return "localhost:" + config.Get(mortar.ServerGRPCPort).String()
+	}
+	return "localhost:" + config.Get(mortar.ServerRESTExternalPort).String()
+}
+
+// callbackAddress is where the sub workshop reports painted cars
+func callbackAddress(config cfg.Config) string {
+	if value := config.Get(CallbackAddressKey); value.IsSet() {
+		return value.String()
+	}
+	return "localhost:" + config.Get(mortar.ServerGRPCPort).String()
+}
+
+type restSubWorkshopClient struct {
+	client  *http.Client
+	encoder *jsonpb.Marshaler
+	url     string
+	timeout time.Duration
+}
+
+func (r *restSubWorkshopClient) PaintCar(ctx context.Context, request *workshop.SubPaintCarRequest) error {
+	body := new(bytes.Buffer)
+	if err := r.encoder.Marshal(body, request); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	httpReq, err := http.NewRequest(http.MethodPost, r.url, body)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		// the sub workshop gRPC gateway sets it as the deadline of its gRPC call
+		httpReq.Header.Set(grpcTimeoutHeader, encodeGRPCTimeout(time.Until(deadline)))
+	}
+	response, err := r.client.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return status.Errorf(codes.DeadlineExceeded, "sub workshop at %s didn't answer in time, %v", r.url, err)
+		}
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return status.Errorf(codeFromHTTPStatus(response.StatusCode), "painting failed with status %d", response.StatusCode)
+	}
+	return nil
+}
+
+// Close does nothing, the HTTP client is shared by all the sub workshops
+func (r *restSubWorkshopClient) Close() error {
+	return nil
+}
+
+// grpcSubWorkshopClient dials the sub workshop on its first call and reuses the connection until it's closed
+type grpcSubWorkshopClient struct {
+	sync.Mutex
+	builder    client.GRPCClientConnectionBuilder
+	address    string
+	timeout    time.Duration
+	dialOption grpc.DialOption
+	conn       grpc.ClientConnInterface
+	closed     bool
+}
+
+func (g *grpcSubWorkshopClient) PaintCar(ctx context.Context, request *workshop.SubPaintCarRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+	conn, err := g.connection(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = workshop.NewSubWorkshopClient(conn).PaintCar(ctx, request)
+	return err
+}
+
+// connection dials only once, failed dials are tried again by the next call
+func (g *grpcSubWorkshopClient) connection(ctx context.Context) (grpc.ClientConnInterface, error) {
+	g.Lock()
+	defer g.Unlock()
+	if g.closed {
+		return nil, status.Errorf(codes.Unavailable, "connection to sub workshop at %s is closed", g.address)
+	}
+	if g.conn == nil {
+		conn, err := g.builder.Build().Dial(ctx, g.address, g.dialOption)
+		if err != nil {
+			return nil, fmt.Errorf("can't reach sub workshop at %s, %w", g.address, err)
+		}
+		g.conn = conn
+	}
+	return g.conn, nil
+}
+
+func (g *grpcSubWorkshopClient) Close() error {
+	g.Lock()
+	defer g.Unlock()
+	g.closed = true
+	if closer, ok := g.conn.(io.Closer); ok {
+		g.conn = nil
+		return closer.Close()
+	}
+	return nil
+}
+
+// encodeGRPCTimeout formats timeout the way gRPC sends it, in milliseconds and at most 8 digits
+func encodeGRPCTimeout(timeout time.Duration) string {
+	const maxValue = 99999999
+	milliseconds := int64(timeout / time.Millisecond)
+	switch {
+	case milliseconds < 1:
+		// already exhausted, the sub workshop fails fast
+		return "1n"
+	case milliseconds > maxValue:
+		if seconds := int64(timeout / time.Second); seconds <= maxValue {
+			return fmt.Sprintf("%dS", seconds)
+		}
+		return fmt.Sprintf("%dH", maxValue)
+	default:
+		return fmt.Sprintf("%dm", milliseconds)
+	}
+}
+
+// codeFromHTTPStatus tells how the sub workshop REST gateway responded, client errors are never retried
+func codeFromHTTPStatus(statusCode int) codes.Code {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case statusCode == http.StatusRequestTimeout, statusCode == http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case statusCode == http.StatusNotImplemented:
+		return codes.Unimplemented
+	case statusCode >= http.StatusInternalServerError:
+		return codes.Unavailable
+	case statusCode >= http.StatusBadRequest:
+		return codes.FailedPrecondition
+	default:
+		return codes.Unknown
+	}
+}
@@ -1,23 +1,32 @@
 package controllers
 
 import (
-	"bytes"
 	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
-	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-masonry/mortar/interfaces/cfg"
 	"github.com/go-masonry/mortar/interfaces/http/client"
 	"github.com/go-masonry/mortar/interfaces/log"
 	workshop "github.com/go-masonry/tutorial/07-makefile/api"
 	"github.com/go-masonry/tutorial/07-makefile/app/data"
-	"github.com/golang/protobuf/jsonpb"
+	"github.com/go-masonry/tutorial/07-makefile/app/security"
 	"github.com/golang/protobuf/ptypes/empty"
 	"go.uber.org/fx"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
-	grpcServerPort   = "5380"
-	externalRestPort = "5381"
+	defaultPageSize = 20
+	// MaxPageSize is the largest page List methods return, bigger page sizes are reduced to it
+	MaxPageSize = 100
+
+	// carWriteAttempts is how many times a car changed by someone else is read and written again
+	carWriteAttempts = 5
 )
 
 // WorkshopController responsible for the business logic of our Workshop
@@ -28,87 +37,431 @@ type WorkshopController interface {
 type workshopControllerDeps struct {
 	fx.In
 
+	Config            cfg.Config
 	DB                data.CarDB
+	Idempotency       data.IdempotencyDB
+	Events            CarEvents
 	Logger            log.Logger
+	Lifecycle         fx.Lifecycle
 	HTTPClientBuilder client.NewHTTPClientBuilder
+	GRPCClientBuilder client.GRPCClientConnectionBuilder
+	TLS               security.TLS
 }
 
 type workshopController struct {
-	deps    workshopControllerDeps
-	client  *http.Client
-	encoder *jsonpb.Marshaler
+	deps            workshopControllerDeps
+	subWorkshops    *subWorkshopRouter
+	callbackAddress string
+	callbackTokens  *callbackTokens
+	idempotency     *idempotency
+	dispatcher      outboxDispatcher
 }
 
 // CreateWorkshopController is a constructor for Fx
-func CreateWorkshopController(deps workshopControllerDeps) WorkshopController {
-	client := deps.HTTPClientBuilder().Build()
-	encoder := &jsonpb.Marshaler{OrigName: true}
-	return &workshopController{
-		deps:    deps,
-		client:  client,
-		encoder: encoder,
+func CreateWorkshopController(deps workshopControllerDeps) (WorkshopController, error) {
+	timeout := defaultSubWorkshopTimeout
+	if value := deps.Config.Get(SubWorkshopTimeoutKey); value.IsSet() {
+		timeout = value.Duration()
+	}
+	httpClientBuilder := deps.HTTPClientBuilder()
+	if deps.TLS.Enabled() {
+		httpClientBuilder = httpClientBuilder.WithPreconfiguredClient(deps.TLS.HTTPClient())
+	}
+	httpClient := httpClientBuilder.Build()
+	subWorkshops, err := newSubWorkshopRouter(deps.Config, deps.Logger, func(transport, address string) (subWorkshopClient, error) {
+		return newSubWorkshopClient(transport, address, timeout, httpClient, deps.GRPCClientBuilder, deps.TLS)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// appended before the dispatcher hook so it runs after the dispatcher stopped
+	deps.Lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			subWorkshops.close(ctx)
+			return nil
+		},
+	})
+	tokens, err := newCallbackTokens(deps.Config)
+	if err != nil {
+		return nil, err
 	}
+	w := &workshopController{
+		deps:            deps,
+		subWorkshops:    subWorkshops,
+		callbackAddress: callbackAddress(deps.Config),
+		callbackTokens:  tokens,
+		idempotency:     newIdempotency(deps.Config, deps.Logger, deps.Idempotency),
+	}
+	w.startDispatcher(deps.Lifecycle)
+	return w, nil
 }
 
+// AcceptCar is idempotent when an idempotency key is sent, see IdempotencyKeyHeader
 func (w *workshopController) AcceptCar(ctx context.Context, car *workshop.Car) (*empty.Empty, error) {
-	err := w.deps.DB.InsertCar(ctx, FromProtoCarToModelCar(car))
+	response, err := w.idempotency.do(ctx, "AcceptCar", car, new(empty.Empty), func() (proto.Message, error) {
+		return w.acceptCar(ctx, car)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.(*empty.Empty), nil
+}
+
+func (w *workshopController) acceptCar(ctx context.Context, car *workshop.Car) (*empty.Empty, error) {
+	entity := FromProtoCarToModelCar(car)
+	entity.State = data.CarAccepted
+	err := w.deps.DB.InsertCar(changedBy(ctx, ""), entity)
 	w.deps.Logger.WithError(err).Debug(ctx, "car accepted")
+	if err == nil {
+		w.deps.Events.Publish(ctx, workshop.CarEvent_ACCEPTED, entity)
+	}
 	return &empty.Empty{}, err
 }
 
-func (w *workshopController) PaintCar(ctx context.Context, request *workshop.PaintCarRequest) (*empty.Empty, error) {
-	car, err := w.deps.DB.GetCar(ctx, request.GetCarNumber())
+// PaintCar is idempotent when an idempotency key is sent, see IdempotencyKeyHeader
+func (w *workshopController) PaintCar(ctx context.Context, request *workshop.PaintCarRequest) (*workshop.PaintJob, error) {
+	response, err := w.idempotency.do(ctx, "PaintCar", request, new(workshop.PaintJob), func() (proto.Message, error) {
+		return w.paintCar(ctx, request)
+	})
 	if err != nil {
 		return nil, err
 	}
-	httpReq, err := w.makePaintRestRequest(ctx, car, request)
+	return response.(*workshop.PaintJob), nil
+}
+
+func (w *workshopController) paintCar(ctx context.Context, request *workshop.PaintCarRequest) (*workshop.PaintJob, error) {
+	expected, err := ifMatch(ctx)
 	if err != nil {
 		return nil, err
 	}
-	response, err := w.client.Do(httpReq)
+	car, err := w.deps.DB.GetCar(ctx, request.GetCarNumber())
 	if err != nil {
-		w.deps.Logger.WithError(err).Debug(ctx, "calling sub workshop failed")
 		return nil, err
 	}
-	defer response.Body.Close()
-	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("painting failed with status %d", response.StatusCode)
+	if err = car.At(expected); err != nil {
+		return nil, err
+	}
+	if !data.CanTransitionCar(car.State, data.CarPaintRequested) {
+		return nil, fmt.Errorf("%w: %s is %s", ErrCarNotPaintable, car.CarNumber, car.State)
 	}
-	return &empty.Empty{}, nil
+	now := time.Now().UTC()
+	job := &data.PaintJobEntity{
+		ID:           newID(now),
+		CarNumber:    car.CarNumber,
+		DesiredColor: request.GetDesiredColor(),
+		Status:       data.PaintJobQueued,
+		CreateTime:   now,
+		UpdateTime:   now,
+	}
+	// the sub workshop is called by the dispatcher, within the deadline of this request
+	deadline, _ := ctx.Deadline()
+	// the store makes sure only one of concurrent requests gets here
+	car, err = w.deps.DB.RequestPaint(changedBy(ctx, ""), car.CarNumber, car.Version, job, &data.OutboxEntity{
+		ID:           job.ID,
+		CarNumber:    car.CarNumber,
+		DesiredColor: job.DesiredColor,
+		PaintJobID:   job.ID,
+		NextAttempt:  now,
+		Deadline:     deadline,
+	})
+	if err != nil {
+		return nil, err
+	}
+	setETag(ctx, car)
+	w.deps.Events.Publish(ctx, workshop.CarEvent_PAINT_REQUESTED, car)
+	w.dispatchNow()
+	return FromModelPaintJobToProtoPaintJob(job), nil
+}
+
+// sendToSubWorkshop returns the name of the sub workshop the car was sent to
+func (w *workshopController) sendToSubWorkshop(ctx context.Context, car *data.CarEntity, message *data.OutboxEntity) (string, error) {
+	subWorkshop, err := w.subWorkshops.PaintCar(ctx, &workshop.SubPaintCarRequest{
+		Car:                    FromModelCarToProtoCar(car),
+		DesiredColor:           message.DesiredColor,
+		CallbackServiceAddress: w.callbackAddress,
+		PaintJobId:             message.PaintJobID,
+		CallbackToken:          w.callbackTokens.issue(message.PaintJobID, car.CarNumber, message.DesiredColor, time.Now()),
+	})
+	w.deps.Logger.WithError(err).Debug(ctx, "sent car %s to sub workshop %s", car.CarNumber, subWorkshop)
+	return subWorkshop, err
+}
+
+func (w *workshopController) RegisterSubWorkshop(ctx context.Context, request *workshop.SubWorkshopRegistration) (*empty.Empty, error) {
+	var bodyStyles []string
+	for _, bodyStyle := range request.GetBodyStyles() {
+		bodyStyles = append(bodyStyles, bodyStyle.String())
+	}
+	transport := strings.ToLower(request.GetTransportType().String())
+	err := w.subWorkshops.register(request.GetName(), transport, request.GetAddress(), request.GetColors(), bodyStyles)
+	w.deps.Logger.WithError(err).Info(ctx, "sub workshop %s registered at %s over %s", request.GetName(), request.GetAddress(), transport)
+	return &empty.Empty{}, err
 }
 
 func (w *workshopController) RetrieveCar(ctx context.Context, request *workshop.RetrieveCarRequest) (*workshop.Car, error) {
+	expected, err := ifMatch(ctx)
+	if err != nil {
+		return nil, err
+	}
 	car, err := w.deps.DB.GetCar(ctx, request.GetCarNumber())
 	if err != nil {
 		return nil, err
 	}
-	if car.Painted {
-		car, err = w.deps.DB.RemoveCar(ctx, request.GetCarNumber())
+	if err = car.At(expected); err != nil {
+		return nil, err
+	}
+	if car.State != data.CarPainted {
+		return nil, fmt.Errorf("%w: %s", ErrCarNotPainted, request.GetCarNumber())
+	}
+	// the store makes sure a car that changed concurrently, e.g. sent to be painted again, isn't retrieved
+	if car, err = w.deps.DB.RetrieveCar(changedBy(ctx, ""), car.CarNumber, car.Version); err != nil {
+		return nil, err
+	}
+	setETag(ctx, car)
+	w.deps.Events.Publish(ctx, workshop.CarEvent_RETRIEVED, car)
+	return FromModelCarToProtoCar(car), nil
+}
+
+// UpdateCar changes the owner and body style of a car, the fields set in the request if there is no update mask.
+// The car must still have the etag of the request, or the IfMatchHeader when the request has none.
+func (w *workshopController) UpdateCar(ctx context.Context, request *workshop.UpdateCarRequest) (*workshop.Car, error) {
+	changes := request.GetCar()
+	version := FromProtoEtagToModelVersion(changes.GetEtag())
+	if version == 0 {
+		var err error
+		if version, err = ifMatch(ctx); err != nil {
+			return nil, err
+		}
+	}
+	paths := request.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		if len(changes.GetOwner()) > 0 {
+			paths = append(paths, "owner")
+		}
+		if changes.GetBodyStyle() != workshop.Car_SEDAN {
+			paths = append(paths, "body_style")
+		}
+	}
+	car, err := w.deps.DB.UpdateCar(ctx, changes.GetNumber(), version, func(car *data.CarEntity) error {
+		for _, path := range paths {
+			switch path {
+			case "owner":
+				car.Owner = changes.GetOwner()
+			case "body_style":
+				car.BodyStyle = workshop.CarBody_name[int32(changes.GetBodyStyle())]
+			}
+		}
+		return nil
+	})
+	w.deps.Logger.WithError(err).Debug(ctx, "car updated")
+	if err != nil {
+		return nil, err
+	}
+	setETag(ctx, car)
+	return FromModelCarToProtoCar(car), nil
+}
+
+func (w *workshopController) GetCarHistory(ctx context.Context, request *workshop.GetCarHistoryRequest) (*workshop.GetCarHistoryResponse, error) {
+	afterSequence := int64(0)
+	if len(request.GetPageToken()) > 0 {
+		lastKey, err := decodePageToken(request.GetPageToken())
 		if err != nil {
 			return nil, err
 		}
-		return FromModelCarToProtoCar(car), nil
+		if afterSequence, err = strconv.ParseInt(lastKey, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPageToken, request.GetPageToken())
+		}
 	}
-	return nil, fmt.Errorf("car %s is not painted", request.GetCarNumber())
+	pageSize := pageSize(request.GetPageSize())
+	// ask for one more change to know if there is a next page
+	history, err := w.deps.DB.CarHistory(ctx, request.GetCarNumber(), afterSequence, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 && afterSequence == 0 {
+		return nil, fmt.Errorf("%w: %s", data.ErrCarNotFound, request.GetCarNumber())
+	}
+	response := new(workshop.GetCarHistoryResponse)
+	if len(history) > pageSize {
+		history = history[:pageSize]
+		response.NextPageToken = encodePageToken(strconv.FormatInt(history[pageSize-1].Sequence, 10))
+	}
+	for _, change := range history {
+		response.Entries = append(response.Entries, FromModelCarHistoryToProtoCarHistoryEntry(change))
+	}
+	return response, nil
 }
 
-func (w *workshopController) CarPainted(ctx context.Context, request *workshop.PaintFinishedRequest) (*empty.Empty, error) {
-	err := w.deps.DB.PaintCar(ctx, request.GetCarNumber(), request.GetDesiredColor())
-	return &empty.Empty{}, err
+func (w *workshopController) ListCars(ctx context.Context, request *workshop.ListCarsRequest) (*workshop.ListCarsResponse, error) {
+	afterCarNumber, err := decodePageToken(request.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+	pageSize := pageSize(request.GetPageSize())
+	// ask for one more car to know if there is a next page
+	cars, err := w.deps.DB.ListCars(ctx, FromProtoListCarsRequestToCarFilter(request), afterCarNumber, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	response := new(workshop.ListCarsResponse)
+	if len(cars) > pageSize {
+		cars = cars[:pageSize]
+		response.NextPageToken = encodePageToken(cars[pageSize-1].CarNumber)
+	}
+	for _, car := range cars {
+		response.Cars = append(response.Cars, FromModelCarToProtoCar(car))
+	}
+	return response, nil
 }
 
-func (w *workshopController) makePaintRestRequest(ctx context.Context, car *data.CarEntity, request *workshop.PaintCarRequest) (httpReq *http.Request, err error) {
-	pbReq := &workshop.SubPaintCarRequest{
-		Car:                    FromModelCarToProtoCar(car),
-		DesiredColor:           request.GetDesiredColor(),
-		CallbackServiceAddress: fmt.Sprintf(":%s", grpcServerPort),
+func (w *workshopController) GetPaintJob(ctx context.Context, request *workshop.GetPaintJobRequest) (*workshop.PaintJob, error) {
+	job, err := w.deps.DB.GetPaintJob(ctx, request.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return FromModelPaintJobToProtoPaintJob(job), nil
+}
+
+func (w *workshopController) ListPaintJobs(ctx context.Context, request *workshop.ListPaintJobsRequest) (*workshop.ListPaintJobsResponse, error) {
+	afterID, err := decodePageToken(request.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+	pageSize := pageSize(request.GetPageSize())
+	// ask for one more job to know if there is a next page
+	jobs, err := w.deps.DB.ListPaintJobs(ctx, request.GetCarNumber(), afterID, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	response := new(workshop.ListPaintJobsResponse)
+	if len(jobs) > pageSize {
+		jobs = jobs[:pageSize]
+		response.NextPageToken = encodePageToken(jobs[pageSize-1].ID)
+	}
+	for _, job := range jobs {
+		response.Jobs = append(response.Jobs, FromModelPaintJobToProtoPaintJob(job))
+	}
+	return response, nil
+}
+
+// CancelPaintJob only marks the job as cancelled, if the sub workshop calls back later the car is not painted
+func (w *workshopController) CancelPaintJob(ctx context.Context, request *workshop.CancelPaintJobRequest) (*workshop.PaintJob, error) {
+	job, err := w.finishPaintJob(ctx, request.GetId(), data.PaintJobCancelled, nil)
+	if err != nil {
+		return nil, err
+	}
+	w.undoPaintRequest(changedBy(ctx, job.SubWorkshop), job.CarNumber)
+	return FromModelPaintJobToProtoPaintJob(job), nil
+}
+
+// CarPainted only accepts the callback token issued when the car was sent to be painted, and only once
+func (w *workshopController) CarPainted(ctx context.Context, request *workshop.PaintFinishedRequest) (*empty.Empty, error) {
+	token := request.GetCallbackToken()
+	err := w.callbackTokens.verify(token, request.GetPaintJobId(), request.GetCarNumber(), request.GetDesiredColor(), time.Now())
+	if err == nil {
+		err = w.callbackTokens.use(token)
+	}
+	if err != nil {
+		w.deps.Logger.WithError(err).Warn(ctx, "rejected callback of car %s", request.GetCarNumber())
+		return nil, err
 	}
-	body := new(bytes.Buffer)
-	if err = w.encoder.Marshal(body, pbReq); err != nil {
+	if err = w.carPainted(ctx, request); err != nil {
+		// the sub workshop may try again
+		w.callbackTokens.forget(token)
 		return nil, err
 	}
-	if httpReq, err = http.NewRequest(http.MethodPost, fmt.Sprintf("http://localhost:%s/v1/subworkshop/paint", externalRestPort), body); err == nil {
-		httpReq = httpReq.WithContext(ctx)
+	return &empty.Empty{}, nil
+}
+
+func (w *workshopController) carPainted(ctx context.Context, request *workshop.PaintFinishedRequest) error {
+	// callbacks without a job id come from sub workshops that don't know about paint jobs
+	jobID := request.GetPaintJobId()
+	subWorkshop := ""
+	if len(jobID) > 0 {
+		job, err := w.deps.DB.GetPaintJob(ctx, jobID)
+		if err != nil {
+			return err
+		}
+		if job.Done() {
+			return fmt.Errorf("%w: %s is %s", ErrPaintJobDone, jobID, job.Status)
+		}
+		subWorkshop = job.SubWorkshop
+	}
+	// the car may move from PAINT_REQUESTED to PAINTING meanwhile, it's read and checked again then
+	car, err := w.writeCar(ctx, request.GetCarNumber(), func(car *data.CarEntity) (*data.CarEntity, error) {
+		if !data.CanTransitionCar(car.State, data.CarPainted) {
+			return nil, fmt.Errorf("%w: %s is %s", ErrCarNotBeingPainted, car.CarNumber, car.State)
+		}
+		return w.deps.DB.PaintCar(changedBy(ctx, subWorkshop), car.CarNumber, car.Version, request.GetDesiredColor())
+	})
+	if err != nil {
+		// the job keeps running, the sub workshop may try again
+		return err
+	}
+	if len(jobID) > 0 {
+		if _, err = w.finishPaintJob(ctx, jobID, data.PaintJobSucceeded, nil); err != nil {
+			// the car is painted either way, calling back again can't help
+			w.deps.Logger.WithError(err).Warn(ctx, "car %s is painted but its paint job %s wasn't finished", car.CarNumber, jobID)
+		}
+	}
+	w.deps.Events.Publish(ctx, workshop.CarEvent_PAINTED, car)
+	return nil
+}
+
+func (w *workshopController) WatchCars(request *workshop.WatchCarsRequest, stream workshop.Workshop_WatchCarsServer) error {
+	return w.deps.Events.Watch(stream.Context(), request.GetCarNumber(), request.GetAfterSequence(), stream.Send)
+}
+
+// undoPaintRequest moves the car back to where it was before it was sent to be painted
+func (w *workshopController) undoPaintRequest(ctx context.Context, carNumber string) {
+	car, err := w.writeCar(ctx, carNumber, func(car *data.CarEntity) (*data.CarEntity, error) {
+		previous := data.CarAccepted
+		if car.Painted {
+			previous = data.CarPainted
+		}
+		return w.deps.DB.SetCarState(ctx, car.CarNumber, car.Version, previous)
+	})
+	if err != nil {
+		w.deps.Logger.WithError(err).Debug(ctx, "car %s wasn't moved back", carNumber)
+		return
+	}
+	w.deps.Logger.Debug(ctx, "car %s moved back to %s", carNumber, car.State)
+}
+
+// writeCar reads the car and lets write store it at the version it was read at,
+// when the car changed meanwhile it's read and written again
+func (w *workshopController) writeCar(ctx context.Context, carNumber string, write func(car *data.CarEntity) (*data.CarEntity, error)) (*data.CarEntity, error) {
+	for attempt := 1; ; attempt++ {
+		car, err := w.deps.DB.GetCar(ctx, carNumber)
+		if err != nil {
+			return nil, err
+		}
+		if car, err = write(car); !errors.Is(err, data.ErrCarChanged) || attempt == carWriteAttempts {
+			return car, err
+		}
 	}
-	return
+}
+
+// finishPaintJob moves a job that is not done yet to a final status
+func (w *workshopController) finishPaintJob(ctx context.Context, id string, status string, cause error) (*data.PaintJobEntity, error) {
+	job, err := w.deps.DB.UpdatePaintJob(ctx, id, func(job *data.PaintJobEntity) error {
+		if job.Done() {
+			return fmt.Errorf("%w: %s is %s", ErrPaintJobDone, id, job.Status)
+		}
+		job.Status = status
+		if cause != nil {
+			job.Error = cause.Error()
+		}
+		job.UpdateTime = time.Now().UTC()
+		job.EndTime = job.UpdateTime
+		return nil
+	})
+	w.deps.Logger.WithError(err).Debug(ctx, "paint job %s %s", id, status)
+	return job, err
+}
+
+// newID returns ids that sort in creation order
+func newID(now time.Time) string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%016x%x", now.UnixNano(), suffix)
 }
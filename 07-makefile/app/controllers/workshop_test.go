@@ -1,23 +1,39 @@
 package controllers_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/go-masonry/mortar/http/client"
+	"github.com/go-masonry/mortar/interfaces/cfg"
 	clientInt "github.com/go-masonry/mortar/interfaces/http/client"
+	mock_client "github.com/go-masonry/mortar/interfaces/http/client/mock"
 	workshop "github.com/go-masonry/tutorial/07-makefile/api"
 	"github.com/go-masonry/tutorial/07-makefile/app/controllers"
 	"github.com/go-masonry/tutorial/07-makefile/app/data"
 	"github.com/go-masonry/tutorial/07-makefile/app/mortar"
+	"github.com/go-masonry/tutorial/07-makefile/app/security"
+	"github.com/go-masonry/tutorial/07-makefile/app/security/securitytest"
 	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
+	"google.golang.org/genproto/protobuf/field_mask"
+	"google.golang.org/grpc/metadata"
 )
 
 type workshopSuite struct {
@@ -26,7 +42,28 @@ type workshopSuite struct {
 	ctrl       *gomock.Controller
 	app        *fxtest.App
 	carDB      data.CarDB
+	events     controllers.CarEvents
 	controller controllers.WorkshopController
+
+	grpcConnBuilderMock *mock_client.MockGRPCClientConnectionBuilder
+	gRPCWrapperMock     *mock_client.MockGRPCClientConnectionWrapper
+	// last request the fake sub workshop got over REST
+	subWorkshopRequest atomic.Value
+	// paint requests the fake sub workshop got over REST by paint job id
+	paintRequests sync.Map
+	// status code returned by the fake sub workshop, after it failed subWorkshopFailures times with 503
+	subWorkshopStatusCode int32
+	subWorkshopFailures   int32
+	subWorkshopCalls      int32
+	// the fake sub workshop doesn't answer until the request is cancelled when set
+	subWorkshopHangs int32
+	// hosts the fake sub workshop got requests for, the failing ones always return 503
+	subWorkshopHostsLock sync.Mutex
+	subWorkshopHosts     []string
+	failingHosts         map[string]bool
+	// wrap the stores of the next application when set
+	wrapCarDB         func(data.CarDB) data.CarDB
+	wrapIdempotencyDB func(data.IdempotencyDB) data.IdempotencyDB
 }
 
 func TestWorkshop(t *testing.T) {
@@ -58,6 +95,7 @@ func (s *workshopSuite) TestAcceptCar() {
 //
 // You are encouraged to replace this special client with a default one, look at the commented line below
 // If you do you should see an error similar to this one
+//
 //	Post "http://localhost:5381/v1/subworkshop/paint": dial tcp [::1]:5381: connect: connection refused
 func (s *workshopSuite) TestPaintCar() {
 	_, err := s.controller.AcceptCar(context.Background(), &workshop.Car{
@@ -71,17 +109,433 @@ func (s *workshopSuite) TestPaintCar() {
 	_, err = s.controller.PaintCar(context.Background(), &workshop.PaintCarRequest{CarNumber: "fake car number"})
 	s.Error(err)
 	// now paint
-	_, err = s.controller.PaintCar(context.Background(), &workshop.PaintCarRequest{
+	job, err := s.controller.PaintCar(context.Background(), &workshop.PaintCarRequest{
 		CarNumber:    "1234567",
 		DesiredColor: "orange",
 	})
 	s.NoError(err)
+	s.NotEmpty(job.GetId())
+	s.Equal(workshop.PaintJob_QUEUED, job.GetJobStatus())
+	s.Equal("orange", job.GetDesiredColor())
+	s.NotNil(job.GetCreateTime())
+	s.Nil(job.GetEndTime())
+	// sent to the sub workshop in the background
+	s.Eventually(func() bool { return s.jobStatus(job.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	car, err := s.carDB.GetCar(context.Background(), "1234567")
+	s.NoError(err)
+	s.Equal(data.CarPainting, car.State)
+	s.Equal(int32(1), atomic.LoadInt32(&s.subWorkshopCalls))
+}
+
+func (s *workshopSuite) TestPaintRequestIsRetried() {
+	_, err := s.controller.AcceptCar(context.Background(), &workshop.Car{Number: "retry001", Color: "white"})
+	s.Require().NoError(err)
+	atomic.StoreInt32(&s.subWorkshopFailures, 2)
+	job, err := s.controller.PaintCar(context.Background(), &workshop.PaintCarRequest{CarNumber: "retry001", DesiredColor: "red"})
+	s.Require().NoError(err)
+	s.Eventually(func() bool { return s.jobStatus(job.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	s.Equal(int32(3), atomic.LoadInt32(&s.subWorkshopCalls))
+}
+
+func (s *workshopSuite) TestPaintRequestHasCallerDeadline() {
+	_, err := s.controller.AcceptCar(context.Background(), &workshop.Car{Number: "deadline001", Color: "white"})
+	s.Require().NoError(err)
+	atomic.StoreInt32(&s.subWorkshopHangs, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	callerDeadline, _ := ctx.Deadline()
+	job, err := s.controller.PaintCar(ctx, &workshop.PaintCarRequest{CarNumber: "deadline001", DesiredColor: "red"})
+	s.Require().NoError(err)
+	// the sub workshop is cut off once the caller stopped waiting and the paint request isn't retried
+	s.Eventually(func() bool { return s.jobStatus(job.GetId()) == workshop.PaintJob_FAILED }, time.Second, time.Millisecond)
+	s.Equal(int32(1), atomic.LoadInt32(&s.subWorkshopCalls))
+	deadline, hasDeadline := s.subWorkshopRequest.Load().(*http.Request).Context().Deadline()
+	s.True(hasDeadline)
+	s.False(deadline.After(callerDeadline))
+	car, err := s.carDB.GetCar(context.Background(), "deadline001")
+	s.Require().NoError(err)
+	s.Equal(data.CarAccepted, car.State)
+}
+
+func (s *workshopSuite) TestPaintJobFailsWithoutCallback() {
+	_, err := s.controller.AcceptCar(context.Background(), &workshop.Car{Number: "deadline002", Color: "white"})
+	s.Require().NoError(err)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	job, err := s.controller.PaintCar(ctx, &workshop.PaintCarRequest{CarNumber: "deadline002", DesiredColor: "red"})
+	s.Require().NoError(err)
+	callback := s.callback(job)
+	s.Eventually(func() bool { return s.jobStatus(job.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	// the sub workshop never calls back, the job fails once the caller stopped waiting
+	s.Eventually(func() bool { return s.jobStatus(job.GetId()) == workshop.PaintJob_FAILED }, time.Second, time.Millisecond)
+	car, err := s.carDB.GetCar(context.Background(), "deadline002")
+	s.Require().NoError(err)
+	s.Equal(data.CarAccepted, car.State)
+	pending, err := s.carDB.PendingOutbox(context.Background(), time.Now().Add(time.Hour), 10)
+	s.Require().NoError(err)
+	s.Empty(pending)
+	_, err = s.controller.CarPainted(context.Background(), callback)
+	s.True(errors.Is(err, controllers.ErrPaintJobDone))
+	s.Equal(int32(1), atomic.LoadInt32(&s.subWorkshopCalls))
+}
+
+func (s *workshopSuite) TestPaintCarUsesConfiguredEndpoint() {
+	s.restartApp(fx.Invoke(func(config cfg.Config) {
+		config.Set(controllers.SubWorkshopAddressKey, "paint-shop:8081")
+		config.Set(controllers.CallbackAddressKey, "workshop.example.com:5380")
+	}))
+	_, err := s.controller.AcceptCar(context.Background(), &workshop.Car{Number: "endpoint", Color: "white"})
+	s.Require().NoError(err)
+	job, err := s.controller.PaintCar(context.Background(), &workshop.PaintCarRequest{CarNumber: "endpoint", DesiredColor: "red"})
+	s.Require().NoError(err)
+	s.Eventually(func() bool { return s.jobStatus(job.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	request := s.subWorkshopRequest.Load().(*http.Request)
+	s.Equal("http://paint-shop:8081/v1/subworkshop/paint", request.URL.String())
+	_, hasDeadline := request.Context().Deadline()
+	s.True(hasDeadline)
+	// the sub workshop gateway turns it into the deadline of its own call
+	s.Regexp(`^\d+m$`, request.Header.Get("Grpc-Timeout"))
+	paintRequest := new(workshop.SubPaintCarRequest)
+	s.Require().NoError(jsonpb.Unmarshal(request.Body, paintRequest))
+	s.Equal("workshop.example.com:5380", paintRequest.GetCallbackServiceAddress())
+	s.Equal(job.GetId(), paintRequest.GetPaintJobId())
+}
+
+func (s *workshopSuite) TestPaintCarOverRESTWithTLS() {
+	dir, err := ioutil.TempDir("", "tls")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+	ca, err := securitytest.NewCA("tutorial")
+	s.Require().NoError(err)
+	files, err := ca.WriteFiles(dir, "workshop", "localhost")
+	s.Require().NoError(err)
+	s.restartApp(fx.Invoke(func(config cfg.Config) {
+		config.Set(security.TLSCertificateKey, files.Certificate)
+		config.Set(security.TLSKeyKey, files.Key)
+		config.Set(security.TLSCAKey, files.CA)
+	}))
+	job := s.paintCar("tls00001", workshop.Car_SEDAN, "red")
+	s.Eventually(func() bool { return s.jobStatus(job.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	// Mortar serves REST in plain text
+	request := s.subWorkshopRequest.Load().(*http.Request)
+	s.Equal("http", request.URL.Scheme)
+}
+
+func (s *workshopSuite) TestPaintCarOverGRPC() {
+	s.restartApp(fx.Invoke(func(config cfg.Config) {
+		config.Set(controllers.SubWorkshopTransportKey, controllers.SubWorkshopTransportGRPC)
+		config.Set(controllers.SubWorkshopAddressKey, "paint-shop:8080")
+	}))
+	fakeConnection := new(fakeGRPCConnection)
+	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), "paint-shop:8080", gomock.Any()).Return(fakeConnection, nil)
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock)
+
+	_, err := s.controller.AcceptCar(context.Background(), &workshop.Car{Number: "grpc0001", Color: "white"})
+	s.Require().NoError(err)
+	job, err := s.controller.PaintCar(context.Background(), &workshop.PaintCarRequest{CarNumber: "grpc0001", DesiredColor: "red"})
+	s.Require().NoError(err)
+	s.Eventually(func() bool { return s.jobStatus(job.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	s.Equal(int32(1), fakeConnection.calls())
+	s.Equal(int32(0), atomic.LoadInt32(&s.subWorkshopCalls))
+	// dialed only once
+	again := s.paintCar("grpc0002", workshop.Car_SEDAN, "blue")
+	s.Eventually(func() bool { return s.jobStatus(again.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	s.Equal(int32(2), fakeConnection.calls())
+	s.False(fakeConnection.isClosed())
+	s.app.RequireStop()
+	s.True(fakeConnection.isClosed())
+	s.app = fxtest.New(s.T(), s.fxOptions())
+	s.app.RequireStart()
+}
+
+func (s *workshopSuite) TestReplacedGRPCConnectionIsClosed() {
+	s.restartApp(fx.Invoke(func(config cfg.Config) {
+		config.Set(controllers.SubWorkshopTransportKey, controllers.SubWorkshopTransportGRPC)
+		config.Set(controllers.SubWorkshopAddressKey, "paint-shop:8080")
+	}))
+	first, second := new(fakeGRPCConnection), new(fakeGRPCConnection)
+	gomock.InOrder(
+		s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), "paint-shop:8080", gomock.Any()).Return(first, nil),
+		s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), "paint-shop:9090", gomock.Any()).Return(second, nil),
+	)
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock).Times(2)
+	job := s.paintCar("replace1", workshop.Car_SEDAN, "red")
+	s.Eventually(func() bool { return s.jobStatus(job.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	registration := &workshop.SubWorkshopRegistration{
+		Name:          "default",
+		Address:       "paint-shop:8080",
+		TransportType: workshop.SubWorkshopRegistration_GRPC,
+	}
+	// the same address keeps its connection
+	_, err := s.controller.RegisterSubWorkshop(context.Background(), registration)
+	s.Require().NoError(err)
+	s.False(first.isClosed())
+	registration.Address = "paint-shop:9090"
+	_, err = s.controller.RegisterSubWorkshop(context.Background(), registration)
+	s.Require().NoError(err)
+	s.True(first.isClosed())
+	job = s.paintCar("replace2", workshop.Car_SEDAN, "red")
+	s.Eventually(func() bool { return s.jobStatus(job.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	s.Equal(int32(1), first.calls())
+	s.Equal(int32(1), second.calls())
+}
+
+func (s *workshopSuite) TestUnknownTransport() {
+	app := fx.New(s.fxOptions(fx.Invoke(func(config cfg.Config) {
+		config.Set(controllers.SubWorkshopTransportKey, "pigeon")
+	})))
+	s.Require().Error(app.Err())
+	s.Contains(app.Err().Error(), "unknown sub workshop transport pigeon")
+}
+
+func (s *workshopSuite) TestPaintCarIsRoutedToCapableSubWorkshop() {
+	s.restartApp(fx.Invoke(func(config cfg.Config) {
+		config.Set(controllers.SubWorkshopsKey, map[string]interface{}{
+			"reds":       map[string]interface{}{"address": "reds:8081", "colors": []string{"Red"}},
+			"hatchbacks": map[string]interface{}{"address": "hatchbacks:8081", "body_styles": []string{"hatchback"}},
+		})
+	}))
+	red := s.paintCar("route001", workshop.Car_SEDAN, "red")
+	s.Eventually(func() bool { return s.jobStatus(red.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	blue := s.paintCar("route002", workshop.Car_HATCHBACK, "blue")
+	s.Eventually(func() bool { return s.jobStatus(blue.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	s.Equal([]string{"reds:8081", "hatchbacks:8081"}, s.hosts())
+	// no one paints blue sedans, it's not retried
+	none := s.paintCar("route003", workshop.Car_SEDAN, "blue")
+	s.Eventually(func() bool { return s.jobStatus(none.GetId()) == workshop.PaintJob_FAILED }, time.Second, time.Millisecond)
+	s.Len(s.hosts(), 2)
+}
+
+func (s *workshopSuite) TestRoutingStrategies() {
+	for _, strategy := range []string{controllers.RoutingRoundRobin, controllers.RoutingLeastLoaded} {
+		s.Run(strategy, func() {
+			s.restartApp(fx.Invoke(func(config cfg.Config) {
+				config.Set(controllers.SubWorkshopRoutingKey, strategy)
+				config.Set(controllers.SubWorkshopsKey, map[string]interface{}{
+					"a": map[string]interface{}{"address": "a:8081"},
+					"b": map[string]interface{}{"address": "b:8081"},
+				})
+			}))
+			s.subWorkshopHostsLock.Lock()
+			s.subWorkshopHosts = nil
+			s.subWorkshopHostsLock.Unlock()
+			for i := 0; i < 4; i++ {
+				job := s.paintCar(fmt.Sprintf("%.6s%02d", strategy, i), workshop.Car_SEDAN, "red")
+				s.Eventually(func() bool { return s.jobStatus(job.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+			}
+			// cars are sent one at a time, both strategies take turns
+			s.Equal([]string{"a:8081", "b:8081", "a:8081", "b:8081"}, s.hosts())
+		})
+	}
+}
+
+func (s *workshopSuite) TestFailingSubWorkshopIsEjected() {
+	s.failingHosts = map[string]bool{"a:8081": true}
+	s.restartApp(fx.Invoke(func(config cfg.Config) {
+		config.Set(controllers.SubWorkshopEjectionFailuresKey, 2)
+		config.Set(controllers.SubWorkshopsKey, map[string]interface{}{
+			"a": map[string]interface{}{"address": "a:8081"},
+			"b": map[string]interface{}{"address": "b:8081"},
+		})
+	}))
+	for i := 0; i < 4; i++ {
+		job := s.paintCar(fmt.Sprintf("eject%03d", i), workshop.Car_SEDAN, "red")
+		s.Eventually(func() bool { return s.jobStatus(job.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	}
+	// every failed request was sent again to b, a isn't called once ejected
+	s.Equal([]string{"a:8081", "b:8081", "a:8081", "b:8081", "b:8081", "b:8081"}, s.hosts())
+}
+
+func (s *workshopSuite) TestRegisterSubWorkshop() {
+	s.restartApp(fx.Invoke(func(config cfg.Config) {
+		config.Set(controllers.SubWorkshopRoutingKey, controllers.RoutingColorAffinity)
+		config.Set(controllers.SubWorkshopAddressKey, "generic:8081")
+	}))
+	_, err := s.controller.RegisterSubWorkshop(context.Background(), &workshop.SubWorkshopRegistration{
+		Name:    "metallic",
+		Address: "metallic:8081",
+		Colors:  []string{"silver"},
+	})
+	s.Require().NoError(err)
+	silver := s.paintCar("affine01", workshop.Car_SEDAN, "silver")
+	s.Eventually(func() bool { return s.jobStatus(silver.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	red := s.paintCar("affine02", workshop.Car_SEDAN, "red")
+	s.Eventually(func() bool { return s.jobStatus(red.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	// registering again with the same name replaces it
+	_, err = s.controller.RegisterSubWorkshop(context.Background(), &workshop.SubWorkshopRegistration{
+		Name:    "metallic",
+		Address: "metallic:9091",
+		Colors:  []string{"silver"},
+	})
+	s.Require().NoError(err)
+	again := s.paintCar("affine03", workshop.Car_PHAETON, "silver")
+	s.Eventually(func() bool { return s.jobStatus(again.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	s.Equal([]string{"metallic:8081", "generic:8081", "metallic:9091"}, s.hosts())
+}
+
+func (s *workshopSuite) TestConcurrentPaintCar() {
+	_, err := s.controller.AcceptCar(context.Background(), &workshop.Car{Number: "twice001", Color: "white"})
+	s.Require().NoError(err)
+	var wg sync.WaitGroup
+	var painting int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.controller.PaintCar(context.Background(), &workshop.PaintCarRequest{CarNumber: "twice001", DesiredColor: "red"})
+			if err == nil {
+				atomic.AddInt32(&painting, 1)
+				return
+			}
+			s.True(errors.Is(err, controllers.ErrCarNotPaintable) || errors.Is(err, data.ErrCarChanged), err.Error())
+		}()
+	}
+	wg.Wait()
+	s.Equal(int32(1), painting)
+	s.Eventually(func() bool {
+		car, err := s.carDB.GetCar(context.Background(), "twice001")
+		return err == nil && car.State == data.CarPainting
+	}, time.Second, time.Millisecond)
+	s.Equal(int32(1), atomic.LoadInt32(&s.subWorkshopCalls))
+}
+
+func (s *workshopSuite) TestAcceptCarIsIdempotent() {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(controllers.IdempotencyKeyHeader, "accept-1"))
+	car := &workshop.Car{Number: "idem001", Owner: "retrying owner", Color: "white"}
+	_, err := s.controller.AcceptCar(ctx, car)
+	s.Require().NoError(err)
+	_, err = s.controller.AcceptCar(ctx, car)
+	s.NoError(err, "the retry gets the original response")
+	_, err = s.controller.AcceptCar(context.Background(), car)
+	s.True(errors.Is(err, data.ErrCarAlreadyExists), "requests without a key aren't idempotent")
+	_, err = s.controller.AcceptCar(ctx, &workshop.Car{Number: "idem002", Color: "white"})
+	s.True(errors.Is(err, controllers.ErrIdempotencyKeyReused))
+}
+
+func (s *workshopSuite) TestPaintCarIsIdempotent() {
+	_, err := s.controller.AcceptCar(context.Background(), &workshop.Car{Number: "idem003", Color: "white"})
+	s.Require().NoError(err)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(controllers.IdempotencyKeyHeader, "paint-1"))
+	request := &workshop.PaintCarRequest{CarNumber: "idem003", DesiredColor: "red"}
+	job, err := s.controller.PaintCar(ctx, request)
+	s.Require().NoError(err)
+	retried, err := s.controller.PaintCar(ctx, request)
+	s.Require().NoError(err)
+	s.Equal(job.GetId(), retried.GetId())
+	s.Eventually(func() bool { return s.jobStatus(job.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	s.Equal(int32(1), atomic.LoadInt32(&s.subWorkshopCalls), "the car is sent to the sub workshop once")
+	jobs, err := s.controller.ListPaintJobs(context.Background(), &workshop.ListPaintJobsRequest{CarNumber: "idem003"})
+	s.Require().NoError(err)
+	s.Len(jobs.GetJobs(), 1)
+
+	_, err = s.controller.PaintCar(ctx, &workshop.PaintCarRequest{CarNumber: "idem003", DesiredColor: "blue"})
+	s.True(errors.Is(err, controllers.ErrIdempotencyKeyReused))
+}
+
+func (s *workshopSuite) TestFailedIdempotentRequestRunsAgain() {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(controllers.IdempotencyKeyHeader, "paint-2"))
+	request := &workshop.PaintCarRequest{CarNumber: "idem004", DesiredColor: "red"}
+	_, err := s.controller.PaintCar(ctx, request)
+	s.True(errors.Is(err, data.ErrCarNotFound))
+	_, err = s.controller.AcceptCar(context.Background(), &workshop.Car{Number: "idem004", Color: "white"})
+	s.Require().NoError(err)
+	job, err := s.controller.PaintCar(ctx, request)
+	s.Require().NoError(err)
+	s.NotEmpty(job.GetId())
+}
+
+func (s *workshopSuite) TestUnsavedResponseReleasesIdempotencyKey() {
+	s.wrapIdempotencyDB = func(db data.IdempotencyDB) data.IdempotencyDB {
+		return &failingCompleteIdempotencyDB{IdempotencyDB: db}
+	}
+	s.restartApp()
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(controllers.IdempotencyKeyHeader, "accept-3"))
+	car := &workshop.Car{Number: "idem006", Color: "white"}
+	_, err := s.controller.AcceptCar(ctx, car)
+	s.Require().NoError(err)
+	_, err = s.controller.AcceptCar(ctx, car)
+	s.True(errors.Is(err, data.ErrCarAlreadyExists), "the retry runs again rather than finding the key in use")
+}
+
+func (s *workshopSuite) TestIdempotencyKeyExpires() {
+	s.restartApp(fx.Invoke(func(config cfg.Config) {
+		config.Set(controllers.IdempotencyWindowKey, "20ms")
+	}))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(controllers.IdempotencyKeyHeader, "accept-2"))
+	car := &workshop.Car{Number: "idem005", Color: "white"}
+	_, err := s.controller.AcceptCar(ctx, car)
+	s.Require().NoError(err)
+	time.Sleep(30 * time.Millisecond)
+	_, err = s.controller.AcceptCar(ctx, car)
+	s.True(errors.Is(err, data.ErrCarAlreadyExists), "the key is forgotten after the window")
+}
+
+func (s *workshopSuite) TestPaintJobs() {
+	ctx := context.Background()
+	_, err := s.controller.AcceptCar(ctx, &workshop.Car{Number: "jobs0001", Color: "white"})
+	s.Require().NoError(err)
+	// painted by the sub workshop
+	job, err := s.controller.PaintCar(ctx, &workshop.PaintCarRequest{CarNumber: "jobs0001", DesiredColor: "red"})
+	s.Require().NoError(err)
+	_, err = s.controller.CarPainted(ctx, s.callback(job))
+	s.Require().NoError(err)
+	job, err = s.controller.GetPaintJob(ctx, &workshop.GetPaintJobRequest{Id: job.GetId()})
+	s.Require().NoError(err)
+	s.Equal(workshop.PaintJob_SUCCEEDED, job.GetJobStatus())
+	s.NotNil(job.GetEndTime())
+	_, err = s.controller.CancelPaintJob(ctx, &workshop.CancelPaintJobRequest{Id: job.GetId()})
+	s.True(errors.Is(err, controllers.ErrPaintJobDone))
+	// cancelled before the sub workshop called back, car must keep its color
+	cancelled, err := s.controller.PaintCar(ctx, &workshop.PaintCarRequest{CarNumber: "jobs0001", DesiredColor: "green"})
+	s.Require().NoError(err)
+	callback := s.callback(cancelled)
+	cancelled, err = s.controller.CancelPaintJob(ctx, &workshop.CancelPaintJobRequest{Id: cancelled.GetId()})
+	s.Require().NoError(err)
+	s.Equal(workshop.PaintJob_CANCELLED, cancelled.GetJobStatus())
+	_, err = s.controller.CarPainted(ctx, callback)
+	s.True(errors.Is(err, controllers.ErrPaintJobDone))
+	car, err := s.carDB.GetCar(ctx, "jobs0001")
+	s.Require().NoError(err)
+	s.Equal("red", car.CurrentColor)
+	// sub workshop is down, the job fails after 3 attempts
+	atomic.StoreInt32(&s.subWorkshopStatusCode, http.StatusServiceUnavailable)
+	unavailable, err := s.controller.PaintCar(ctx, &workshop.PaintCarRequest{CarNumber: "jobs0001", DesiredColor: "blue"})
+	s.Require().NoError(err)
+	// car is moved back once the job failed
+	s.Eventually(func() bool {
+		car, err := s.carDB.GetCar(ctx, "jobs0001")
+		return s.jobStatus(unavailable.GetId()) == workshop.PaintJob_FAILED && err == nil && car.State == data.CarPainted
+	}, time.Second, time.Millisecond)
+	// sub workshop refused, the job fails right away
+	atomic.StoreInt32(&s.subWorkshopCalls, 0)
+	atomic.StoreInt32(&s.subWorkshopStatusCode, http.StatusBadRequest)
+	refused, err := s.controller.PaintCar(ctx, &workshop.PaintCarRequest{CarNumber: "jobs0001", DesiredColor: "blue"})
+	s.Require().NoError(err)
+	s.Eventually(func() bool { return s.jobStatus(refused.GetId()) == workshop.PaintJob_FAILED }, time.Second, time.Millisecond)
+	s.Equal(int32(1), atomic.LoadInt32(&s.subWorkshopCalls))
+	// list, oldest first
+	response, err := s.controller.ListPaintJobs(ctx, &workshop.ListPaintJobsRequest{CarNumber: "jobs0001", PageSize: 2})
+	s.Require().NoError(err)
+	s.Require().Len(response.GetJobs(), 2)
+	s.Equal(job.GetId(), response.GetJobs()[0].GetId())
+	s.Equal(cancelled.GetId(), response.GetJobs()[1].GetId())
+	response, err = s.controller.ListPaintJobs(ctx, &workshop.ListPaintJobsRequest{CarNumber: "jobs0001", PageToken: response.GetNextPageToken()})
+	s.Require().NoError(err)
+	s.Require().Len(response.GetJobs(), 2)
+	s.Equal(workshop.PaintJob_FAILED, response.GetJobs()[0].GetJobStatus())
+	s.Equal("rpc error: code = Unavailable desc = painting failed with status 503", response.GetJobs()[0].GetError())
+	s.Equal("rpc error: code = FailedPrecondition desc = painting failed with status 400", response.GetJobs()[1].GetError())
+	s.Empty(response.GetNextPageToken())
+	// unknown job
+	_, err = s.controller.GetPaintJob(ctx, &workshop.GetPaintJobRequest{Id: "nope"})
+	s.True(errors.Is(err, data.ErrPaintJobNotFound))
 }
 
 func (s *workshopSuite) TestRetrieveCar() {
 	// No car
 	_, err := s.controller.RetrieveCar(context.Background(), &workshop.RetrieveCarRequest{CarNumber: "not yet there"})
-	s.EqualError(err, "unknown car ID not yet there")
+	s.EqualError(err, "car not found: not yet there")
+	s.True(errors.Is(err, data.ErrCarNotFound))
 	car := &data.CarEntity{
 		CarNumber:     "12345",
 		Owner:         "test owner",
@@ -94,9 +548,12 @@ func (s *workshopSuite) TestRetrieveCar() {
 	err = s.carDB.InsertCar(context.Background(), car)
 	s.NoError(err)
 	_, err = s.controller.RetrieveCar(context.Background(), &workshop.RetrieveCarRequest{CarNumber: "12345"})
-	s.EqualError(err, "car 12345 is not painted")
+	s.EqualError(err, "car is not painted: 12345")
+	s.True(errors.Is(err, controllers.ErrCarNotPainted))
 	// Now paint the car and get it
-	err = s.carDB.PaintCar(context.Background(), "12345", "black")
+	_, err = s.carDB.SetCarState(context.Background(), "12345", 0, data.CarPaintRequested)
+	s.NoError(err)
+	_, err = s.carDB.PaintCar(context.Background(), "12345", 0, "black")
 	s.NoError(err)
 	carProto, err := s.controller.RetrieveCar(context.Background(), &workshop.RetrieveCarRequest{CarNumber: "12345"})
 	s.NoError(err)
@@ -104,6 +561,29 @@ func (s *workshopSuite) TestRetrieveCar() {
 	s.Equal("12345", carProto.GetNumber())
 	s.Equal("test owner", carProto.GetOwner())
 	s.Equal(workshop.Car_SEDAN, carProto.GetBodyStyle())
+	s.Equal(workshop.Car_RETRIEVED, carProto.GetState())
+}
+
+func (s *workshopSuite) TestFailedRetrieveKeepsCar() {
+	s.wrapCarDB = func(db data.CarDB) data.CarDB { return &failingRetrieveCarDB{CarDB: db} }
+	s.restartApp()
+	job := s.paintCar("retrieve01", workshop.Car_SEDAN, "red")
+	_, err := s.controller.CarPainted(context.Background(), s.callback(job))
+	s.Require().NoError(err)
+
+	_, err = s.controller.RetrieveCar(context.Background(), &workshop.RetrieveCarRequest{CarNumber: "retrieve01"})
+	s.EqualError(err, "store is down")
+	car, err := s.carDB.GetCar(context.Background(), "retrieve01")
+	s.Require().NoError(err)
+	s.Equal(data.CarPainted, car.State)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var last *workshop.CarEvent
+	s.Require().NoError(s.events.Watch(ctx, "retrieve01", 1, func(event *workshop.CarEvent) error {
+		last = event
+		return nil
+	}))
+	s.Equal(workshop.CarEvent_PAINTED, last.GetEventType())
 }
 
 func (s *workshopSuite) TestCarPainted() {
@@ -114,10 +594,18 @@ func (s *workshopSuite) TestCarPainted() {
 		Color:     "fuchsia",
 	})
 	s.NoError(err)
+	// car must be sent to be painted first, the sub workshop gets the callback token with it
 	_, err = s.controller.CarPainted(context.Background(), &workshop.PaintFinishedRequest{
 		CarNumber:    "123456",
 		DesiredColor: "indigo",
 	})
+	s.True(errors.Is(err, controllers.ErrInvalidCallbackToken))
+	job, err := s.controller.PaintCar(context.Background(), &workshop.PaintCarRequest{
+		CarNumber:    "123456",
+		DesiredColor: "indigo",
+	})
+	s.NoError(err)
+	_, err = s.controller.CarPainted(context.Background(), s.callback(job))
 	s.NoError(err)
 	car, err := s.carDB.GetCar(context.Background(), "123456")
 	s.NoError(err)
@@ -126,6 +614,260 @@ func (s *workshopSuite) TestCarPainted() {
 	s.True(car.Painted)
 }
 
+func (s *workshopSuite) TestPaintJobSurvivesRestart() {
+	dbDir, err := ioutil.TempDir("", "workshop")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dbDir)
+	bolt := fx.Invoke(func(config cfg.Config) {
+		config.Set(data.DBTypeKey, data.DBTypeBolt)
+		config.Set(data.DBBoltPathKey, filepath.Join(dbDir, "cars.db"))
+		// callbacks of cars sent before a restart are accepted only with the same secret
+		config.Set(controllers.CallbackSecretKey, "restart secret")
+	})
+	s.restartApp(bolt)
+	job := s.paintCar("restart1", workshop.Car_SEDAN, "red")
+	callback := s.callback(job)
+	s.Eventually(func() bool { return s.jobStatus(job.GetId()) == workshop.PaintJob_RUNNING }, time.Second, time.Millisecond)
+	// the sub workshop calls back after the workshop restarted
+	s.restartApp(bolt)
+	_, err = s.controller.CarPainted(context.Background(), callback)
+	s.Require().NoError(err)
+	car, err := s.carDB.GetCar(context.Background(), "restart1")
+	s.Require().NoError(err)
+	s.Equal("red", car.CurrentColor)
+	s.Equal(workshop.PaintJob_SUCCEEDED, s.jobStatus(job.GetId()))
+}
+
+func (s *workshopSuite) TestSpoofedCallbackIsRejected() {
+	job := s.paintCar("spoofed1", workshop.Car_SEDAN, "red")
+	callback := s.callback(job)
+	// no token
+	_, err := s.controller.CarPainted(context.Background(), &workshop.PaintFinishedRequest{CarNumber: "spoofed1", DesiredColor: "red", PaintJobId: job.GetId()})
+	s.True(errors.Is(err, controllers.ErrInvalidCallbackToken))
+	// token of another color
+	_, err = s.controller.CarPainted(context.Background(), &workshop.PaintFinishedRequest{
+		CarNumber:     "spoofed1",
+		DesiredColor:  "black",
+		PaintJobId:    job.GetId(),
+		CallbackToken: callback.GetCallbackToken(),
+	})
+	s.True(errors.Is(err, controllers.ErrInvalidCallbackToken))
+	// forged signature
+	expiry := strings.SplitN(callback.GetCallbackToken(), ".", 2)[0]
+	_, err = s.controller.CarPainted(context.Background(), &workshop.PaintFinishedRequest{
+		CarNumber:     "spoofed1",
+		DesiredColor:  "red",
+		PaintJobId:    job.GetId(),
+		CallbackToken: expiry + ".c2lnbmF0dXJl",
+	})
+	s.True(errors.Is(err, controllers.ErrInvalidCallbackToken))
+	car, err := s.carDB.GetCar(context.Background(), "spoofed1")
+	s.Require().NoError(err)
+	s.Equal("white", car.CurrentColor)
+
+	_, err = s.controller.CarPainted(context.Background(), callback)
+	s.Require().NoError(err)
+	// replayed
+	_, err = s.controller.CarPainted(context.Background(), callback)
+	s.True(errors.Is(err, controllers.ErrCallbackReplayed))
+}
+
+func (s *workshopSuite) TestExpiredCallbackIsRejected() {
+	s.restartApp(fx.Invoke(func(config cfg.Config) {
+		config.Set(controllers.CallbackTokenTTLKey, "-1s")
+	}))
+	job := s.paintCar("expired1", workshop.Car_SEDAN, "red")
+	_, err := s.controller.CarPainted(context.Background(), s.callback(job))
+	s.True(errors.Is(err, controllers.ErrInvalidCallbackToken))
+	s.Contains(err.Error(), "expired")
+}
+
+func (s *workshopSuite) TestCarEventsArePublished() {
+	_, err := s.controller.AcceptCar(context.Background(), &workshop.Car{Number: "events01", Color: "white"})
+	s.Require().NoError(err)
+	job, err := s.controller.PaintCar(context.Background(), &workshop.PaintCarRequest{CarNumber: "events01", DesiredColor: "red"})
+	s.Require().NoError(err)
+	callback := s.callback(job)
+	_, err = s.controller.CarPainted(context.Background(), callback)
+	s.Require().NoError(err)
+	_, err = s.controller.RetrieveCar(context.Background(), &workshop.RetrieveCarRequest{CarNumber: "events01"})
+	s.Require().NoError(err)
+	// failed calls don't publish
+	_, err = s.controller.CarPainted(context.Background(), callback)
+	s.Error(err)
+
+	// resume after the accepted event
+	ctx, cancel := context.WithCancel(context.Background())
+	var events []*workshop.CarEvent
+	err = s.events.Watch(ctx, "events01", 1, func(event *workshop.CarEvent) error {
+		if events = append(events, event); len(events) == 3 {
+			cancel()
+		}
+		return nil
+	})
+	s.Require().NoError(err)
+	s.Equal(workshop.CarEvent_PAINT_REQUESTED, events[0].GetEventType())
+	s.Equal(workshop.CarEvent_PAINTED, events[1].GetEventType())
+	s.Equal("red", events[1].GetCar().GetColor())
+	s.Equal(workshop.CarEvent_RETRIEVED, events[2].GetEventType())
+}
+
+func (s *workshopSuite) TestListCars() {
+	for _, number := range []string{"list0001", "list0002", "list0003"} {
+		_, err := s.controller.AcceptCar(context.Background(), &workshop.Car{
+			Number:    number,
+			Owner:     "list owner",
+			BodyStyle: workshop.Car_HATCHBACK,
+			Color:     "white",
+		})
+		s.Require().NoError(err)
+	}
+	request := &workshop.ListCarsRequest{
+		Owner:      "list owner",
+		BodyStyles: []workshop.CarBody{workshop.Car_HATCHBACK},
+		PageSize:   2,
+	}
+	response, err := s.controller.ListCars(context.Background(), request)
+	s.Require().NoError(err)
+	s.Len(response.GetCars(), 2)
+	s.Equal("list0001", response.GetCars()[0].GetNumber())
+	s.Equal(workshop.Car_HATCHBACK, response.GetCars()[0].GetBodyStyle())
+	s.NotEmpty(response.GetNextPageToken())
+	// last page
+	request.PageToken = response.GetNextPageToken()
+	response, err = s.controller.ListCars(context.Background(), request)
+	s.Require().NoError(err)
+	s.Len(response.GetCars(), 1)
+	s.Equal("list0003", response.GetCars()[0].GetNumber())
+	s.Empty(response.GetNextPageToken())
+	// filter out everything
+	response, err = s.controller.ListCars(context.Background(), &workshop.ListCarsRequest{
+		Owner:   "list owner",
+		Painted: &wrappers.BoolValue{Value: true},
+	})
+	s.Require().NoError(err)
+	s.Empty(response.GetCars())
+	// bad token
+	_, err = s.controller.ListCars(context.Background(), &workshop.ListCarsRequest{PageToken: "not a token!"})
+	s.True(errors.Is(err, controllers.ErrInvalidPageToken))
+}
+
+func (s *workshopSuite) TestUpdateCar() {
+	ctx := context.Background()
+	_, err := s.controller.AcceptCar(ctx, &workshop.Car{Number: "update01", Owner: "typo owner", BodyStyle: workshop.Car_HATCHBACK, Color: "white"})
+	s.Require().NoError(err)
+	// only the fields in the mask are changed
+	car, err := s.controller.UpdateCar(ctx, &workshop.UpdateCarRequest{
+		Car:        &workshop.Car{Number: "update01", Owner: "new owner", Etag: "1"},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"owner"}},
+	})
+	s.Require().NoError(err)
+	s.Equal("new owner", car.GetOwner())
+	s.Equal(workshop.Car_HATCHBACK, car.GetBodyStyle())
+	s.Equal("white", car.GetColor())
+	s.Equal("2", car.GetEtag())
+	// without a mask the fields that are set are changed
+	car, err = s.controller.UpdateCar(ctx, &workshop.UpdateCarRequest{
+		Car: &workshop.Car{Number: "update01", BodyStyle: workshop.Car_PHAETON},
+	})
+	s.Require().NoError(err)
+	s.Equal("new owner", car.GetOwner())
+	s.Equal(workshop.Car_PHAETON, car.GetBodyStyle())
+	s.Equal("3", car.GetEtag())
+	// somebody changed the car since etag 2
+	_, err = s.controller.UpdateCar(ctx, &workshop.UpdateCarRequest{
+		Car:        &workshop.Car{Number: "update01", Owner: "stale owner", Etag: "2"},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"owner"}},
+	})
+	s.True(errors.Is(err, data.ErrCarChanged))
+	stored, err := s.carDB.GetCar(ctx, "update01")
+	s.Require().NoError(err)
+	s.Equal("new owner", stored.Owner)
+	// no car
+	_, err = s.controller.UpdateCar(ctx, &workshop.UpdateCarRequest{Car: &workshop.Car{Number: "update02", Owner: "nobody"}})
+	s.True(errors.Is(err, data.ErrCarNotFound))
+}
+
+func (s *workshopSuite) TestIfMatch() {
+	ctx := context.Background()
+	_, err := s.controller.AcceptCar(ctx, &workshop.Car{Number: "ifmatch1", Owner: "owner", Color: "white"})
+	s.Require().NoError(err)
+	ifMatch := func(etag string) context.Context {
+		return metadata.NewIncomingContext(ctx, metadata.Pairs(controllers.IfMatchHeader, etag))
+	}
+	// the car changed since etag 1
+	car, err := s.controller.UpdateCar(ctx, &workshop.UpdateCarRequest{Car: &workshop.Car{Number: "ifmatch1", Owner: "new owner"}})
+	s.Require().NoError(err)
+	s.Equal("2", car.GetEtag())
+	_, err = s.controller.PaintCar(ifMatch(`"1"`), &workshop.PaintCarRequest{CarNumber: "ifmatch1", DesiredColor: "red"})
+	s.True(errors.Is(err, data.ErrCarChanged))
+	_, err = s.controller.UpdateCar(ifMatch(`"1"`), &workshop.UpdateCarRequest{Car: &workshop.Car{Number: "ifmatch1", Owner: "stale owner"}})
+	s.True(errors.Is(err, data.ErrCarChanged))
+	_, err = s.controller.PaintCar(ifMatch("not an etag"), &workshop.PaintCarRequest{CarNumber: "ifmatch1", DesiredColor: "red"})
+	s.True(errors.Is(err, controllers.ErrInvalidETag))
+	s.Equal(int32(0), atomic.LoadInt32(&s.subWorkshopCalls), "nothing was sent to be painted")
+	// the current etag, quoted as HTTP clients send it, or any etag
+	_, err = s.controller.PaintCar(ifMatch(`"2"`), &workshop.PaintCarRequest{CarNumber: "ifmatch1", DesiredColor: "red"})
+	s.Require().NoError(err)
+	_, err = s.controller.UpdateCar(ifMatch("*"), &workshop.UpdateCarRequest{Car: &workshop.Car{Number: "ifmatch1", Owner: "newer owner"}})
+	s.NoError(err)
+}
+
+func (s *workshopSuite) TestCarHistory() {
+	ctx := security.WithPrincipal(context.Background(), security.Principal{Subject: "dana"})
+	ctx = opentracing.ContextWithSpan(ctx, tracedSpan{opentracing.NoopTracer{}.StartSpan("test")})
+	_, err := s.controller.AcceptCar(ctx, &workshop.Car{Number: "history1", Owner: "dana", BodyStyle: workshop.Car_SEDAN, Color: "white"})
+	s.Require().NoError(err)
+	job, err := s.controller.PaintCar(ctx, &workshop.PaintCarRequest{CarNumber: "history1", DesiredColor: "red"})
+	s.Require().NoError(err)
+	callback := s.callback(job)
+	// the sub workshop may call back before the workshop saw its answer
+	s.Require().Eventually(func() bool {
+		car, err := s.carDB.GetCar(context.Background(), "history1")
+		return err == nil && car.State == data.CarPainting
+	}, time.Second, time.Millisecond)
+	_, err = s.controller.CarPainted(context.Background(), callback)
+	s.Require().NoError(err)
+
+	history, err := s.controller.GetCarHistory(ctx, &workshop.GetCarHistoryRequest{CarNumber: "history1"})
+	s.Require().NoError(err)
+	s.Empty(history.GetNextPageToken())
+	entries := history.GetEntries()
+	s.Require().Len(entries, 4)
+	s.Equal(workshop.Car_UNKNOWN, entries[0].GetOldState())
+	s.Equal(workshop.Car_ACCEPTED, entries[0].GetNewState())
+	s.Equal("dana", entries[0].GetActor())
+	s.Equal("abc123", entries[0].GetTraceId())
+	s.Equal("1", entries[0].GetEtag())
+	s.NotNil(entries[0].GetTime())
+	s.Equal(workshop.Car_PAINT_REQUESTED, entries[1].GetNewState())
+	s.Equal("dana", entries[1].GetActor())
+	s.Equal(workshop.Car_PAINTING, entries[2].GetNewState())
+	s.Equal("workshop", entries[2].GetActor())
+	s.NotEmpty(entries[2].GetSubWorkshop())
+	s.Equal(workshop.Car_PAINTING, entries[3].GetOldState())
+	s.Equal(workshop.Car_PAINTED, entries[3].GetNewState())
+	s.Equal("white", entries[3].GetOldColor())
+	s.Equal("red", entries[3].GetNewColor())
+	s.Equal(entries[2].GetSubWorkshop(), entries[3].GetSubWorkshop())
+
+	// pages
+	page, err := s.controller.GetCarHistory(ctx, &workshop.GetCarHistoryRequest{CarNumber: "history1", PageSize: 3})
+	s.Require().NoError(err)
+	s.Len(page.GetEntries(), 3)
+	s.Require().NotEmpty(page.GetNextPageToken())
+	page, err = s.controller.GetCarHistory(ctx, &workshop.GetCarHistoryRequest{CarNumber: "history1", PageSize: 3, PageToken: page.GetNextPageToken()})
+	s.Require().NoError(err)
+	s.Require().Len(page.GetEntries(), 1)
+	s.Equal(workshop.Car_PAINTED, page.GetEntries()[0].GetNewState())
+	s.Empty(page.GetNextPageToken())
+
+	_, err = s.controller.GetCarHistory(ctx, &workshop.GetCarHistoryRequest{CarNumber: "history2"})
+	s.True(errors.Is(err, data.ErrCarNotFound))
+	_, err = s.controller.GetCarHistory(ctx, &workshop.GetCarHistoryRequest{CarNumber: "history1", PageToken: "not a token"})
+	s.True(errors.Is(err, controllers.ErrInvalidPageToken))
+}
+
 func (s *workshopSuite) SetupSuite() {
 	var err error
 	s.pwd, err = os.Getwd()
@@ -133,39 +875,118 @@ func (s *workshopSuite) SetupSuite() {
 }
 
 func (s *workshopSuite) SetupTest() {
+	s.subWorkshopStatusCode = http.StatusOK
+	s.subWorkshopFailures = 0
+	s.subWorkshopCalls = 0
+	s.subWorkshopHangs = 0
+	s.subWorkshopHosts = nil
+	s.failingHosts = nil
+	s.wrapCarDB = nil
+	s.wrapIdempotencyDB = nil
 	s.ctrl = gomock.NewController(s.T())
+	s.grpcConnBuilderMock = mock_client.NewMockGRPCClientConnectionBuilder(s.ctrl)
+	s.gRPCWrapperMock = mock_client.NewMockGRPCClientConnectionWrapper(s.ctrl)
 	s.app = fxtest.New(s.T(),
 		s.fxOptions(),
 	)
 	s.app.RequireStart()
 }
 
+// restartApp starts the application again with options applied before the controller is created
+func (s *workshopSuite) restartApp(options ...fx.Option) {
+	s.app.RequireStop()
+	s.app = fxtest.New(s.T(),
+		s.fxOptions(options...),
+	)
+	s.app.RequireStart()
+}
+
 func (s *workshopSuite) TearDownTest() {
 	s.app.RequireStop()
 	s.ctrl.Finish()
 }
 
-func (s *workshopSuite) fxOptions() fx.Option {
+func (s *workshopSuite) fxOptions(options ...fx.Option) fx.Option {
 	return fx.Options(
 		fx.NopLogger, // remove fx debug prints
 		mortar.ViperFxOption(s.pwd+"/../../config/config.yml", s.pwd+"/../../config/config_test.yml"),
 		mortar.LoggerFxOption(),
 		//providers.HTTPClientBuildersFxOption(), // uncomment this line to see that TestPaintCar fails
+		fx.Invoke(func(config cfg.Config) {
+			config.Set(controllers.OutboxRetryKey+".attempts", 3)
+			config.Set(controllers.OutboxRetryKey+".initial", "1ms")
+			config.Set(controllers.OutboxRetryKey+".max", "5ms")
+			config.Set(controllers.OutboxIntervalKey, "10ms")
+		}),
+		fx.Options(options...),
 		fx.Provide(s.specialHTTPClientBuilder),
-		fx.Provide(data.CreateCarDB),
+		fx.Provide(func() clientInt.GRPCClientConnectionBuilder { return s.grpcConnBuilderMock }),
+		fx.Provide(security.CreateTLS),
+		fx.Provide(fx.Annotated{Name: "store", Target: data.CreateCarDB}),
+		fx.Provide(s.wrappedCarDB),
+		fx.Provide(fx.Annotated{Name: "idempotency", Target: data.CreateIdempotencyDB}),
+		fx.Provide(s.wrappedIdempotencyDB),
 		fx.Provide(controllers.CreateWorkshopController),
+		fx.Provide(controllers.CreateCarEvents),
 		fx.Populate(&s.carDB),
+		fx.Populate(&s.events),
 		fx.Populate(&s.controller),
 	)
 }
 
+type storeDeps struct {
+	fx.In
+
+	CarDB         data.CarDB         `name:"store"`
+	IdempotencyDB data.IdempotencyDB `name:"idempotency"`
+}
+
+func (s *workshopSuite) wrappedCarDB(deps storeDeps) data.CarDB {
+	if s.wrapCarDB == nil {
+		return deps.CarDB
+	}
+	return s.wrapCarDB(deps.CarDB)
+}
+
+func (s *workshopSuite) wrappedIdempotencyDB(deps storeDeps) data.IdempotencyDB {
+	if s.wrapIdempotencyDB == nil {
+		return deps.IdempotencyDB
+	}
+	return s.wrapIdempotencyDB(deps.IdempotencyDB)
+}
+
 func (s *workshopSuite) specialHTTPClientBuilder() clientInt.NewHTTPClientBuilder {
 	return func() clientInt.HTTPClientBuilder {
-		return client.HTTPClientBuilder().AddInterceptors(func(*http.Request, clientInt.HTTPpHandler) (*http.Response, error) {
+		return client.HTTPClientBuilder().AddInterceptors(func(request *http.Request, _ clientInt.HTTPpHandler) (*http.Response, error) {
 			// special case, don't go anywhere just return the response
+			body, err := ioutil.ReadAll(request.Body)
+			if err != nil {
+				return nil, err
+			}
+			request.Body = ioutil.NopCloser(bytes.NewReader(body))
+			paintRequest := new(workshop.SubPaintCarRequest)
+			if err = jsonpb.Unmarshal(bytes.NewReader(body), paintRequest); err == nil {
+				s.paintRequests.Store(paintRequest.GetPaintJobId(), paintRequest)
+			}
+			s.subWorkshopRequest.Store(request)
+			atomic.AddInt32(&s.subWorkshopCalls, 1)
+			if atomic.LoadInt32(&s.subWorkshopHangs) != 0 {
+				<-request.Context().Done()
+				return nil, request.Context().Err()
+			}
+			statusCode := int(atomic.LoadInt32(&s.subWorkshopStatusCode))
+			if atomic.AddInt32(&s.subWorkshopFailures, -1) >= 0 {
+				statusCode = http.StatusServiceUnavailable
+			}
+			s.subWorkshopHostsLock.Lock()
+			s.subWorkshopHosts = append(s.subWorkshopHosts, request.URL.Host)
+			if s.failingHosts[request.URL.Host] {
+				statusCode = http.StatusServiceUnavailable
+			}
+			s.subWorkshopHostsLock.Unlock()
 			return &http.Response{
-				Status:        "200 OK",
-				StatusCode:    200,
+				Status:        http.StatusText(statusCode),
+				StatusCode:    statusCode,
 				Proto:         "HTTP/1.1",
 				ProtoMajor:    1,
 				ProtoMinor:    1,
@@ -175,3 +996,78 @@ func (s *workshopSuite) specialHTTPClientBuilder() clientInt.NewHTTPClientBuilde
 		})
 	}
 }
+
+// hosts returns the hosts the fake sub workshop got requests for, oldest first
+func (s *workshopSuite) hosts() []string {
+	s.subWorkshopHostsLock.Lock()
+	defer s.subWorkshopHostsLock.Unlock()
+	return append([]string(nil), s.subWorkshopHosts...)
+}
+
+// paintCar accepts a new car and asks to paint it
+func (s *workshopSuite) paintCar(number string, bodyStyle workshop.CarBody, color string) *workshop.PaintJob {
+	_, err := s.controller.AcceptCar(context.Background(), &workshop.Car{Number: number, BodyStyle: bodyStyle, Color: "white"})
+	s.Require().NoError(err)
+	job, err := s.controller.PaintCar(context.Background(), &workshop.PaintCarRequest{CarNumber: number, DesiredColor: color})
+	s.Require().NoError(err)
+	return job
+}
+
+// callback is what the sub workshop sends back once it painted the car of job
+func (s *workshopSuite) callback(job *workshop.PaintJob) *workshop.PaintFinishedRequest {
+	var request *workshop.SubPaintCarRequest
+	s.Require().Eventually(func() bool {
+		value, exists := s.paintRequests.Load(job.GetId())
+		if exists {
+			request = value.(*workshop.SubPaintCarRequest)
+		}
+		return exists
+	}, time.Second, time.Millisecond)
+	return &workshop.PaintFinishedRequest{
+		CarNumber:     request.GetCar().GetNumber(),
+		DesiredColor:  request.GetDesiredColor(),
+		PaintJobId:    request.GetPaintJobId(),
+		CallbackToken: request.GetCallbackToken(),
+	}
+}
+
+func (s *workshopSuite) jobStatus(id string) workshop.PaintJobStatus {
+	job, err := s.controller.GetPaintJob(context.Background(), &workshop.GetPaintJobRequest{Id: id})
+	s.Require().NoError(err)
+	return job.GetJobStatus()
+}
+
+// tracedSpan is part of the abc123 trace, Jaeger span contexts print as trace-id:span-id:parent-id:flags
+type tracedSpan struct {
+	opentracing.Span
+}
+
+func (tracedSpan) Context() opentracing.SpanContext {
+	return tracedSpanContext{}
+}
+
+type tracedSpanContext struct {
+	opentracing.SpanContext
+}
+
+func (tracedSpanContext) String() string {
+	return "abc123:1:0:1"
+}
+
+// failingRetrieveCarDB can't retrieve cars
+type failingRetrieveCarDB struct {
+	data.CarDB
+}
+
+func (f *failingRetrieveCarDB) RetrieveCar(context.Context, string, int64) (*data.CarEntity, error) {
+	return nil, fmt.Errorf("store is down")
+}
+
+// failingCompleteIdempotencyDB can't save responses
+type failingCompleteIdempotencyDB struct {
+	data.IdempotencyDB
+}
+
+func (f *failingCompleteIdempotencyDB) Complete(context.Context, string, []byte) error {
+	return fmt.Errorf("store is down")
+}
@@ -0,0 +1,401 @@
+package data
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/fx"
+)
+
+const defaultBoltTimeout = time.Second
+
+var (
+	carsBucket      = []byte("cars")
+	outboxBucket    = []byte("outbox")
+	paintJobsBucket = []byte("paint_jobs")
+	// historyBucket has a bucket per car, with its changes by sequence
+	historyBucket = []byte("history")
+)
+
+// boltCarDB stores cars in an embedded bolt file.
+// Every write is a single transaction that is synced to disk before it returns, a crash can't leave a partial write.
+type boltCarDB struct {
+	db *bolt.DB
+}
+
+func createBoltCarDB(deps carDBDeps) (CarDB, error) {
+	path := deps.Config.Get(DBBoltPathKey).String()
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%s must be set when using %s car db", DBBoltPathKey, DBTypeBolt)
+	}
+	timeout := defaultBoltTimeout
+	if value := deps.Config.Get(DBBoltTimeoutKey); value.IsSet() {
+		timeout = value.Duration()
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: timeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s, %w", path, err)
+	}
+	if err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{carsBucket, outboxBucket, paintJobsBucket, historyBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+	deps.Lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return db.Close()
+		},
+	})
+	return &boltCarDB{db: db}, nil
+}
+
+func (b *boltCarDB) InsertCar(ctx context.Context, car *CarEntity) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(carsBucket)
+		if bucket.Get([]byte(car.CarNumber)) != nil {
+			return carAlreadyExists(car.CarNumber)
+		}
+		stored := car.copy()
+		stored.State = CarAccepted
+		stored.Version = 1
+		if err := putCar(bucket, stored); err != nil {
+			return err
+		}
+		return putHistory(tx, newCarHistory(ctx, nil, stored))
+	})
+}
+
+func (b *boltCarDB) PaintCar(ctx context.Context, carNumber string, version int64, newColor string) (*CarEntity, error) {
+	return b.write(ctx, carNumber, version, func(tx *bolt.Tx, car *CarEntity) error {
+		return car.paint(newColor)
+	})
+}
+
+func (b *boltCarDB) SetCarState(ctx context.Context, carNumber string, version int64, state string) (*CarEntity, error) {
+	return b.write(ctx, carNumber, version, func(tx *bolt.Tx, car *CarEntity) error {
+		return car.transition(state)
+	})
+}
+
+func (b *boltCarDB) RequestPaint(ctx context.Context, carNumber string, version int64, job *PaintJobEntity, message *OutboxEntity) (*CarEntity, error) {
+	return b.write(ctx, carNumber, version, func(tx *bolt.Tx, car *CarEntity) error {
+		if err := car.transition(CarPaintRequested); err != nil {
+			return err
+		}
+		if err := putPaintJob(tx.Bucket(paintJobsBucket), job); err != nil {
+			return err
+		}
+		return putOutbox(tx.Bucket(outboxBucket), message)
+	})
+}
+
+func (b *boltCarDB) UpdateCar(ctx context.Context, carNumber string, version int64, edit func(car *CarEntity) error) (*CarEntity, error) {
+	return b.write(ctx, carNumber, version, func(tx *bolt.Tx, car *CarEntity) error {
+		return car.update(edit)
+	})
+}
+
+// write saves the changes change makes to the car if it's still at version, in the same transaction
+func (b *boltCarDB) write(ctx context.Context, carNumber string, version int64, change func(tx *bolt.Tx, car *CarEntity) error) (car *CarEntity, err error) {
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(carsBucket)
+		if car, err = getCar(bucket, carNumber); err != nil {
+			return err
+		}
+		if err = car.At(version); err != nil {
+			return err
+		}
+		before := car.copy()
+		if err = change(tx, car); err != nil {
+			return err
+		}
+		if err = putCar(bucket, car); err != nil {
+			return err
+		}
+		if car.State == before.State {
+			return nil
+		}
+		return putHistory(tx, newCarHistory(ctx, before, car))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (b *boltCarDB) GetCar(ctx context.Context, carNumber string) (car *CarEntity, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		car, err = getCar(tx.Bucket(carsBucket), carNumber)
+		return err
+	})
+	return
+}
+
+func (b *boltCarDB) RemoveCar(ctx context.Context, carNumber string, version int64) (car *CarEntity, err error) {
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(carsBucket)
+		if car, err = getCar(bucket, carNumber); err != nil {
+			return err
+		}
+		if err = car.At(version); err != nil {
+			return err
+		}
+		return bucket.Delete([]byte(carNumber))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (b *boltCarDB) RetrieveCar(ctx context.Context, carNumber string, version int64) (car *CarEntity, err error) {
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(carsBucket)
+		if car, err = getCar(bucket, carNumber); err != nil {
+			return err
+		}
+		if err = car.At(version); err != nil {
+			return err
+		}
+		before := car.copy()
+		if err = car.transition(CarRetrieved); err != nil {
+			return err
+		}
+		if err = bucket.Delete([]byte(carNumber)); err != nil {
+			return err
+		}
+		return putHistory(tx, newCarHistory(ctx, before, car))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (b *boltCarDB) ListCars(ctx context.Context, filter CarFilter, afterCarNumber string, limit int) (cars []*CarEntity, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		// keys are sorted byte wise, the same order as car numbers
+		cursor := tx.Bucket(carsBucket).Cursor()
+		key, value := cursor.Seek([]byte(afterCarNumber))
+		if key != nil && string(key) == afterCarNumber {
+			key, value = cursor.Next()
+		}
+		for ; key != nil && len(cars) < limit; key, value = cursor.Next() {
+			car, err := decodeCar(key, value)
+			if err != nil {
+				return err
+			}
+			if filter.matches(car) {
+				cars = append(cars, car)
+			}
+		}
+		return nil
+	})
+	return
+}
+
+func (b *boltCarDB) CarHistory(ctx context.Context, carNumber string, afterSequence int64, limit int) (history []*CarHistoryEntity, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(historyBucket).Bucket([]byte(carNumber))
+		if bucket == nil {
+			return nil
+		}
+		// keys are big endian sequences, sorted byte wise as numbers
+		cursor := bucket.Cursor()
+		for key, value := cursor.Seek(historyKey(afterSequence + 1)); key != nil && len(history) < limit; key, value = cursor.Next() {
+			change := new(CarHistoryEntity)
+			if err := json.Unmarshal(value, change); err != nil {
+				return fmt.Errorf("history of car %s is corrupted, %w", carNumber, err)
+			}
+			history = append(history, change)
+		}
+		return nil
+	})
+	return
+}
+
+func (b *boltCarDB) UpdatePaintJob(ctx context.Context, id string, update func(job *PaintJobEntity) error) (job *PaintJobEntity, err error) {
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(paintJobsBucket)
+		if job, err = getPaintJob(bucket, id); err != nil {
+			return err
+		}
+		if err = update(job); err != nil {
+			return err
+		}
+		if job.Done() {
+			if err = tx.Bucket(outboxBucket).Delete([]byte(id)); err != nil {
+				return err
+			}
+		}
+		return putPaintJob(bucket, job)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (b *boltCarDB) GetPaintJob(ctx context.Context, id string) (job *PaintJobEntity, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		job, err = getPaintJob(tx.Bucket(paintJobsBucket), id)
+		return err
+	})
+	return
+}
+
+func (b *boltCarDB) ListPaintJobs(ctx context.Context, carNumber string, afterID string, limit int) (jobs []*PaintJobEntity, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(paintJobsBucket).Cursor()
+		key, value := cursor.Seek([]byte(afterID))
+		if key != nil && string(key) == afterID {
+			key, value = cursor.Next()
+		}
+		for ; key != nil && len(jobs) < limit; key, value = cursor.Next() {
+			job, err := decodePaintJob(key, value)
+			if err != nil {
+				return err
+			}
+			if len(carNumber) == 0 || job.CarNumber == carNumber {
+				jobs = append(jobs, job)
+			}
+		}
+		return nil
+	})
+	return
+}
+
+func (b *boltCarDB) PendingOutbox(ctx context.Context, now time.Time, limit int) (messages []*OutboxEntity, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(outboxBucket).Cursor()
+		for key, value := cursor.First(); key != nil && len(messages) < limit; key, value = cursor.Next() {
+			message := new(OutboxEntity)
+			if err := json.Unmarshal(value, message); err != nil {
+				return fmt.Errorf("outbox message %s is corrupted, %w", key, err)
+			}
+			if !message.NextAttempt.After(now) {
+				messages = append(messages, message)
+			}
+		}
+		return nil
+	})
+	return
+}
+
+func (b *boltCarDB) RescheduleOutbox(ctx context.Context, message *OutboxEntity) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(outboxBucket)
+		// removed while it was being delivered
+		if bucket.Get([]byte(message.ID)) == nil {
+			return nil
+		}
+		return putOutbox(bucket, message)
+	})
+}
+
+func (b *boltCarDB) RemoveOutbox(ctx context.Context, id string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(outboxBucket).Delete([]byte(id))
+	})
+}
+
+func getCar(bucket *bolt.Bucket, carNumber string) (*CarEntity, error) {
+	value := bucket.Get([]byte(carNumber))
+	if value == nil {
+		return nil, carNotFound(carNumber)
+	}
+	return decodeCar([]byte(carNumber), value)
+}
+
+func decodeCar(key, value []byte) (*CarEntity, error) {
+	car := new(CarEntity)
+	if err := json.Unmarshal(value, car); err != nil {
+		return nil, fmt.Errorf("car %s is corrupted, %w", key, err)
+	}
+	if len(car.State) == 0 {
+		// stored before cars had a state
+		car.State = CarAccepted
+		if car.Painted {
+			car.State = CarPainted
+		}
+	}
+	if car.Version == 0 {
+		// stored before cars had a version
+		car.Version = 1
+	}
+	return car, nil
+}
+
+func putCar(bucket *bolt.Bucket, car *CarEntity) error {
+	value, err := json.Marshal(car)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(car.CarNumber), value)
+}
+
+func putHistory(tx *bolt.Tx, history *CarHistoryEntity) error {
+	root := tx.Bucket(historyBucket)
+	sequence, err := root.NextSequence()
+	if err != nil {
+		return err
+	}
+	history.Sequence = int64(sequence)
+	bucket, err := root.CreateBucketIfNotExists([]byte(history.CarNumber))
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(historyKey(history.Sequence), value)
+}
+
+func historyKey(sequence int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(sequence))
+	return key
+}
+
+func getPaintJob(bucket *bolt.Bucket, id string) (*PaintJobEntity, error) {
+	value := bucket.Get([]byte(id))
+	if value == nil {
+		return nil, paintJobNotFound(id)
+	}
+	return decodePaintJob([]byte(id), value)
+}
+
+func decodePaintJob(key, value []byte) (*PaintJobEntity, error) {
+	job := new(PaintJobEntity)
+	if err := json.Unmarshal(value, job); err != nil {
+		return nil, fmt.Errorf("paint job %s is corrupted, %w", key, err)
+	}
+	return job, nil
+}
+
+func putPaintJob(bucket *bolt.Bucket, job *PaintJobEntity) error {
+	value, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(job.ID), value)
+}
+
+func putOutbox(bucket *bolt.Bucket, message *OutboxEntity) error {
+	value, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(message.ID), value)
+}
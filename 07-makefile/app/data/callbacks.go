@@ -0,0 +1,114 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/fx"
+)
+
+// CallbackQueuePathKey is the path of the bolt file that keeps the sub workshop callbacks that are yet to be delivered
+const CallbackQueuePathKey = "custom.subworkshop.callback.queue.path"
+
+var callbacksBucket = []byte("callbacks")
+
+// CallbackEntity is a paint result the sub workshop failed to report back to the workshop
+type CallbackEntity struct {
+	ID              string
+	CarNumber       string
+	DesiredColor    string
+	PaintJobID      string
+	CallbackAddress string
+	CallbackToken   string
+	Attempts        int
+	LastError       string
+	NextAttempt     time.Time
+	// Deadline of the paint request, zero if it had none
+	Deadline time.Time
+}
+
+// CallbackQueue persists callbacks so they survive restarts, ids are expected to sort in creation order
+type CallbackQueue interface {
+	// Push adds the callback, or replaces the one with the same id
+	Push(ctx context.Context, callback *CallbackEntity) error
+	// Due returns up to limit callbacks that should be attempted at now, oldest first
+	Due(ctx context.Context, now time.Time, limit int) ([]*CallbackEntity, error)
+	Remove(ctx context.Context, id string) error
+}
+
+type callbackQueueDeps struct {
+	fx.In
+
+	Config    cfg.Config
+	Lifecycle fx.Lifecycle
+}
+
+type boltCallbackQueue struct {
+	db *bolt.DB
+}
+
+// CreateCallbackQueue is a constructor for Fx
+func CreateCallbackQueue(deps callbackQueueDeps) (CallbackQueue, error) {
+	path := deps.Config.Get(CallbackQueuePathKey).String()
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%s must be set", CallbackQueuePathKey)
+	}
+	timeout := defaultBoltTimeout
+	if value := deps.Config.Get(DBBoltTimeoutKey); value.IsSet() {
+		timeout = value.Duration()
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: timeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s, %w", path, err)
+	}
+	if err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(callbacksBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+	deps.Lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return db.Close()
+		},
+	})
+	return &boltCallbackQueue{db: db}, nil
+}
+
+func (b *boltCallbackQueue) Push(ctx context.Context, callback *CallbackEntity) error {
+	value, err := json.Marshal(callback)
+	if err != nil {
+		return err
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(callbacksBucket).Put([]byte(callback.ID), value)
+	})
+}
+
+func (b *boltCallbackQueue) Due(ctx context.Context, now time.Time, limit int) (callbacks []*CallbackEntity, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(callbacksBucket).Cursor()
+		for key, value := cursor.First(); key != nil && len(callbacks) < limit; key, value = cursor.Next() {
+			callback := new(CallbackEntity)
+			if err := json.Unmarshal(value, callback); err != nil {
+				return fmt.Errorf("callback %s is corrupted, %w", key, err)
+			}
+			if !callback.NextAttempt.After(now) {
+				callbacks = append(callbacks, callback)
+			}
+		}
+		return nil
+	})
+	return
+}
+
+func (b *boltCallbackQueue) Remove(ctx context.Context, id string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(callbacksBucket).Delete([]byte(id))
+	})
+}
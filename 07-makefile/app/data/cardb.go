@@ -0,0 +1,107 @@
+package data
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/log"
+	"go.uber.org/fx"
+)
+
+const (
+	// DBTypeKey selects the CarDB implementation, one of DBTypeInMemory (default), DBTypeBolt or DBTypeSQL
+	DBTypeKey = "custom.db.type"
+	// DBBoltPathKey is the path of the bolt database file
+	DBBoltPathKey = "custom.db.bolt.path"
+	// DBBoltTimeoutKey is how long to wait for the bolt file lock before giving up
+	DBBoltTimeoutKey = "custom.db.bolt.timeout"
+	// DBSQLDriverKey is the database/sql driver name, sqlite3 by default
+	DBSQLDriverKey = "custom.db.sql.driver"
+	// DBSQLDSNKey is the data source name passed to the driver
+	DBSQLDSNKey = "custom.db.sql.dsn"
+
+	DBTypeInMemory = "inmemory"
+	DBTypeBolt     = "bolt"
+	DBTypeSQL      = "sql"
+)
+
+// This interface will represent our car db, inserted cars start at CarAccepted and version 1.
+// Writes take the version the caller read the car at and fail with ErrCarChanged if the car is at another
+// version by then, version 0 writes the car at any version.
+// Every change of the state of a car is saved in its history with the write, see WithChangeSource.
+type CarDB interface {
+	Outbox
+	PaintJobDB
+
+	InsertCar(ctx context.Context, car *CarEntity) error
+	// PaintCar moves the car to CarPainted, see CanTransitionCar
+	PaintCar(ctx context.Context, carNumber string, version int64, newColor string) (*CarEntity, error)
+	// SetCarState moves the car to state, see CanTransitionCar
+	SetCarState(ctx context.Context, carNumber string, version int64, state string) (*CarEntity, error)
+	// RequestPaint moves the car to CarPaintRequested, inserts its paint job and adds message to the outbox, all or
+	// none are saved
+	RequestPaint(ctx context.Context, carNumber string, version int64, job *PaintJobEntity, message *OutboxEntity) (*CarEntity, error)
+	// UpdateCar saves the owner and body style edit sets
+	UpdateCar(ctx context.Context, carNumber string, version int64, edit func(car *CarEntity) error) (*CarEntity, error)
+	GetCar(ctx context.Context, carNumber string) (*CarEntity, error)
+	RemoveCar(ctx context.Context, carNumber string, version int64) (*CarEntity, error)
+	// RetrieveCar moves the car to CarRetrieved and removes it, both or neither are saved. Its history is kept.
+	RetrieveCar(ctx context.Context, carNumber string, version int64) (*CarEntity, error)
+	// ListCars returns up to limit cars matching filter, ordered by car number and starting after afterCarNumber
+	ListCars(ctx context.Context, filter CarFilter, afterCarNumber string, limit int) ([]*CarEntity, error)
+	// CarHistory returns up to limit changes of the car, oldest first and starting after afterSequence.
+	// The history of removed cars is kept, it's empty for cars that never existed.
+	CarHistory(ctx context.Context, carNumber string, afterSequence int64, limit int) ([]*CarHistoryEntity, error)
+}
+
+// CarFilter selects cars in ListCars, zero value fields match every car
+type CarFilter struct {
+	Owner        string
+	BodyStyles   []string
+	CurrentColor string
+	Painted      *bool
+}
+
+func (f CarFilter) matches(car *CarEntity) bool {
+	if len(f.Owner) > 0 && car.Owner != f.Owner {
+		return false
+	}
+	if len(f.CurrentColor) > 0 && car.CurrentColor != f.CurrentColor {
+		return false
+	}
+	if f.Painted != nil && car.Painted != *f.Painted {
+		return false
+	}
+	if len(f.BodyStyles) == 0 {
+		return true
+	}
+	for _, bodyStyle := range f.BodyStyles {
+		if car.BodyStyle == bodyStyle {
+			return true
+		}
+	}
+	return false
+}
+
+type carDBDeps struct {
+	fx.In
+
+	Config    cfg.Config
+	Logger    log.Logger
+	Lifecycle fx.Lifecycle
+}
+
+// CreateCarDB creates the CarDB implementation chosen in the configuration
+func CreateCarDB(deps carDBDeps) (CarDB, error) {
+	switch dbType := deps.Config.Get(DBTypeKey); dbType.String() {
+	case "", DBTypeInMemory:
+		return createInMemoryCarDB(), nil
+	case DBTypeBolt:
+		return createBoltCarDB(deps)
+	case DBTypeSQL:
+		return createSQLCarDB(deps)
+	default:
+		return nil, fmt.Errorf("unknown car db type %s", dbType.String())
+	}
+}
@@ -0,0 +1,683 @@
+package data_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/tutorial/07-makefile/app/data"
+	"github.com/go-masonry/tutorial/07-makefile/app/mortar"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+// These tests are meant to be run with the race detector
+//
+//	go test -race ./app/data/...
+const (
+	stressWorkers = 16
+	stressCars    = 256
+)
+
+// carDBSuite runs the same tests against every CarDB implementation
+type carDBSuite struct {
+	suite.Suite
+	dbType string
+	dbDir  string
+	pwd    string
+	app    *fxtest.App
+	carDB  data.CarDB
+}
+
+func TestInMemoryCarDB(t *testing.T) {
+	suite.Run(t, &carDBSuite{dbType: data.DBTypeInMemory})
+}
+
+func TestBoltCarDB(t *testing.T) {
+	suite.Run(t, &carDBSuite{dbType: data.DBTypeBolt})
+}
+
+func TestSQLCarDB(t *testing.T) {
+	suite.Run(t, &carDBSuite{dbType: data.DBTypeSQL})
+}
+
+func (s *carDBSuite) TestConcurrentInsertOfDifferentCars() {
+	s.runConcurrently(func(worker int) {
+		for i := 0; i < stressCars; i++ {
+			s.NoError(s.carDB.InsertCar(context.Background(), newCar(fmt.Sprintf("%02d-%05d", worker, i))))
+		}
+	})
+	for worker := 0; worker < stressWorkers; worker++ {
+		for i := 0; i < stressCars; i++ {
+			_, err := s.carDB.GetCar(context.Background(), fmt.Sprintf("%02d-%05d", worker, i))
+			s.NoError(err)
+		}
+	}
+}
+
+func (s *carDBSuite) TestConcurrentInsertOfSameCar() {
+	var inserted int32
+	s.runConcurrently(func(int) {
+		if err := s.carDB.InsertCar(context.Background(), newCar("same-car")); err == nil {
+			atomic.AddInt32(&inserted, 1)
+		}
+	})
+	s.Equal(int32(1), inserted)
+}
+
+func (s *carDBSuite) TestConcurrentPaintGetAndRemove() {
+	for i := 0; i < stressCars; i++ {
+		s.Require().NoError(s.carDB.InsertCar(context.Background(), newCar(fmt.Sprintf("car-%05d", i))))
+	}
+	var removed int32
+	s.runConcurrently(func(worker int) {
+		for i := 0; i < stressCars; i++ {
+			carNumber := fmt.Sprintf("car-%05d", i)
+			switch worker % 3 {
+			case 0:
+				s.carDB.SetCarState(context.Background(), carNumber, 0, data.CarPaintRequested)
+				s.carDB.PaintCar(context.Background(), carNumber, 0, fmt.Sprintf("color-%d", worker))
+			case 1:
+				if car, err := s.carDB.GetCar(context.Background(), carNumber); err == nil {
+					s.Equal(carNumber, car.CarNumber)
+				}
+			default:
+				if _, err := s.carDB.RemoveCar(context.Background(), carNumber, 0); err == nil {
+					atomic.AddInt32(&removed, 1)
+				}
+			}
+		}
+	})
+	s.Equal(int32(stressCars), removed)
+}
+
+func (s *carDBSuite) TestGetCarReturnsCopy() {
+	car := newCar("copy-car")
+	s.Require().NoError(s.carDB.InsertCar(context.Background(), car))
+	// changing the inserted entity must not affect the store
+	car.CurrentColor = "pink"
+	stored, err := s.carDB.GetCar(context.Background(), "copy-car")
+	s.Require().NoError(err)
+	s.Equal("white", stored.CurrentColor)
+	// neither should changing the returned one
+	stored.CurrentColor = "purple"
+	stored.Painted = true
+	stored, err = s.carDB.GetCar(context.Background(), "copy-car")
+	s.Require().NoError(err)
+	s.Equal("white", stored.CurrentColor)
+	s.False(stored.Painted)
+}
+
+func (s *carDBSuite) TestCarsSurviveRestart() {
+	if s.dbType == data.DBTypeInMemory {
+		s.T().Skip("in memory cars are lost on restart")
+	}
+	s.Require().NoError(s.carDB.InsertCar(context.Background(), newCar("durable-car")))
+	s.paint("durable-car", "gold")
+	// restart
+	s.app.RequireStop()
+	s.startApp()
+	car, err := s.carDB.GetCar(context.Background(), "durable-car")
+	s.Require().NoError(err)
+	s.Equal("gold", car.CurrentColor)
+	s.True(car.Painted)
+	s.Equal(data.CarPainted, car.State)
+	history, err := s.carDB.CarHistory(context.Background(), "durable-car", 0, 10)
+	s.Require().NoError(err)
+	s.Len(history, 3)
+}
+
+func (s *carDBSuite) TestErrors() {
+	s.Require().NoError(s.carDB.InsertCar(context.Background(), newCar("error-car")))
+	err := s.carDB.InsertCar(context.Background(), newCar("error-car"))
+	s.EqualError(err, "car already exists: error-car")
+	s.True(errors.Is(err, data.ErrCarAlreadyExists))
+	_, err = s.carDB.GetCar(context.Background(), "no-car")
+	s.EqualError(err, "car not found: no-car")
+	s.True(errors.Is(err, data.ErrCarNotFound))
+	_, err = s.carDB.PaintCar(context.Background(), "no-car", 0, "red")
+	s.True(errors.Is(err, data.ErrCarNotFound))
+	_, err = s.carDB.RemoveCar(context.Background(), "no-car", 0)
+	s.True(errors.Is(err, data.ErrCarNotFound))
+}
+
+func (s *carDBSuite) TestStateTransitions() {
+	ctx := context.Background()
+	car := newCar("state-car")
+	car.State = data.CarPainted // ignored
+	s.Require().NoError(s.carDB.InsertCar(ctx, car))
+	stored, err := s.carDB.GetCar(ctx, "state-car")
+	s.Require().NoError(err)
+	s.Equal(data.CarAccepted, stored.State)
+	// can't be painted before it was sent to be painted
+	_, err = s.carDB.PaintCar(ctx, "state-car", 0, "red")
+	s.EqualError(err, "illegal car state transition: state-car from ACCEPTED to PAINTED")
+	s.True(errors.Is(err, data.ErrIllegalCarTransition))
+	_, err = s.carDB.SetCarState(ctx, "state-car", 0, data.CarRetrieved)
+	s.True(errors.Is(err, data.ErrIllegalCarTransition))
+	// full lifecycle
+	for _, state := range []string{data.CarPaintRequested, data.CarPainting} {
+		stored, err = s.carDB.SetCarState(ctx, "state-car", 0, state)
+		s.Require().NoError(err)
+		s.Equal(state, stored.State)
+	}
+	_, err = s.carDB.PaintCar(ctx, "state-car", 0, "red")
+	s.Require().NoError(err)
+	stored, err = s.carDB.SetCarState(ctx, "state-car", 0, data.CarRetrieved)
+	s.Require().NoError(err)
+	s.Equal("red", stored.CurrentColor)
+	s.True(stored.Painted)
+	_, err = s.carDB.SetCarState(ctx, "state-car", 0, data.CarPaintRequested)
+	s.True(errors.Is(err, data.ErrIllegalCarTransition))
+	_, err = s.carDB.SetCarState(ctx, "no-car", 0, data.CarPaintRequested)
+	s.True(errors.Is(err, data.ErrCarNotFound))
+}
+
+func (s *carDBSuite) TestConcurrentPaintRequests() {
+	s.Require().NoError(s.carDB.InsertCar(context.Background(), newCar("requested-car")))
+	var requested int32
+	s.runConcurrently(func(int) {
+		if _, err := s.carDB.SetCarState(context.Background(), "requested-car", 0, data.CarPaintRequested); err == nil {
+			atomic.AddInt32(&requested, 1)
+		} else {
+			s.True(errors.Is(err, data.ErrIllegalCarTransition))
+		}
+	})
+	s.Equal(int32(1), requested)
+}
+
+func (s *carDBSuite) TestUpdateCar() {
+	ctx := context.Background()
+	s.Require().NoError(s.carDB.InsertCar(ctx, newCar("update-car")))
+	stored, err := s.carDB.GetCar(ctx, "update-car")
+	s.Require().NoError(err)
+	s.Equal(int64(1), stored.Version)
+
+	updated, err := s.carDB.UpdateCar(ctx, "update-car", 1, func(car *data.CarEntity) error {
+		car.Owner = "new owner"
+		car.CurrentColor = "pink" // ignored
+		return nil
+	})
+	s.Require().NoError(err)
+	s.Equal("new owner", updated.Owner)
+	s.Equal("white", updated.CurrentColor)
+	s.Equal(int64(2), updated.Version)
+	// every change moves the version
+	s.paint("update-car", "red")
+	stored, err = s.carDB.GetCar(ctx, "update-car")
+	s.Require().NoError(err)
+	s.Equal(int64(4), stored.Version)
+	s.Equal("new owner", stored.Owner)
+
+	_, err = s.carDB.UpdateCar(ctx, "update-car", 2, func(car *data.CarEntity) error {
+		car.Owner = "stale owner"
+		return nil
+	})
+	s.True(errors.Is(err, data.ErrCarChanged))
+	_, err = s.carDB.UpdateCar(ctx, "update-car", 0, func(car *data.CarEntity) error {
+		car.BodyStyle = "HATCHBACK"
+		return nil
+	})
+	s.Require().NoError(err)
+	stored, err = s.carDB.GetCar(ctx, "update-car")
+	s.Require().NoError(err)
+	s.Equal("HATCHBACK", stored.BodyStyle)
+	s.Equal("new owner", stored.Owner)
+	s.Equal(int64(5), stored.Version)
+	_, err = s.carDB.UpdateCar(ctx, "no-car", 0, func(*data.CarEntity) error { return nil })
+	s.True(errors.Is(err, data.ErrCarNotFound))
+}
+
+func (s *carDBSuite) TestConcurrentUpdates() {
+	s.Require().NoError(s.carDB.InsertCar(context.Background(), newCar("contended-car")))
+	var updated int32
+	s.runConcurrently(func(worker int) {
+		_, err := s.carDB.UpdateCar(context.Background(), "contended-car", 1, func(car *data.CarEntity) error {
+			car.Owner = fmt.Sprintf("owner-%d", worker)
+			return nil
+		})
+		if err == nil {
+			atomic.AddInt32(&updated, 1)
+		} else {
+			s.True(errors.Is(err, data.ErrCarChanged))
+		}
+	})
+	s.Equal(int32(1), updated)
+}
+
+func (s *carDBSuite) TestWritesCheckVersion() {
+	ctx := context.Background()
+	s.Require().NoError(s.carDB.InsertCar(ctx, newCar("cas-car")))
+	_, err := s.carDB.SetCarState(ctx, "cas-car", 2, data.CarPaintRequested)
+	s.True(errors.Is(err, data.ErrCarChanged))
+	car, err := s.carDB.RequestPaint(ctx, "cas-car", 1, newPaintJob("cas-message", "cas-car"), &data.OutboxEntity{ID: "cas-message", CarNumber: "cas-car", NextAttempt: time.Now()})
+	s.Require().NoError(err)
+	s.Equal(int64(2), car.Version)
+	_, err = s.carDB.PaintCar(ctx, "cas-car", 1, "red")
+	s.True(errors.Is(err, data.ErrCarChanged))
+	car, err = s.carDB.PaintCar(ctx, "cas-car", 2, "red")
+	s.Require().NoError(err)
+	s.Equal("red", car.CurrentColor)
+	s.Equal(int64(3), car.Version)
+	_, err = s.carDB.RemoveCar(ctx, "cas-car", 2)
+	s.True(errors.Is(err, data.ErrCarChanged))
+	_, err = s.carDB.GetCar(ctx, "cas-car")
+	s.NoError(err, "a car that changed isn't removed")
+	_, err = s.carDB.RemoveCar(ctx, "cas-car", 3)
+	s.NoError(err)
+}
+
+// TestNoLostUpdates reads, changes and writes back the same car from every worker, retrying on conflicts
+func (s *carDBSuite) TestNoLostUpdates() {
+	s.Require().NoError(s.carDB.InsertCar(context.Background(), newCar("counted-car")))
+	s.runConcurrently(func(int) {
+		for {
+			car, err := s.carDB.GetCar(context.Background(), "counted-car")
+			s.Require().NoError(err)
+			_, err = s.carDB.UpdateCar(context.Background(), "counted-car", car.Version, func(stored *data.CarEntity) error {
+				stored.Owner = car.Owner + "+"
+				return nil
+			})
+			if !errors.Is(err, data.ErrCarChanged) {
+				s.NoError(err)
+				return
+			}
+		}
+	})
+	car, err := s.carDB.GetCar(context.Background(), "counted-car")
+	s.Require().NoError(err)
+	s.Equal("stress owner"+strings.Repeat("+", stressWorkers), car.Owner)
+	s.Equal(int64(1+stressWorkers), car.Version)
+}
+
+func (s *carDBSuite) TestRetrieveCar() {
+	ctx := context.Background()
+	s.Require().NoError(s.carDB.InsertCar(ctx, newCar("retrieve-car")))
+	_, err := s.carDB.RetrieveCar(ctx, "retrieve-car", 0)
+	s.True(errors.Is(err, data.ErrIllegalCarTransition))
+	_, err = s.carDB.SetCarState(ctx, "retrieve-car", 0, data.CarPaintRequested)
+	s.Require().NoError(err)
+	painted, err := s.carDB.PaintCar(ctx, "retrieve-car", 0, "red")
+	s.Require().NoError(err)
+	_, err = s.carDB.RetrieveCar(ctx, "retrieve-car", painted.Version-1)
+	s.True(errors.Is(err, data.ErrCarChanged))
+	_, err = s.carDB.GetCar(ctx, "retrieve-car")
+	s.NoError(err, "a failed retrieve leaves the car")
+
+	retrieved, err := s.carDB.RetrieveCar(ctx, "retrieve-car", painted.Version)
+	s.Require().NoError(err)
+	s.Equal(data.CarRetrieved, retrieved.State)
+	s.Equal("red", retrieved.CurrentColor)
+	s.Equal(painted.Version+1, retrieved.Version)
+	_, err = s.carDB.GetCar(ctx, "retrieve-car")
+	s.True(errors.Is(err, data.ErrCarNotFound))
+	_, err = s.carDB.RetrieveCar(ctx, "retrieve-car", 0)
+	s.True(errors.Is(err, data.ErrCarNotFound))
+	history, err := s.carDB.CarHistory(ctx, "retrieve-car", 0, 10)
+	s.Require().NoError(err)
+	s.Require().Len(history, 4)
+	s.Equal(data.CarPainted, history[3].OldState)
+	s.Equal(data.CarRetrieved, history[3].NewState)
+	s.Equal(retrieved.Version, history[3].Version)
+}
+
+func (s *carDBSuite) TestCarHistory() {
+	ctx := data.WithChangeSource(context.Background(), data.ChangeSource{Actor: "alice", TraceID: "trace-1"})
+	s.Require().NoError(s.carDB.InsertCar(ctx, newCar("history-car")))
+	s.Require().NoError(s.carDB.InsertCar(ctx, newCar("other-history-car")))
+	_, err := s.carDB.RequestPaint(ctx, "history-car", 0, newPaintJob("history-message", "history-car"), &data.OutboxEntity{ID: "history-message", CarNumber: "history-car", NextAttempt: time.Now()})
+	s.Require().NoError(err)
+	// changes that leave the state as is aren't history
+	_, err = s.carDB.UpdateCar(ctx, "history-car", 0, func(car *data.CarEntity) error {
+		car.Owner = "bob"
+		return nil
+	})
+	s.Require().NoError(err)
+	painter := data.WithChangeSource(context.Background(), data.ChangeSource{Actor: "workshop", SubWorkshop: "east"})
+	_, err = s.carDB.SetCarState(painter, "history-car", 0, data.CarPainting)
+	s.Require().NoError(err)
+	_, err = s.carDB.PaintCar(painter, "history-car", 0, "red")
+	s.Require().NoError(err)
+	_, err = s.carDB.SetCarState(painter, "history-car", 0, data.CarAccepted) // illegal, not saved
+	s.True(errors.Is(err, data.ErrIllegalCarTransition))
+	_, err = s.carDB.RemoveCar(ctx, "history-car", 0)
+	s.Require().NoError(err)
+
+	history, err := s.carDB.CarHistory(ctx, "history-car", 0, 10)
+	s.Require().NoError(err)
+	s.Require().Len(history, 4)
+	expected := []struct {
+		oldState, newState, oldColor, newColor, actor, subWorkshop string
+		version                                                    int64
+	}{
+		{"", data.CarAccepted, "", "white", "alice", "", 1},
+		{data.CarAccepted, data.CarPaintRequested, "white", "white", "alice", "", 2},
+		{data.CarPaintRequested, data.CarPainting, "white", "white", "workshop", "east", 4},
+		{data.CarPainting, data.CarPainted, "white", "red", "workshop", "east", 5},
+	}
+	for i, change := range history {
+		s.Equal("history-car", change.CarNumber)
+		s.Equal(expected[i].oldState, change.OldState)
+		s.Equal(expected[i].newState, change.NewState)
+		s.Equal(expected[i].oldColor, change.OldColor)
+		s.Equal(expected[i].newColor, change.NewColor)
+		s.Equal(expected[i].actor, change.Actor)
+		s.Equal(expected[i].subWorkshop, change.SubWorkshop)
+		s.Equal(expected[i].version, change.Version)
+		s.WithinDuration(time.Now(), change.Time, time.Minute)
+		if i > 0 {
+			s.Greater(change.Sequence, history[i-1].Sequence)
+		}
+	}
+	s.Equal("trace-1", history[0].TraceID)
+	s.Empty(history[2].TraceID)
+
+	// pages
+	page, err := s.carDB.CarHistory(ctx, "history-car", 0, 3)
+	s.Require().NoError(err)
+	s.Equal(history[:3], page)
+	page, err = s.carDB.CarHistory(ctx, "history-car", page[2].Sequence, 3)
+	s.Require().NoError(err)
+	s.Equal(history[3:], page)
+
+	other, err := s.carDB.CarHistory(ctx, "other-history-car", 0, 10)
+	s.Require().NoError(err)
+	s.Len(other, 1)
+	none, err := s.carDB.CarHistory(ctx, "no-car", 0, 10)
+	s.NoError(err)
+	s.Empty(none)
+}
+
+func (s *carDBSuite) TestListCars() {
+	ctx := context.Background()
+	for i, bodyStyle := range []string{"SEDAN", "PHAETON", "HATCHBACK", "SEDAN", "PHAETON"} {
+		car := newCar(fmt.Sprintf("list-car-%d", i))
+		car.BodyStyle = bodyStyle
+		car.Owner = []string{"alice", "bob"}[i%2]
+		s.Require().NoError(s.carDB.InsertCar(ctx, car))
+	}
+	s.paint("list-car-3", "red")
+
+	carNumbers := func(cars []*data.CarEntity) (numbers []string) {
+		for _, car := range cars {
+			numbers = append(numbers, car.CarNumber)
+		}
+		return
+	}
+	cars, err := s.carDB.ListCars(ctx, data.CarFilter{}, "", 10)
+	s.Require().NoError(err)
+	s.Equal([]string{"list-car-0", "list-car-1", "list-car-2", "list-car-3", "list-car-4"}, carNumbers(cars))
+	// pages
+	cars, err = s.carDB.ListCars(ctx, data.CarFilter{}, "", 2)
+	s.Require().NoError(err)
+	s.Equal([]string{"list-car-0", "list-car-1"}, carNumbers(cars))
+	cars, err = s.carDB.ListCars(ctx, data.CarFilter{}, "list-car-1", 2)
+	s.Require().NoError(err)
+	s.Equal([]string{"list-car-2", "list-car-3"}, carNumbers(cars))
+	cars, err = s.carDB.ListCars(ctx, data.CarFilter{}, "list-car-4", 2)
+	s.Require().NoError(err)
+	s.Empty(cars)
+	// filters
+	cars, err = s.carDB.ListCars(ctx, data.CarFilter{Owner: "alice"}, "", 10)
+	s.Require().NoError(err)
+	s.Equal([]string{"list-car-0", "list-car-2", "list-car-4"}, carNumbers(cars))
+	cars, err = s.carDB.ListCars(ctx, data.CarFilter{BodyStyles: []string{"PHAETON", "HATCHBACK"}}, "", 10)
+	s.Require().NoError(err)
+	s.Equal([]string{"list-car-1", "list-car-2", "list-car-4"}, carNumbers(cars))
+	painted := true
+	cars, err = s.carDB.ListCars(ctx, data.CarFilter{Painted: &painted}, "", 10)
+	s.Require().NoError(err)
+	s.Equal([]string{"list-car-3"}, carNumbers(cars))
+	s.True(cars[0].Painted)
+	cars, err = s.carDB.ListCars(ctx, data.CarFilter{CurrentColor: "white", Owner: "bob", BodyStyles: []string{"SEDAN"}}, "", 10)
+	s.Require().NoError(err)
+	s.Empty(cars)
+}
+
+func (s *carDBSuite) TestRequestPaintAddsToOutbox() {
+	ctx := context.Background()
+	s.Require().NoError(s.carDB.InsertCar(ctx, newCar("outbox-car")))
+	now := time.Now()
+	deadline := now.Add(time.Hour).UTC().Truncate(time.Millisecond)
+	message := &data.OutboxEntity{ID: "message-1", CarNumber: "outbox-car", DesiredColor: "red", PaintJobID: "job-1", NextAttempt: now, Deadline: deadline}
+	car, err := s.carDB.RequestPaint(ctx, "outbox-car", 0, newPaintJob("job-1", "outbox-car"), message)
+	s.Require().NoError(err)
+	s.Equal(data.CarPaintRequested, car.State)
+	// the car can't be requested again, nothing is added to the outbox
+	_, err = s.carDB.RequestPaint(ctx, "outbox-car", 0, newPaintJob("message-2", "outbox-car"), &data.OutboxEntity{ID: "message-2", CarNumber: "outbox-car", NextAttempt: now})
+	s.True(errors.Is(err, data.ErrIllegalCarTransition))
+	_, err = s.carDB.RequestPaint(ctx, "no-car", 0, newPaintJob("message-3", "no-car"), &data.OutboxEntity{ID: "message-3", CarNumber: "no-car", NextAttempt: now})
+	s.True(errors.Is(err, data.ErrCarNotFound))
+	for _, id := range []string{"message-2", "message-3"} {
+		_, err = s.carDB.GetPaintJob(ctx, id)
+		s.True(errors.Is(err, data.ErrPaintJobNotFound), "job of a failed request must not be saved")
+	}
+	pending, err := s.carDB.PendingOutbox(ctx, now, 10)
+	s.Require().NoError(err)
+	s.Require().Len(pending, 1)
+	s.Equal("message-1", pending[0].ID)
+	s.Equal("outbox-car", pending[0].CarNumber)
+	s.Equal("red", pending[0].DesiredColor)
+	s.Equal("job-1", pending[0].PaintJobID)
+	s.True(deadline.Equal(pending[0].Deadline), "deadline %s, not %s", deadline, pending[0].Deadline)
+	// failed delivery is retried later
+	pending[0].Attempts = 1
+	pending[0].LastError = "sub workshop is down"
+	pending[0].NextAttempt = now.Add(time.Minute)
+	s.Require().NoError(s.carDB.RescheduleOutbox(ctx, pending[0]))
+	pending, err = s.carDB.PendingOutbox(ctx, now, 10)
+	s.Require().NoError(err)
+	s.Empty(pending)
+	pending, err = s.carDB.PendingOutbox(ctx, now.Add(time.Minute), 10)
+	s.Require().NoError(err)
+	s.Require().Len(pending, 1)
+	s.Equal(1, pending[0].Attempts)
+	s.Equal("sub workshop is down", pending[0].LastError)
+	s.True(deadline.Equal(pending[0].Deadline))
+	// delivered
+	s.Require().NoError(s.carDB.RemoveOutbox(ctx, "message-1"))
+	s.Require().NoError(s.carDB.RemoveOutbox(ctx, "message-1"))
+	pending, err = s.carDB.PendingOutbox(ctx, now.Add(time.Minute), 10)
+	s.Require().NoError(err)
+	s.Empty(pending)
+}
+
+func (s *carDBSuite) TestOutboxSurvivesRestart() {
+	if s.dbType == data.DBTypeInMemory {
+		s.T().Skip("in memory outbox is lost on restart")
+	}
+	ctx := context.Background()
+	s.Require().NoError(s.carDB.InsertCar(ctx, newCar("durable-outbox-car")))
+	_, err := s.carDB.RequestPaint(ctx, "durable-outbox-car", 0, newPaintJob("durable-message", "durable-outbox-car"), &data.OutboxEntity{ID: "durable-message", CarNumber: "durable-outbox-car", NextAttempt: time.Now()})
+	s.Require().NoError(err)
+	// restart
+	s.app.RequireStop()
+	s.startApp()
+	pending, err := s.carDB.PendingOutbox(ctx, time.Now(), 10)
+	s.Require().NoError(err)
+	s.Require().Len(pending, 1)
+	s.Equal("durable-message", pending[0].ID)
+}
+
+func (s *carDBSuite) TestPaintJobs() {
+	ctx := context.Background()
+	for i, carNumber := range []string{"job-car-1", "job-car-2", "job-car-3"} {
+		s.Require().NoError(s.carDB.InsertCar(ctx, newCar(carNumber)))
+		id := fmt.Sprintf("job-%d", i)
+		_, err := s.carDB.RequestPaint(ctx, carNumber, 0, newPaintJob(id, carNumber), &data.OutboxEntity{ID: id, CarNumber: carNumber, NextAttempt: time.Now()})
+		s.Require().NoError(err)
+	}
+	job, err := s.carDB.GetPaintJob(ctx, "job-1")
+	s.Require().NoError(err)
+	s.Equal("job-car-2", job.CarNumber)
+	s.Equal("red", job.DesiredColor)
+	s.Equal(data.PaintJobQueued, job.Status)
+	s.True(job.EndTime.IsZero())
+
+	endTime := time.Now().UTC()
+	updated, err := s.carDB.UpdatePaintJob(ctx, "job-1", func(job *data.PaintJobEntity) error {
+		job.Status = data.PaintJobFailed
+		job.Error = "no paint"
+		job.SubWorkshop = "east"
+		job.EndTime = endTime
+		return nil
+	})
+	s.Require().NoError(err)
+	s.Equal(data.PaintJobFailed, updated.Status)
+	_, err = s.carDB.UpdatePaintJob(ctx, "job-0", func(job *data.PaintJobEntity) error {
+		job.Status = data.PaintJobRunning
+		return nil
+	})
+	s.Require().NoError(err)
+	// a done job is never delivered
+	pending, err := s.carDB.PendingOutbox(ctx, time.Now(), 10)
+	s.Require().NoError(err)
+	s.Equal([]string{"job-0", "job-2"}, outboxIDs(pending))
+	_, err = s.carDB.UpdatePaintJob(ctx, "job-1", func(job *data.PaintJobEntity) error {
+		job.Status = data.PaintJobSucceeded
+		return errors.New("job is done")
+	})
+	s.EqualError(err, "job is done")
+	job, err = s.carDB.GetPaintJob(ctx, "job-1")
+	s.Require().NoError(err)
+	s.Equal(data.PaintJobFailed, job.Status)
+	s.Equal("no paint", job.Error)
+	s.Equal("east", job.SubWorkshop)
+	s.True(endTime.Equal(job.EndTime))
+	_, err = s.carDB.UpdatePaintJob(ctx, "no-job", func(*data.PaintJobEntity) error { return nil })
+	s.True(errors.Is(err, data.ErrPaintJobNotFound))
+	_, err = s.carDB.GetPaintJob(ctx, "no-job")
+	s.True(errors.Is(err, data.ErrPaintJobNotFound))
+
+	jobs, err := s.carDB.ListPaintJobs(ctx, "", "", 2)
+	s.Require().NoError(err)
+	s.Equal([]string{"job-0", "job-1"}, paintJobIDs(jobs))
+	jobs, err = s.carDB.ListPaintJobs(ctx, "", "job-1", 2)
+	s.Require().NoError(err)
+	s.Equal([]string{"job-2"}, paintJobIDs(jobs))
+	jobs, err = s.carDB.ListPaintJobs(ctx, "job-car-3", "", 10)
+	s.Require().NoError(err)
+	s.Equal([]string{"job-2"}, paintJobIDs(jobs))
+}
+
+func (s *carDBSuite) TestPaintJobsSurviveRestart() {
+	if s.dbType == data.DBTypeInMemory {
+		s.T().Skip("in memory paint jobs are lost on restart")
+	}
+	ctx := context.Background()
+	s.Require().NoError(s.carDB.InsertCar(ctx, newCar("durable-job-car")))
+	_, err := s.carDB.RequestPaint(ctx, "durable-job-car", 0, newPaintJob("durable-job", "durable-job-car"), &data.OutboxEntity{ID: "durable-job", CarNumber: "durable-job-car", NextAttempt: time.Now()})
+	s.Require().NoError(err)
+	// restart
+	s.app.RequireStop()
+	s.startApp()
+	job, err := s.carDB.GetPaintJob(ctx, "durable-job")
+	s.Require().NoError(err)
+	s.Equal("durable-job-car", job.CarNumber)
+	s.Equal(data.PaintJobQueued, job.Status)
+}
+
+func (s *carDBSuite) SetupSuite() {
+	var err error
+	s.pwd, err = os.Getwd()
+	s.Require().NoError(err)
+}
+
+func (s *carDBSuite) SetupTest() {
+	var err error
+	s.dbDir, err = ioutil.TempDir("", "cardb")
+	s.Require().NoError(err)
+	s.startApp()
+}
+
+func (s *carDBSuite) startApp() {
+	s.app = fxtest.New(s.T(),
+		fx.NopLogger, // remove fx debug prints
+		mortar.ViperFxOption(s.pwd+"/../../config/config.yml", s.pwd+"/../../config/config_test.yml"),
+		mortar.LoggerFxOption(),
+		fx.Invoke(func(config cfg.Config) {
+			config.Set(data.DBTypeKey, s.dbType)
+			config.Set(data.DBBoltPathKey, filepath.Join(s.dbDir, "cars.db"))
+			config.Set(data.DBSQLDSNKey, "file:"+filepath.Join(s.dbDir, "cars.sqlite.db"))
+		}),
+		fx.Provide(data.CreateCarDB),
+		fx.Populate(&s.carDB),
+	)
+	s.app.RequireStart()
+}
+
+func (s *carDBSuite) TearDownTest() {
+	s.app.RequireStop()
+	os.RemoveAll(s.dbDir)
+}
+
+func (s *carDBSuite) runConcurrently(work func(worker int)) {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for worker := 0; worker < stressWorkers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			<-start
+			work(worker)
+		}(worker)
+	}
+	close(start)
+	wg.Wait()
+}
+
+// paint moves an accepted car through the lifecycle until it's painted
+func (s *carDBSuite) paint(carNumber, color string) {
+	_, err := s.carDB.SetCarState(context.Background(), carNumber, 0, data.CarPaintRequested)
+	s.Require().NoError(err)
+	_, err = s.carDB.PaintCar(context.Background(), carNumber, 0, color)
+	s.Require().NoError(err)
+}
+
+func newCar(carNumber string) *data.CarEntity {
+	return &data.CarEntity{
+		CarNumber:     carNumber,
+		Owner:         "stress owner",
+		BodyStyle:     "SEDAN",
+		OriginalColor: "white",
+		CurrentColor:  "white",
+	}
+}
+
+func newPaintJob(id, carNumber string) *data.PaintJobEntity {
+	now := time.Now().UTC()
+	return &data.PaintJobEntity{
+		ID:           id,
+		CarNumber:    carNumber,
+		DesiredColor: "red",
+		Status:       data.PaintJobQueued,
+		CreateTime:   now,
+		UpdateTime:   now,
+	}
+}
+
+func paintJobIDs(jobs []*data.PaintJobEntity) []string {
+	var ids []string
+	for _, job := range jobs {
+		ids = append(ids, job.ID)
+	}
+	return ids
+}
+
+func outboxIDs(messages []*data.OutboxEntity) []string {
+	var ids []string
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
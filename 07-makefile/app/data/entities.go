@@ -1,5 +1,40 @@
 package data
 
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Car lifecycle states
+const (
+	CarAccepted       = "ACCEPTED"
+	CarPaintRequested = "PAINT_REQUESTED"
+	CarPainting       = "PAINTING"
+	CarPainted        = "PAINTED"
+	CarRetrieved      = "RETRIEVED"
+)
+
+// carTransitions lists the states a car may move to from every state.
+// Going back from PAINT_REQUESTED/PAINTING to ACCEPTED or PAINTED undoes a failed or cancelled paint request.
+// The sub workshop may call back before we know it started painting, hence PAINT_REQUESTED -> PAINTED.
+var carTransitions = map[string][]string{
+	CarAccepted:       {CarPaintRequested},
+	CarPaintRequested: {CarPainting, CarPainted, CarAccepted},
+	CarPainting:       {CarPainted, CarAccepted},
+	CarPainted:        {CarPaintRequested, CarRetrieved},
+}
+
+// CanTransitionCar tells if a car may move from one state to another
+func CanTransitionCar(from, to string) bool {
+	for _, allowed := range carTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
 // CarEntity is our internal representation of the car
 type CarEntity struct {
 	CarNumber     string
@@ -7,5 +42,151 @@ type CarEntity struct {
 	BodyStyle     string
 	OriginalColor string
 	CurrentColor  string
-	Painted       bool
+	// Painted is true once the car was painted, it stays true when the car is sent to be painted again
+	Painted bool
+	State   string
+	// Version starts at 1 when the car is inserted and grows with every change, it's the etag of the car
+	Version int64
+}
+
+// transition moves the car to state, the store must save it afterwards
+func (c *CarEntity) transition(state string) error {
+	if !CanTransitionCar(c.State, state) {
+		return fmt.Errorf("%w: %s from %s to %s", ErrIllegalCarTransition, c.CarNumber, c.State, state)
+	}
+	c.State = state
+	c.Version++
+	return nil
+}
+
+// paint moves the car to CarPainted with its new color
+func (c *CarEntity) paint(newColor string) error {
+	if err := c.transition(CarPainted); err != nil {
+		return err
+	}
+	c.CurrentColor = newColor
+	c.Painted = true
+	return nil
+}
+
+// At checks that the car is still at version, 0 matches every version
+func (c *CarEntity) At(version int64) error {
+	if version != 0 && version != c.Version {
+		return fmt.Errorf("%w: %s is at version %d, not %d", ErrCarChanged, c.CarNumber, c.Version, version)
+	}
+	return nil
+}
+
+// update lets edit change the owner and body style of the car, nothing else is kept
+func (c *CarEntity) update(edit func(car *CarEntity) error) error {
+	edited := c.copy()
+	if err := edit(edited); err != nil {
+		return err
+	}
+	c.Owner = edited.Owner
+	c.BodyStyle = edited.BodyStyle
+	c.Version++
+	return nil
+}
+
+func (c *CarEntity) copy() *CarEntity {
+	clone := *c
+	return &clone
+}
+
+// CarHistoryEntity is a change of the state of a car, the history of a car is kept after it's removed
+type CarHistoryEntity struct {
+	// Sequence orders the history of all cars, it grows with every change
+	Sequence  int64
+	CarNumber string
+	// Version of the car after the change
+	Version     int64
+	Time        time.Time
+	Actor       string
+	SubWorkshop string
+	TraceID     string
+	// OldState is empty when the car was inserted
+	OldState string
+	NewState string
+	OldColor string
+	NewColor string
+}
+
+// ChangeSource tells who made the changes of a request, it's saved in the history of the cars the request changes
+type ChangeSource struct {
+	// Actor is the subject of the caller, or the workshop itself for changes it makes in the background
+	Actor string
+	// SubWorkshop is the name of the sub workshop that paints the car
+	SubWorkshop string
+	TraceID     string
+}
+
+type changeSourceKey struct{}
+
+// WithChangeSource returns a context whose changes are saved as made by source
+func WithChangeSource(ctx context.Context, source ChangeSource) context.Context {
+	return context.WithValue(ctx, changeSourceKey{}, source)
+}
+
+// newCarHistory describes the change from before, nil when the car is inserted, to after
+func newCarHistory(ctx context.Context, before, after *CarEntity) *CarHistoryEntity {
+	source, _ := ctx.Value(changeSourceKey{}).(ChangeSource)
+	history := &CarHistoryEntity{
+		CarNumber:   after.CarNumber,
+		Version:     after.Version,
+		Time:        time.Now().UTC(),
+		Actor:       source.Actor,
+		SubWorkshop: source.SubWorkshop,
+		TraceID:     source.TraceID,
+		NewState:    after.State,
+		NewColor:    after.CurrentColor,
+	}
+	if before != nil {
+		history.OldState = before.State
+		history.OldColor = before.CurrentColor
+	}
+	return history
+}
+
+func (h *CarHistoryEntity) copy() *CarHistoryEntity {
+	clone := *h
+	return &clone
+}
+
+// Paint job statuses, a job starts QUEUED and ends in one of the final statuses
+const (
+	PaintJobQueued    = "QUEUED"
+	PaintJobRunning   = "RUNNING"
+	PaintJobSucceeded = "SUCCEEDED"
+	PaintJobFailed    = "FAILED"
+	PaintJobCancelled = "CANCELLED"
+)
+
+// PaintJobEntity is our internal representation of a request to paint a car
+type PaintJobEntity struct {
+	ID           string
+	CarNumber    string
+	DesiredColor string
+	Status       string
+	Error        string
+	// SubWorkshop is the name of the sub workshop the job was sent to
+	SubWorkshop string
+	CreateTime  time.Time
+	UpdateTime  time.Time
+	EndTime     time.Time
+}
+
+// Done is true once the job reached a final status
+func (j *PaintJobEntity) Done() bool {
+	switch j.Status {
+	case PaintJobSucceeded, PaintJobFailed, PaintJobCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+func (j *PaintJobEntity) copy() *PaintJobEntity {
+	clone := *j
+	return &clone
 }
@@ -0,0 +1,31 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrCarNotFound is returned when there is no car with the requested number
+	ErrCarNotFound = errors.New("car not found")
+	// ErrCarAlreadyExists is returned when inserting a car with a number that is already taken
+	ErrCarAlreadyExists = errors.New("car already exists")
+	// ErrIllegalCarTransition is returned when a car can't move from its current state to the requested one
+	ErrIllegalCarTransition = errors.New("illegal car state transition")
+	// ErrCarChanged is returned when a car is updated at a version it's no longer at
+	ErrCarChanged = errors.New("car changed")
+	// ErrPaintJobNotFound is returned when there is no paint job with the requested id
+	ErrPaintJobNotFound = errors.New("paint job not found")
+)
+
+func carNotFound(carNumber string) error {
+	return fmt.Errorf("%w: %s", ErrCarNotFound, carNumber)
+}
+
+func carAlreadyExists(carNumber string) error {
+	return fmt.Errorf("%w: %s", ErrCarAlreadyExists, carNumber)
+}
+
+func paintJobNotFound(id string) error {
+	return fmt.Errorf("%w: %s", ErrPaintJobNotFound, id)
+}
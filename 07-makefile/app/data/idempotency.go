@@ -0,0 +1,88 @@
+package data
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// IdempotencyEntity is a request made with an idempotency key and, once it succeeded, its response
+type IdempotencyEntity struct {
+	Key         string
+	RequestHash []byte
+	// Response is empty until the request succeeds
+	Response   []byte
+	Done       bool
+	ExpireTime time.Time
+}
+
+// IdempotencyDB stores requests made with idempotency keys until they expire
+type IdempotencyDB interface {
+	// Reserve stores record unless an unexpired one with the same key exists, which is returned instead
+	Reserve(ctx context.Context, record *IdempotencyEntity) (existing *IdempotencyEntity, err error)
+	// Complete saves the response of the reserved key
+	Complete(ctx context.Context, key string, response []byte) error
+	// Release removes the reserved key, the request can be made again
+	Release(ctx context.Context, key string) error
+}
+
+// CreateIdempotencyDB is a constructor for Fx, records are kept in memory and lost on restart
+func CreateIdempotencyDB() IdempotencyDB {
+	return &inMemoryIdempotencyDB{records: make(map[string]*IdempotencyEntity)}
+}
+
+type inMemoryIdempotencyDB struct {
+	sync.Mutex
+	records map[string]*IdempotencyEntity
+	order   []*IdempotencyEntity // by expiry, records expire in the order they are reserved
+}
+
+func (i *inMemoryIdempotencyDB) Reserve(ctx context.Context, record *IdempotencyEntity) (*IdempotencyEntity, error) {
+	i.Lock()
+	defer i.Unlock()
+	i.removeExpired(time.Now())
+	if existing, exists := i.records[record.Key]; exists {
+		return existing.copy(), nil
+	}
+	stored := record.copy()
+	i.records[record.Key] = stored
+	i.order = append(i.order, stored)
+	return nil, nil
+}
+
+func (i *inMemoryIdempotencyDB) Complete(ctx context.Context, key string, response []byte) error {
+	i.Lock()
+	defer i.Unlock()
+	if record, exists := i.records[key]; exists {
+		record.Response = append([]byte(nil), response...)
+		record.Done = true
+	}
+	return nil
+}
+
+func (i *inMemoryIdempotencyDB) Release(ctx context.Context, key string) error {
+	i.Lock()
+	defer i.Unlock()
+	// the record stays in order until it expires, it's skipped then since it's no longer the one in records
+	delete(i.records, key)
+	return nil
+}
+
+// removeExpired must be called with the lock held
+func (i *inMemoryIdempotencyDB) removeExpired(now time.Time) {
+	expired := 0
+	for ; expired < len(i.order) && !now.Before(i.order[expired].ExpireTime); expired++ {
+		if record := i.order[expired]; i.records[record.Key] == record {
+			delete(i.records, record.Key)
+		}
+		i.order[expired] = nil
+	}
+	i.order = i.order[expired:]
+}
+
+func (e *IdempotencyEntity) copy() *IdempotencyEntity {
+	c := *e
+	c.RequestHash = append([]byte(nil), e.RequestHash...)
+	c.Response = append([]byte(nil), e.Response...)
+	return &c
+}
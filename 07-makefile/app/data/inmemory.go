@@ -2,63 +2,253 @@ package data
 
 import (
 	"context"
-	"fmt"
-
-	"go.uber.org/fx"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// This interface will represent our car db
-type CarDB interface {
-	InsertCar(ctx context.Context, car *CarEntity) error
-	PaintCar(ctx context.Context, carNumber string, newColor string) error
-	GetCar(ctx context.Context, carNumber string) (*CarEntity, error)
-	RemoveCar(ctx context.Context, carNumber string) (*CarEntity, error)
+// Number of independently locked shards, cars are spread between them by their car number
+const carDBShards = 32
+
+func createInMemoryCarDB() CarDB {
+	db := &inMemoryCarDB{outbox: make(map[string]*OutboxEntity)}
+	db.jobs = make(map[string]*PaintJobEntity)
+	for i := range db.shards {
+		db.shards[i] = &carDBShard{cars: make(map[string]*CarEntity), history: make(map[string][]*CarHistoryEntity)}
+	}
+	return db
 }
 
-type carDBDeps struct {
-	fx.In
+// inMemoryCarDB is safe for concurrent use, every shard is guarded by its own lock.
+// Entities are copied on the way in and on the way out, callers never share memory with the store.
+// The paint jobs and the outbox have locks of their own, they are taken while holding a shard lock and never the
+// other way around. The outbox lock is also taken while holding the paint jobs lock.
+type inMemoryCarDB struct {
+	shards          [carDBShards]*carDBShard
+	historySequence int64 // atomic
+	inMemoryPaintJobs
+
+	outboxLock sync.Mutex
+	outbox     map[string]*OutboxEntity
 }
 
-func CreateCarDB(deps carDBDeps) CarDB {
-	return &carDB{
-		deps: deps,
-		cars: make(map[string]*CarEntity),
-	}
+type carDBShard struct {
+	sync.RWMutex
+	cars    map[string]*CarEntity
+	history map[string][]*CarHistoryEntity
 }
 
-type carDB struct {
-	deps carDBDeps
-	cars map[string]*CarEntity
+func (c *inMemoryCarDB) shard(carNumber string) *carDBShard {
+	hash := fnv.New32a()
+	hash.Write([]byte(carNumber))
+	return c.shards[hash.Sum32()%carDBShards]
 }
 
-func (c *carDB) InsertCar(ctx context.Context, car *CarEntity) error {
-	if _, exists := c.cars[car.CarNumber]; exists {
-		return fmt.Errorf("car %s already exists", car.CarNumber)
+func (c *inMemoryCarDB) InsertCar(ctx context.Context, car *CarEntity) error {
+	shard := c.shard(car.CarNumber)
+	shard.Lock()
+	defer shard.Unlock()
+	if _, exists := shard.cars[car.CarNumber]; exists {
+		return carAlreadyExists(car.CarNumber)
 	}
-	c.cars[car.CarNumber] = car
+	stored := car.copy()
+	stored.State = CarAccepted
+	stored.Version = 1
+	shard.cars[car.CarNumber] = stored
+	c.addHistory(shard, newCarHistory(ctx, nil, stored))
 	return nil
 }
 
-func (c *carDB) PaintCar(ctx context.Context, carNumber string, newColor string) error {
-	if car, exists := c.cars[carNumber]; exists {
-		car.CurrentColor = newColor
-		car.Painted = true
+func (c *inMemoryCarDB) PaintCar(ctx context.Context, carNumber string, version int64, newColor string) (*CarEntity, error) {
+	return c.write(ctx, carNumber, version, func(car *CarEntity) error {
+		return car.paint(newColor)
+	})
+}
+
+func (c *inMemoryCarDB) SetCarState(ctx context.Context, carNumber string, version int64, state string) (*CarEntity, error) {
+	return c.write(ctx, carNumber, version, func(car *CarEntity) error {
+		return car.transition(state)
+	})
+}
+
+func (c *inMemoryCarDB) RequestPaint(ctx context.Context, carNumber string, version int64, job *PaintJobEntity, message *OutboxEntity) (*CarEntity, error) {
+	return c.write(ctx, carNumber, version, func(car *CarEntity) error {
+		if err := car.transition(CarPaintRequested); err != nil {
+			return err
+		}
+		c.jobsLock.Lock()
+		c.insertPaintJob(job)
+		c.jobsLock.Unlock()
+		c.outboxLock.Lock()
+		c.outbox[message.ID] = message.copy()
+		c.outboxLock.Unlock()
+		return nil
+	})
+}
+
+func (c *inMemoryCarDB) UpdatePaintJob(ctx context.Context, id string, update func(job *PaintJobEntity) error) (*PaintJobEntity, error) {
+	return c.inMemoryPaintJobs.UpdatePaintJob(ctx, id, func(job *PaintJobEntity) error {
+		if err := update(job); err != nil {
+			return err
+		}
+		if job.Done() {
+			c.outboxLock.Lock()
+			delete(c.outbox, id)
+			c.outboxLock.Unlock()
+		}
 		return nil
+	})
+}
+
+func (c *inMemoryCarDB) UpdateCar(ctx context.Context, carNumber string, version int64, edit func(car *CarEntity) error) (*CarEntity, error) {
+	return c.write(ctx, carNumber, version, func(car *CarEntity) error {
+		return car.update(edit)
+	})
+}
+
+// write changes the stored car with change if it's still at version, change must leave the car as is when it fails
+func (c *inMemoryCarDB) write(ctx context.Context, carNumber string, version int64, change func(car *CarEntity) error) (*CarEntity, error) {
+	shard := c.shard(carNumber)
+	shard.Lock()
+	defer shard.Unlock()
+	car, exists := shard.cars[carNumber]
+	if !exists {
+		return nil, carNotFound(carNumber)
+	}
+	if err := car.At(version); err != nil {
+		return nil, err
+	}
+	before := car.copy()
+	if err := change(car); err != nil {
+		return nil, err
+	}
+	if car.State != before.State {
+		c.addHistory(shard, newCarHistory(ctx, before, car))
+	}
+	return car.copy(), nil
+}
+
+// addHistory must be called with the shard lock held
+func (c *inMemoryCarDB) addHistory(shard *carDBShard, history *CarHistoryEntity) {
+	history.Sequence = atomic.AddInt64(&c.historySequence, 1)
+	shard.history[history.CarNumber] = append(shard.history[history.CarNumber], history)
+}
+
+func (c *inMemoryCarDB) GetCar(ctx context.Context, carNumber string) (*CarEntity, error) {
+	shard := c.shard(carNumber)
+	shard.RLock()
+	defer shard.RUnlock()
+	if car, exists := shard.cars[carNumber]; exists {
+		return car.copy(), nil
+	}
+	return nil, carNotFound(carNumber)
+}
+
+func (c *inMemoryCarDB) RemoveCar(ctx context.Context, carNumber string, version int64) (*CarEntity, error) {
+	shard := c.shard(carNumber)
+	shard.Lock()
+	defer shard.Unlock()
+	car, exists := shard.cars[carNumber]
+	if !exists {
+		return nil, carNotFound(carNumber)
+	}
+	if err := car.At(version); err != nil {
+		return nil, err
+	}
+	delete(shard.cars, carNumber)
+	return car.copy(), nil
+}
+
+func (c *inMemoryCarDB) RetrieveCar(ctx context.Context, carNumber string, version int64) (*CarEntity, error) {
+	shard := c.shard(carNumber)
+	shard.Lock()
+	defer shard.Unlock()
+	car, exists := shard.cars[carNumber]
+	if !exists {
+		return nil, carNotFound(carNumber)
+	}
+	if err := car.At(version); err != nil {
+		return nil, err
 	}
-	return fmt.Errorf("unknown car ID %s", carNumber)
+	retrieved := car.copy()
+	if err := retrieved.transition(CarRetrieved); err != nil {
+		return nil, err
+	}
+	delete(shard.cars, carNumber)
+	c.addHistory(shard, newCarHistory(ctx, car, retrieved))
+	return retrieved, nil
 }
 
-func (c *carDB) GetCar(ctx context.Context, carNumber string) (*CarEntity, error) {
-	if car, exists := c.cars[carNumber]; exists {
-		return car, nil
+func (c *inMemoryCarDB) ListCars(ctx context.Context, filter CarFilter, afterCarNumber string, limit int) ([]*CarEntity, error) {
+	var cars []*CarEntity
+	for _, shard := range c.shards {
+		shard.RLock()
+		for carNumber, car := range shard.cars {
+			if carNumber > afterCarNumber && filter.matches(car) {
+				cars = append(cars, car.copy())
+			}
+		}
+		shard.RUnlock()
+	}
+	sort.Slice(cars, func(i, j int) bool {
+		return cars[i].CarNumber < cars[j].CarNumber
+	})
+	if len(cars) > limit {
+		cars = cars[:limit]
 	}
-	return nil, fmt.Errorf("unknown car ID %s", carNumber)
+	return cars, nil
 }
-func (c *carDB) RemoveCar(ctx context.Context, carNumber string) (*CarEntity, error) {
-	car, err := c.GetCar(ctx, carNumber)
-	if err == nil {
-		delete(c.cars, carNumber)
-		return car, nil
+
+func (c *inMemoryCarDB) CarHistory(ctx context.Context, carNumber string, afterSequence int64, limit int) ([]*CarHistoryEntity, error) {
+	shard := c.shard(carNumber)
+	shard.RLock()
+	defer shard.RUnlock()
+	var history []*CarHistoryEntity
+	for _, change := range shard.history[carNumber] {
+		if len(history) == limit {
+			break
+		}
+		if change.Sequence > afterSequence {
+			history = append(history, change.copy())
+		}
 	}
-	return nil, err
+	return history, nil
+}
+
+func (c *inMemoryCarDB) PendingOutbox(ctx context.Context, now time.Time, limit int) ([]*OutboxEntity, error) {
+	c.outboxLock.Lock()
+	defer c.outboxLock.Unlock()
+	var messages []*OutboxEntity
+	for _, message := range c.outbox {
+		if !message.NextAttempt.After(now) {
+			messages = append(messages, message.copy())
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].ID < messages[j].ID
+	})
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+	return messages, nil
+}
+
+func (c *inMemoryCarDB) RescheduleOutbox(ctx context.Context, message *OutboxEntity) error {
+	c.outboxLock.Lock()
+	defer c.outboxLock.Unlock()
+	if stored, exists := c.outbox[message.ID]; exists {
+		stored.Attempts = message.Attempts
+		stored.LastError = message.LastError
+		stored.NextAttempt = message.NextAttempt
+	}
+	return nil
+}
+
+func (c *inMemoryCarDB) RemoveOutbox(ctx context.Context, id string) error {
+	c.outboxLock.Lock()
+	defer c.outboxLock.Unlock()
+	delete(c.outbox, id)
+	return nil
 }
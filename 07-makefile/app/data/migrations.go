@@ -0,0 +1,319 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/log"
+	"go.uber.org/fx"
+)
+
+// migration is a single versioned schema change, versions must be sequential starting from 1
+type migration struct {
+	Version int
+	Name    string
+	Up      []string
+	Down    []string
+}
+
+// Append new migrations to the end of this list, never change the ones that were already released
+var migrations = []migration{
+	{
+		Version: 1,
+		Name:    "create cars table",
+		Up: []string{
+			`CREATE TABLE cars (
+				car_number     TEXT PRIMARY KEY,
+				owner          TEXT NOT NULL,
+				body_style     TEXT NOT NULL,
+				original_color TEXT NOT NULL,
+				current_color  TEXT NOT NULL,
+				painted        BOOLEAN NOT NULL DEFAULT FALSE
+			)`,
+		},
+		Down: []string{
+			`DROP TABLE cars`,
+		},
+	},
+	{
+		Version: 2,
+		Name:    "add car state",
+		Up: []string{
+			`ALTER TABLE cars ADD COLUMN state TEXT NOT NULL DEFAULT 'ACCEPTED'`,
+			`UPDATE cars SET state = 'PAINTED' WHERE painted`,
+		},
+		Down: []string{
+			// older SQLite versions can't drop columns
+			`CREATE TABLE cars_v1 (
+				car_number     TEXT PRIMARY KEY,
+				owner          TEXT NOT NULL,
+				body_style     TEXT NOT NULL,
+				original_color TEXT NOT NULL,
+				current_color  TEXT NOT NULL,
+				painted        BOOLEAN NOT NULL DEFAULT FALSE
+			)`,
+			`INSERT INTO cars_v1 SELECT car_number, owner, body_style, original_color, current_color, painted FROM cars`,
+			`DROP TABLE cars`,
+			`ALTER TABLE cars_v1 RENAME TO cars`,
+		},
+	},
+	{
+		Version: 3,
+		Name:    "create outbox table",
+		Up: []string{
+			`CREATE TABLE outbox (
+				id            TEXT PRIMARY KEY,
+				car_number    TEXT NOT NULL,
+				desired_color TEXT NOT NULL,
+				paint_job_id  TEXT NOT NULL,
+				attempts      INTEGER NOT NULL DEFAULT 0,
+				last_error    TEXT NOT NULL DEFAULT '',
+				next_attempt  INTEGER NOT NULL -- unix nanoseconds
+			)`,
+			`CREATE INDEX outbox_next_attempt ON outbox (next_attempt)`,
+		},
+		Down: []string{
+			`DROP TABLE outbox`,
+		},
+	},
+	{
+		Version: 4,
+		Name:    "add car version",
+		Up: []string{
+			`ALTER TABLE cars ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+		},
+		Down: []string{
+			`CREATE TABLE cars_v3 (
+				car_number     TEXT PRIMARY KEY,
+				owner          TEXT NOT NULL,
+				body_style     TEXT NOT NULL,
+				original_color TEXT NOT NULL,
+				current_color  TEXT NOT NULL,
+				painted        BOOLEAN NOT NULL DEFAULT FALSE,
+				state          TEXT NOT NULL DEFAULT 'ACCEPTED'
+			)`,
+			`INSERT INTO cars_v3 SELECT car_number, owner, body_style, original_color, current_color, painted, state FROM cars`,
+			`DROP TABLE cars`,
+			`ALTER TABLE cars_v3 RENAME TO cars`,
+		},
+	},
+	{
+		Version: 5,
+		Name:    "create car history table",
+		Up: []string{
+			`CREATE TABLE car_history (
+				sequence     INTEGER PRIMARY KEY AUTOINCREMENT,
+				car_number   TEXT NOT NULL,
+				version      INTEGER NOT NULL,
+				time         INTEGER NOT NULL, -- unix nanoseconds
+				actor        TEXT NOT NULL,
+				sub_workshop TEXT NOT NULL,
+				trace_id     TEXT NOT NULL,
+				old_state    TEXT NOT NULL,
+				new_state    TEXT NOT NULL,
+				old_color    TEXT NOT NULL,
+				new_color    TEXT NOT NULL
+			)`,
+			`CREATE INDEX car_history_car_number ON car_history (car_number, sequence)`,
+		},
+		Down: []string{
+			`DROP TABLE car_history`,
+		},
+	},
+	{
+		Version: 6,
+		Name:    "create paint jobs table",
+		Up: []string{
+			`CREATE TABLE paint_jobs (
+				id            TEXT PRIMARY KEY,
+				car_number    TEXT NOT NULL,
+				desired_color TEXT NOT NULL,
+				status        TEXT NOT NULL,
+				error         TEXT NOT NULL,
+				sub_workshop  TEXT NOT NULL,
+				create_time   INTEGER NOT NULL, -- unix nanoseconds
+				update_time   INTEGER NOT NULL, -- unix nanoseconds
+				end_time      INTEGER NOT NULL  -- unix nanoseconds, 0 until the job is done
+			)`,
+			`CREATE INDEX paint_jobs_car_number ON paint_jobs (car_number, id)`,
+		},
+		Down: []string{
+			`DROP TABLE paint_jobs`,
+		},
+	},
+	{
+		Version: 7,
+		Name:    "add outbox deadline",
+		Up: []string{
+			// unix nanoseconds, 0 without a deadline
+			`ALTER TABLE outbox ADD COLUMN deadline INTEGER NOT NULL DEFAULT 0`,
+		},
+		Down: []string{
+			`CREATE TABLE outbox_v6 (
+				id            TEXT PRIMARY KEY,
+				car_number    TEXT NOT NULL,
+				desired_color TEXT NOT NULL,
+				paint_job_id  TEXT NOT NULL,
+				attempts      INTEGER NOT NULL DEFAULT 0,
+				last_error    TEXT NOT NULL DEFAULT '',
+				next_attempt  INTEGER NOT NULL -- unix nanoseconds
+			)`,
+			`INSERT INTO outbox_v6 SELECT id, car_number, desired_color, paint_job_id, attempts, last_error, next_attempt FROM outbox`,
+			`DROP TABLE outbox`,
+			`ALTER TABLE outbox_v6 RENAME TO outbox`,
+			`CREATE INDEX outbox_next_attempt ON outbox (next_attempt)`,
+		},
+	},
+}
+
+// MigrationStatus describes a single migration and whether it was applied
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Migrator manages the SQL car db schema
+type Migrator interface {
+	// Up applies all pending migrations
+	Up(ctx context.Context) error
+	// Down reverts the last applied migrations, one per step
+	Down(ctx context.Context, steps int) error
+	// Status lists all known migrations
+	Status(ctx context.Context) ([]MigrationStatus, error)
+}
+
+type migratorDeps struct {
+	fx.In
+
+	Config    cfg.Config
+	Logger    log.Logger
+	Lifecycle fx.Lifecycle
+}
+
+type migrator struct {
+	db     *sql.DB
+	logger log.Logger
+}
+
+// CreateMigrator opens the configured SQL database and returns a Migrator for it
+func CreateMigrator(deps migratorDeps) (Migrator, error) {
+	db, err := openSQLDB(deps.Config)
+	if err != nil {
+		return nil, err
+	}
+	deps.Lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return db.Close()
+		},
+	})
+	return newMigrator(db, deps.Logger), nil
+}
+
+func newMigrator(db *sql.DB, logger log.Logger) *migrator {
+	return &migrator{db: db, logger: logger}
+}
+
+func (m *migrator) Up(ctx context.Context) error {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, mig := range migrations {
+		if _, done := applied[mig.Version]; done {
+			continue
+		}
+		if err := m.apply(ctx, mig.Up, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, mig.Version, time.Now().UTC())
+			return err
+		}); err != nil {
+			return fmt.Errorf("migration %d (%s) failed, %w", mig.Version, mig.Name, err)
+		}
+		m.logger.Info(ctx, "applied migration %d (%s)", mig.Version, mig.Name)
+	}
+	return nil
+}
+
+func (m *migrator) Down(ctx context.Context, steps int) error {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+	for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+		mig := migrations[i]
+		if _, done := applied[mig.Version]; !done {
+			continue
+		}
+		if err := m.apply(ctx, mig.Down, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, mig.Version)
+			return err
+		}); err != nil {
+			return fmt.Errorf("reverting migration %d (%s) failed, %w", mig.Version, mig.Name, err)
+		}
+		m.logger.Info(ctx, "reverted migration %d (%s)", mig.Version, mig.Name)
+		steps--
+	}
+	return nil
+}
+
+func (m *migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, mig := range migrations {
+		status := MigrationStatus{Version: mig.Version, Name: mig.Name}
+		if appliedAt, done := applied[mig.Version]; done {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// apply runs statements and bookkeeping in a single transaction
+func (m *migrator) apply(ctx context.Context, statements []string, bookkeeping func(tx *sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, statement := range statements {
+		if _, err = tx.ExecContext(ctx, statement); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err = bookkeeping(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *migrator) appliedVersions(ctx context.Context) (map[int]time.Time, error) {
+	if _, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL
+	)`); err != nil {
+		return nil, err
+	}
+	rows, err := m.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
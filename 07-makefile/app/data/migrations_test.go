@@ -0,0 +1,80 @@
+package data_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/tutorial/07-makefile/app/data"
+	"github.com/go-masonry/tutorial/07-makefile/app/mortar"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type migratorSuite struct {
+	suite.Suite
+	dbDir    string
+	app      *fxtest.App
+	migrator data.Migrator
+}
+
+func TestMigrator(t *testing.T) {
+	suite.Run(t, new(migratorSuite))
+}
+
+func (s *migratorSuite) TestUpDownAndStatus() {
+	ctx := context.Background()
+	statuses, err := s.migrator.Status(ctx)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(statuses)
+	for _, status := range statuses {
+		s.Nil(status.AppliedAt, "migration %d should be pending", status.Version)
+	}
+	// apply everything, twice to make sure it's idempotent
+	s.Require().NoError(s.migrator.Up(ctx))
+	s.Require().NoError(s.migrator.Up(ctx))
+	statuses, err = s.migrator.Status(ctx)
+	s.Require().NoError(err)
+	for _, status := range statuses {
+		s.NotNil(status.AppliedAt, "migration %d should be applied", status.Version)
+	}
+	// revert the last one
+	s.Require().NoError(s.migrator.Down(ctx, 1))
+	statuses, err = s.migrator.Status(ctx)
+	s.Require().NoError(err)
+	s.Nil(statuses[len(statuses)-1].AppliedAt)
+	// revert everything
+	s.Require().NoError(s.migrator.Down(ctx, len(statuses)))
+	statuses, err = s.migrator.Status(ctx)
+	s.Require().NoError(err)
+	for _, status := range statuses {
+		s.Nil(status.AppliedAt, "migration %d should be reverted", status.Version)
+	}
+}
+
+func (s *migratorSuite) SetupTest() {
+	pwd, err := os.Getwd()
+	s.Require().NoError(err)
+	s.dbDir, err = ioutil.TempDir("", "migrator")
+	s.Require().NoError(err)
+	s.app = fxtest.New(s.T(),
+		fx.NopLogger, // remove fx debug prints
+		mortar.ViperFxOption(pwd+"/../../config/config.yml", pwd+"/../../config/config_test.yml"),
+		mortar.LoggerFxOption(),
+		fx.Invoke(func(config cfg.Config) {
+			config.Set(data.DBSQLDSNKey, "file:"+filepath.Join(s.dbDir, "migrations.sqlite.db"))
+		}),
+		fx.Provide(data.CreateMigrator),
+		fx.Populate(&s.migrator),
+	)
+	s.app.RequireStart()
+}
+
+func (s *migratorSuite) TearDownTest() {
+	s.app.RequireStop()
+	os.RemoveAll(s.dbDir)
+}
@@ -0,0 +1,35 @@
+package data
+
+import (
+	"context"
+	"time"
+)
+
+// OutboxEntity is a paint request waiting to be delivered to the sub workshop
+type OutboxEntity struct {
+	ID           string
+	CarNumber    string
+	DesiredColor string
+	PaintJobID   string
+	Attempts     int
+	LastError    string
+	NextAttempt  time.Time
+	// Deadline of the request that asked for the paint, zero if it had none
+	Deadline time.Time
+}
+
+func (o *OutboxEntity) copy() *OutboxEntity {
+	clone := *o
+	return &clone
+}
+
+// Outbox keeps paint requests until they are delivered, they are added with CarDB.RequestPaint.
+// Ids are expected to sort in creation order, a message has the id of the paint job it was added with.
+type Outbox interface {
+	// PendingOutbox returns up to limit messages that should be delivered at now, oldest first
+	PendingOutbox(ctx context.Context, now time.Time, limit int) ([]*OutboxEntity, error)
+	// RescheduleOutbox saves the Attempts, LastError and NextAttempt of the message
+	RescheduleOutbox(ctx context.Context, message *OutboxEntity) error
+	// RemoveOutbox removes a delivered message, removing an unknown message is not an error
+	RemoveOutbox(ctx context.Context, id string) error
+}
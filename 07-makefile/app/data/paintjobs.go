@@ -0,0 +1,72 @@
+package data
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// PaintJobDB stores paint jobs next to the cars they paint, jobs are inserted with CarDB.RequestPaint.
+// Job ids are expected to sort in creation order.
+type PaintJobDB interface {
+	// UpdatePaintJob calls update with the stored job and stores the result, unless update returns an error.
+	// A job that is done once updated leaves the outbox along with it, it's never delivered afterwards.
+	UpdatePaintJob(ctx context.Context, id string, update func(job *PaintJobEntity) error) (*PaintJobEntity, error)
+	GetPaintJob(ctx context.Context, id string) (*PaintJobEntity, error)
+	// ListPaintJobs returns up to limit jobs of carNumber (all cars if empty), ordered by id and starting after afterID
+	ListPaintJobs(ctx context.Context, carNumber string, afterID string, limit int) ([]*PaintJobEntity, error)
+}
+
+// inMemoryPaintJobs keeps the paint jobs of inMemoryCarDB, its lock is taken while holding a shard lock and never
+// the other way around
+type inMemoryPaintJobs struct {
+	jobsLock sync.RWMutex
+	jobs     map[string]*PaintJobEntity
+	jobIDs   []string // sorted
+}
+
+// insertPaintJob must be called with the jobs lock held
+func (p *inMemoryPaintJobs) insertPaintJob(job *PaintJobEntity) {
+	p.jobs[job.ID] = job.copy()
+	index := sort.SearchStrings(p.jobIDs, job.ID)
+	p.jobIDs = append(p.jobIDs, "")
+	copy(p.jobIDs[index+1:], p.jobIDs[index:])
+	p.jobIDs[index] = job.ID
+}
+
+func (p *inMemoryPaintJobs) UpdatePaintJob(ctx context.Context, id string, update func(job *PaintJobEntity) error) (*PaintJobEntity, error) {
+	p.jobsLock.Lock()
+	defer p.jobsLock.Unlock()
+	stored, exists := p.jobs[id]
+	if !exists {
+		return nil, paintJobNotFound(id)
+	}
+	job := stored.copy()
+	if err := update(job); err != nil {
+		return nil, err
+	}
+	p.jobs[id] = job
+	return job.copy(), nil
+}
+
+func (p *inMemoryPaintJobs) GetPaintJob(ctx context.Context, id string) (*PaintJobEntity, error) {
+	p.jobsLock.RLock()
+	defer p.jobsLock.RUnlock()
+	if job, exists := p.jobs[id]; exists {
+		return job.copy(), nil
+	}
+	return nil, paintJobNotFound(id)
+}
+
+func (p *inMemoryPaintJobs) ListPaintJobs(ctx context.Context, carNumber string, afterID string, limit int) ([]*PaintJobEntity, error) {
+	p.jobsLock.RLock()
+	defer p.jobsLock.RUnlock()
+	var jobs []*PaintJobEntity
+	for index := sort.SearchStrings(p.jobIDs, afterID); index < len(p.jobIDs) && len(jobs) < limit; index++ {
+		job := p.jobs[p.jobIDs[index]]
+		if job.ID > afterID && (len(carNumber) == 0 || job.CarNumber == carNumber) {
+			jobs = append(jobs, job.copy())
+		}
+	}
+	return jobs, nil
+}
@@ -0,0 +1,489 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	_ "github.com/mattn/go-sqlite3" // sqlite3 driver
+	"go.uber.org/fx"
+)
+
+const (
+	defaultSQLDriver = "sqlite3"
+	// anyVersionAttempts is how many times a write without an expected version is tried when the car keeps changing
+	anyVersionAttempts = 5
+
+	carColumns    = "car_number, owner, body_style, original_color, current_color, painted, state, version"
+	outboxColumns = "id, car_number, desired_color, paint_job_id, attempts, last_error, next_attempt, deadline"
+	jobColumns    = "id, car_number, desired_color, status, error, sub_workshop, create_time, update_time, end_time"
+	// the sequence is generated by the database
+	historyColumns = "car_number, version, time, actor, sub_workshop, trace_id, old_state, new_state, old_color, new_color"
+)
+
+// sqlCarDB stores cars in a SQL database, by default an embedded SQLite file.
+// Schema is migrated to the latest version when the application starts.
+type sqlCarDB struct {
+	db *sql.DB
+}
+
+func createSQLCarDB(deps carDBDeps) (CarDB, error) {
+	db, err := openSQLDB(deps.Config)
+	if err != nil {
+		return nil, err
+	}
+	deps.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return newMigrator(db, deps.Logger).Up(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			return db.Close()
+		},
+	})
+	return &sqlCarDB{db: db}, nil
+}
+
+func openSQLDB(config cfg.Config) (*sql.DB, error) {
+	driver := defaultSQLDriver
+	if value := config.Get(DBSQLDriverKey); value.IsSet() {
+		driver = value.String()
+	}
+	dsn := config.Get(DBSQLDSNKey).String()
+	if len(dsn) == 0 {
+		return nil, fmt.Errorf("%s must be set when using %s car db", DBSQLDSNKey, DBTypeSQL)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database, %w", driver, err)
+	}
+	if driver == defaultSQLDriver {
+		// SQLite allows only one writer at a time
+		db.SetMaxOpenConns(1)
+	}
+	return db, nil
+}
+
+func (s *sqlCarDB) InsertCar(ctx context.Context, car *CarEntity) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO cars (`+carColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, 1)
+		ON CONFLICT (car_number) DO NOTHING`,
+		car.CarNumber, car.Owner, car.BodyStyle, car.OriginalColor, car.CurrentColor, car.Painted, CarAccepted)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return carAlreadyExists(car.CarNumber)
+	}
+	stored := car.copy()
+	stored.State = CarAccepted
+	stored.Version = 1
+	if err = insertHistory(ctx, tx, newCarHistory(ctx, nil, stored)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqlCarDB) PaintCar(ctx context.Context, carNumber string, version int64, newColor string) (*CarEntity, error) {
+	return s.write(ctx, carNumber, version, func(car *CarEntity) error {
+		return car.paint(newColor)
+	}, nil)
+}
+
+func (s *sqlCarDB) SetCarState(ctx context.Context, carNumber string, version int64, state string) (*CarEntity, error) {
+	return s.write(ctx, carNumber, version, func(car *CarEntity) error {
+		return car.transition(state)
+	}, nil)
+}
+
+func (s *sqlCarDB) RequestPaint(ctx context.Context, carNumber string, version int64, job *PaintJobEntity, message *OutboxEntity) (*CarEntity, error) {
+	return s.write(ctx, carNumber, version, func(car *CarEntity) error {
+		return car.transition(CarPaintRequested)
+	}, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO paint_jobs (`+jobColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			job.ID, job.CarNumber, job.DesiredColor, job.Status, job.Error, job.SubWorkshop,
+			job.CreateTime.UnixNano(), job.UpdateTime.UnixNano(), unixNano(job.EndTime))
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO outbox (`+outboxColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			message.ID, message.CarNumber, message.DesiredColor, message.PaintJobID, message.Attempts, message.LastError,
+			message.NextAttempt.UnixNano(), unixNano(message.Deadline))
+		return err
+	})
+}
+
+func (s *sqlCarDB) UpdateCar(ctx context.Context, carNumber string, version int64, edit func(car *CarEntity) error) (*CarEntity, error) {
+	return s.write(ctx, carNumber, version, func(car *CarEntity) error {
+		return car.update(edit)
+	}, nil)
+}
+
+// write updates the car and runs also, when it's set, in a single transaction
+func (s *sqlCarDB) write(ctx context.Context, carNumber string, version int64, update func(car *CarEntity) error, also func(tx *sql.Tx) error) (*CarEntity, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	car, err := updateCar(ctx, tx, carNumber, version, update)
+	if err != nil {
+		return nil, err
+	}
+	if also != nil {
+		if err = also(tx); err != nil {
+			return nil, err
+		}
+	}
+	return car, tx.Commit()
+}
+
+type execQueryRower interface {
+	queryRower
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// updateCar saves the changes made by update only if nobody changed the car in the meantime, and its history
+// when its state changed. Without an expected version, 0, a car that changed is selected and updated again,
+// up to anyVersionAttempts times.
+func updateCar(ctx context.Context, db execQueryRower, carNumber string, version int64, update func(car *CarEntity) error) (*CarEntity, error) {
+	for attempt := 1; ; attempt++ {
+		car, err := selectCar(ctx, db, carNumber)
+		if err != nil {
+			return nil, err
+		}
+		if err = car.At(version); err != nil {
+			return nil, err
+		}
+		before := car.copy()
+		if err = update(car); err != nil {
+			return nil, err
+		}
+		result, err := db.ExecContext(ctx,
+			`UPDATE cars SET owner = ?, body_style = ?, current_color = ?, painted = ?, state = ?, version = ?
+			WHERE car_number = ? AND version = ?`,
+			car.Owner, car.BodyStyle, car.CurrentColor, car.Painted, car.State, car.Version, carNumber, before.Version)
+		if err != nil {
+			return nil, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if affected > 0 {
+			if car.State != before.State {
+				err = insertHistory(ctx, db, newCarHistory(ctx, before, car))
+			}
+			return car, err
+		}
+		// removed or changed since we selected it
+		if version != 0 || attempt == anyVersionAttempts {
+			if _, err := selectCar(ctx, db, carNumber); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %s changed concurrently", ErrCarChanged, carNumber)
+		}
+	}
+}
+
+func (s *sqlCarDB) GetCar(ctx context.Context, carNumber string) (*CarEntity, error) {
+	return selectCar(ctx, s.db, carNumber)
+}
+
+func (s *sqlCarDB) RemoveCar(ctx context.Context, carNumber string, version int64) (*CarEntity, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	car, err := deleteCar(ctx, tx, carNumber, version)
+	if err != nil {
+		return nil, err
+	}
+	return car, tx.Commit()
+}
+
+func (s *sqlCarDB) RetrieveCar(ctx context.Context, carNumber string, version int64) (*CarEntity, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	car, err := updateCar(ctx, tx, carNumber, version, func(car *CarEntity) error {
+		return car.transition(CarRetrieved)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err = deleteCar(ctx, tx, carNumber, car.Version); err != nil {
+		return nil, err
+	}
+	return car, tx.Commit()
+}
+
+// deleteCar removes the car only if nobody changed it in the meantime, like updateCar
+func deleteCar(ctx context.Context, db execQueryRower, carNumber string, version int64) (*CarEntity, error) {
+	for attempt := 1; ; attempt++ {
+		car, err := selectCar(ctx, db, carNumber)
+		if err != nil {
+			return nil, err
+		}
+		if err = car.At(version); err != nil {
+			return nil, err
+		}
+		result, err := db.ExecContext(ctx, `DELETE FROM cars WHERE car_number = ? AND version = ?`, carNumber, car.Version)
+		if err != nil {
+			return nil, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if affected > 0 {
+			return car, nil
+		}
+		// removed or changed since we selected it
+		if version != 0 || attempt == anyVersionAttempts {
+			if _, err := selectCar(ctx, db, carNumber); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %s changed concurrently", ErrCarChanged, carNumber)
+		}
+	}
+}
+
+func (s *sqlCarDB) ListCars(ctx context.Context, filter CarFilter, afterCarNumber string, limit int) ([]*CarEntity, error) {
+	where := []string{"car_number > ?"}
+	args := []interface{}{afterCarNumber}
+	if len(filter.Owner) > 0 {
+		where = append(where, "owner = ?")
+		args = append(args, filter.Owner)
+	}
+	if len(filter.CurrentColor) > 0 {
+		where = append(where, "current_color = ?")
+		args = append(args, filter.CurrentColor)
+	}
+	if filter.Painted != nil {
+		where = append(where, "painted = ?")
+		args = append(args, *filter.Painted)
+	}
+	if len(filter.BodyStyles) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(filter.BodyStyles)), ", ")
+		where = append(where, fmt.Sprintf("body_style IN (%s)", placeholders))
+		for _, bodyStyle := range filter.BodyStyles {
+			args = append(args, bodyStyle)
+		}
+	}
+	args = append(args, limit)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+carColumns+` FROM cars WHERE `+
+			strings.Join(where, " AND ")+` ORDER BY car_number LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var cars []*CarEntity
+	for rows.Next() {
+		car, err := scanCar(rows)
+		if err != nil {
+			return nil, err
+		}
+		cars = append(cars, car)
+	}
+	return cars, rows.Err()
+}
+
+func (s *sqlCarDB) CarHistory(ctx context.Context, carNumber string, afterSequence int64, limit int) ([]*CarHistoryEntity, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT sequence, `+historyColumns+` FROM car_history WHERE car_number = ? AND sequence > ? ORDER BY sequence LIMIT ?`,
+		carNumber, afterSequence, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var history []*CarHistoryEntity
+	for rows.Next() {
+		change := new(CarHistoryEntity)
+		var changeTime int64
+		if err = rows.Scan(&change.Sequence, &change.CarNumber, &change.Version, &changeTime, &change.Actor, &change.SubWorkshop,
+			&change.TraceID, &change.OldState, &change.NewState, &change.OldColor, &change.NewColor); err != nil {
+			return nil, err
+		}
+		change.Time = time.Unix(0, changeTime).UTC()
+		history = append(history, change)
+	}
+	return history, rows.Err()
+}
+
+func insertHistory(ctx context.Context, db execQueryRower, history *CarHistoryEntity) error {
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO car_history (`+historyColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		history.CarNumber, history.Version, history.Time.UnixNano(), history.Actor, history.SubWorkshop,
+		history.TraceID, history.OldState, history.NewState, history.OldColor, history.NewColor)
+	return err
+}
+
+func (s *sqlCarDB) UpdatePaintJob(ctx context.Context, id string, update func(job *PaintJobEntity) error) (*PaintJobEntity, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	job, err := selectPaintJob(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err = update(job); err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx,
+		`UPDATE paint_jobs SET status = ?, error = ?, sub_workshop = ?, update_time = ?, end_time = ? WHERE id = ?`,
+		job.Status, job.Error, job.SubWorkshop, job.UpdateTime.UnixNano(), unixNano(job.EndTime), id); err != nil {
+		return nil, err
+	}
+	if job.Done() {
+		if _, err = tx.ExecContext(ctx, `DELETE FROM outbox WHERE id = ?`, id); err != nil {
+			return nil, err
+		}
+	}
+	return job, tx.Commit()
+}
+
+func (s *sqlCarDB) GetPaintJob(ctx context.Context, id string) (*PaintJobEntity, error) {
+	return selectPaintJob(ctx, s.db, id)
+}
+
+func (s *sqlCarDB) ListPaintJobs(ctx context.Context, carNumber string, afterID string, limit int) ([]*PaintJobEntity, error) {
+	query := `SELECT ` + jobColumns + ` FROM paint_jobs WHERE id > ?`
+	args := []interface{}{afterID}
+	if len(carNumber) > 0 {
+		query += ` AND car_number = ?`
+		args = append(args, carNumber)
+	}
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY id LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var jobs []*PaintJobEntity
+	for rows.Next() {
+		job, err := scanPaintJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func (s *sqlCarDB) PendingOutbox(ctx context.Context, now time.Time, limit int) ([]*OutboxEntity, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+outboxColumns+` FROM outbox WHERE next_attempt <= ? ORDER BY id LIMIT ?`, now.UnixNano(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var messages []*OutboxEntity
+	for rows.Next() {
+		message := new(OutboxEntity)
+		var nextAttempt, deadline int64
+		if err = rows.Scan(&message.ID, &message.CarNumber, &message.DesiredColor, &message.PaintJobID,
+			&message.Attempts, &message.LastError, &nextAttempt, &deadline); err != nil {
+			return nil, err
+		}
+		message.NextAttempt = time.Unix(0, nextAttempt).UTC()
+		if deadline != 0 {
+			message.Deadline = time.Unix(0, deadline).UTC()
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+func (s *sqlCarDB) RescheduleOutbox(ctx context.Context, message *OutboxEntity) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE outbox SET attempts = ?, last_error = ?, next_attempt = ? WHERE id = ?`,
+		message.Attempts, message.LastError, message.NextAttempt.UnixNano(), message.ID)
+	return err
+}
+
+func (s *sqlCarDB) RemoveOutbox(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM outbox WHERE id = ?`, id)
+	return err
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func selectCar(ctx context.Context, db queryRower, carNumber string) (*CarEntity, error) {
+	car, err := scanCar(db.QueryRowContext(ctx, `SELECT `+carColumns+` FROM cars WHERE car_number = ?`, carNumber))
+	if err == sql.ErrNoRows {
+		return nil, carNotFound(carNumber)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return car, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanCar reads a row selected with carColumns
+func scanCar(row scanner) (*CarEntity, error) {
+	car := new(CarEntity)
+	if err := row.Scan(&car.CarNumber, &car.Owner, &car.BodyStyle, &car.OriginalColor, &car.CurrentColor, &car.Painted, &car.State, &car.Version); err != nil {
+		return nil, err
+	}
+	return car, nil
+}
+
+func selectPaintJob(ctx context.Context, db queryRower, id string) (*PaintJobEntity, error) {
+	job, err := scanPaintJob(db.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM paint_jobs WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, paintJobNotFound(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// scanPaintJob reads a row selected with jobColumns
+func scanPaintJob(row scanner) (*PaintJobEntity, error) {
+	job := new(PaintJobEntity)
+	var createTime, updateTime, endTime int64
+	if err := row.Scan(&job.ID, &job.CarNumber, &job.DesiredColor, &job.Status, &job.Error, &job.SubWorkshop,
+		&createTime, &updateTime, &endTime); err != nil {
+		return nil, err
+	}
+	job.CreateTime = time.Unix(0, createTime).UTC()
+	job.UpdateTime = time.Unix(0, updateTime).UTC()
+	if endTime != 0 {
+		job.EndTime = time.Unix(0, endTime).UTC()
+	}
+	return job, nil
+}
+
+// unixNano is 0 for the zero time, whose unix nanoseconds don't fit an int64
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
@@ -0,0 +1,73 @@
+package mortar
+
+import (
+	"context"
+
+	"github.com/go-masonry/mortar/interfaces/http/client"
+	"github.com/go-masonry/mortar/providers/groups"
+	"github.com/go-masonry/tutorial/07-makefile/app/data"
+	"github.com/go-masonry/tutorial/07-makefile/app/security"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+)
+
+// AuthFxOption makes every RPC we serve require credentials, see security.AuthAPIKeysKey and security.AuthJWTSecretKey,
+// and checks the callers against the policy of security.AuthzRulesKey.
+// Our own REST and gRPC clients present security.AuthClientTokenKey.
+func AuthFxOption() fx.Option {
+	return fx.Options(
+		fx.Provide(security.CreateAuthentication),
+		fx.Provide(security.CreateAuthorization),
+		fx.Provide(fx.Annotated{
+			Group:  security.OwnersGroup,
+			Target: carOwners,
+		}),
+		fx.Provide(fx.Annotated{
+			Group: groups.UnaryServerInterceptors,
+			Target: func(auth security.Authentication, authz security.Authorization) grpc.UnaryServerInterceptor {
+				// authorization needs the principal authentication puts on the context, interceptor groups have no order
+				return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+					return auth.UnaryServerInterceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+						return authz.UnaryServerInterceptor(ctx, req, info, handler)
+					})
+				}
+			},
+		}),
+		fx.Provide(fx.Annotated{
+			Group: groups.StreamServerInterceptors,
+			Target: func(auth security.Authentication, authz security.Authorization) grpc.StreamServerInterceptor {
+				return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+					return auth.StreamServerInterceptor(srv, stream, info, func(srv interface{}, stream grpc.ServerStream) error {
+						return authz.StreamServerInterceptor(srv, stream, info, handler)
+					})
+				}
+			},
+		}),
+		fx.Provide(fx.Annotated{
+			Group: groups.RESTClientInterceptors,
+			Target: func(auth security.Authentication) client.HTTPClientInterceptor {
+				return auth.RESTClientInterceptor
+			},
+		}),
+		fx.Provide(fx.Annotated{
+			Group: groups.GRPCClientInterceptors,
+			Target: func(auth security.Authentication) grpc.UnaryClientInterceptor {
+				return auth.GRPCClientInterceptor
+			},
+		}),
+	)
+}
+
+// carOwners lets owners call the methods of their cars, see security.OwnershipRule
+func carOwners(db data.CarDB) security.OwnerLookup {
+	return security.OwnerLookup{
+		Kind: "car",
+		Owner: func(ctx context.Context, carNumber string) (string, error) {
+			car, err := db.GetCar(ctx, carNumber)
+			if err != nil {
+				return "", err
+			}
+			return car.Owner, nil
+		},
+	}
+}
@@ -0,0 +1,21 @@
+package mortar
+
+import (
+	"github.com/go-masonry/mortar/providers/groups"
+	"github.com/go-masonry/tutorial/07-makefile/app/services"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+)
+
+// DeadlinesFxOption limits how long every unary RPC may take, see services.ServerTimeoutKey
+func DeadlinesFxOption() fx.Option {
+	return fx.Options(
+		fx.Provide(services.CreateDeadlines),
+		fx.Provide(fx.Annotated{
+			Group: groups.UnaryServerInterceptors,
+			Target: func(deadlines services.Deadlines) grpc.UnaryServerInterceptor {
+				return deadlines.UnaryServerInterceptor
+			},
+		}),
+	)
+}
@@ -0,0 +1,33 @@
+package mortar
+
+import (
+	"github.com/go-masonry/mortar/providers/groups"
+	"github.com/go-masonry/tutorial/07-makefile/app/services"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+)
+
+// ErrorsFxOption maps our domain errors to gRPC codes and, through the gateway, to HTTP status codes
+func ErrorsFxOption() fx.Option {
+	return fx.Options(
+		fx.Provide(fx.Annotated{
+			Group: groups.UnaryServerInterceptors,
+			Target: func() grpc.UnaryServerInterceptor {
+				return services.ErrorsUnaryServerInterceptor
+			},
+		}),
+		fx.Provide(fx.Annotated{
+			Group: groups.StreamServerInterceptors,
+			Target: func() grpc.StreamServerInterceptor {
+				return services.ErrorsStreamServerInterceptor
+			},
+		}),
+		fx.Provide(fx.Annotated{
+			Group: groups.GRPCGatewayMuxOptions,
+			Target: func() runtime.ServeMuxOption {
+				return runtime.WithErrorHandler(services.HTTPErrorHandler)
+			},
+		}),
+	)
+}
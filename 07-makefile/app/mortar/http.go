@@ -1,8 +1,12 @@
 package mortar
 
 import (
+	"github.com/go-masonry/mortar/interfaces/http/client"
 	"github.com/go-masonry/mortar/providers"
+	"github.com/go-masonry/mortar/providers/groups"
+	"github.com/go-masonry/tutorial/07-makefile/app/resilience"
 	"go.uber.org/fx"
+	"google.golang.org/grpc"
 )
 
 func HttpClientFxOptions() fx.Option {
@@ -14,6 +18,44 @@ func HttpClientFxOptions() fx.Option {
 	)
 }
 
+// CircuitBreakerFxOption stops REST and gRPC clients from calling destinations that keep failing
+func CircuitBreakerFxOption() fx.Option {
+	return fx.Options(
+		fx.Provide(resilience.CreateCircuitBreakers),
+		fx.Provide(fx.Annotated{
+			Group: groups.RESTClientInterceptors,
+			Target: func(breakers resilience.CircuitBreakers) client.HTTPClientInterceptor {
+				return breakers.RESTClientInterceptor
+			},
+		}),
+		fx.Provide(fx.Annotated{
+			Group: groups.GRPCClientInterceptors,
+			Target: func(breakers resilience.CircuitBreakers) grpc.UnaryClientInterceptor {
+				return breakers.GRPCClientInterceptor
+			},
+		}),
+	)
+}
+
+// BulkheadFxOption limits the concurrent calls REST and gRPC clients make to every destination
+func BulkheadFxOption() fx.Option {
+	return fx.Options(
+		fx.Provide(resilience.CreateBulkheads),
+		fx.Provide(fx.Annotated{
+			Group: groups.RESTClientInterceptors,
+			Target: func(bulkheads resilience.Bulkheads) client.HTTPClientInterceptor {
+				return bulkheads.RESTClientInterceptor
+			},
+		}),
+		fx.Provide(fx.Annotated{
+			Group: groups.GRPCClientInterceptors,
+			Target: func(bulkheads resilience.Bulkheads) grpc.UnaryClientInterceptor {
+				return bulkheads.GRPCClientInterceptor
+			},
+		}),
+	)
+}
+
 func HttpServerFxOptions() fx.Option {
 	return fx.Options(
 		providers.HTTPServerBuilderFxOption(), // Web Server Builder
@@ -9,6 +9,8 @@ import (
 	"github.com/go-masonry/mortar/interfaces/log"
 	"github.com/go-masonry/mortar/mortar"
 	"github.com/go-masonry/mortar/providers"
+	"github.com/go-masonry/mortar/providers/groups"
+	"github.com/go-masonry/tutorial/07-makefile/app/security"
 	"go.uber.org/fx"
 )
 
@@ -18,6 +20,12 @@ func LoggerFxOption() fx.Option {
 		providers.LoggerFxOption(),
 		providers.LoggerGRPCIncomingContextExtractorFxOption(),
 		bjaeger.TraceInfoContextExtractorFxOption(),
+		fx.Provide(fx.Annotated{
+			Group: groups.LoggerContextExtractors,
+			Target: func() log.ContextExtractor {
+				return security.PrincipalContextExtractor // who called us
+			},
+		}),
 	)
 }
 
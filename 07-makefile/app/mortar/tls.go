@@ -0,0 +1,78 @@
+package mortar
+
+import (
+	"fmt"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	serverInt "github.com/go-masonry/mortar/interfaces/http/server"
+	"github.com/go-masonry/tutorial/07-makefile/app/security"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.uber.org/fx"
+)
+
+// TLSFxOption secures the gRPC server with the configured certificates, see security.TLSCertificateKey.
+// REST listeners are served over TLS when their ports are configured, see security.TLSRESTExternalPortKey.
+// Clients and the gRPC gateway get their credentials from security.TLS as well.
+func TLSFxOption() fx.Option {
+	return fx.Options(
+		fx.Provide(security.CreateTLS),
+		fx.Invoke(func(tls security.TLS, builder serverInt.GRPCWebServiceBuilder) {
+			builder.AddGRPCServerOptions(tls.GRPCServerOptions()...)
+		}),
+		fx.Invoke(tlsRESTListeners),
+	)
+}
+
+type tlsRESTDeps struct {
+	fx.In
+
+	Config  cfg.Config
+	TLS     security.TLS
+	Builder serverInt.GRPCWebServiceBuilder
+	// What Mortar registers on its own REST listeners, see groups.GRPCGatewayGeneratedHandlers and the like
+	GatewayHandlers   []serverInt.GRPCGatewayGeneratedHandlers `group:"grpcGatewayGeneratedHandlers"`
+	GatewayOptions    []runtime.ServeMuxOption                 `group:"grpcGatewayMuxOptions"`
+	ExternalHandlers  []serverInt.HTTPHandlerPatternPair       `group:"externalHttpHandlers"`
+	ExternalFunctions []serverInt.HTTPHandlerFuncPatternPair   `group:"externalHttpHandlerFunctions"`
+	InternalHandlers  []serverInt.HTTPHandlerPatternPair       `group:"internalHttpHandlers"`
+	InternalFunctions []serverInt.HTTPHandlerFuncPatternPair   `group:"internalHttpHandlerFunctions"`
+}
+
+// tlsRESTListeners adds the REST listeners Mortar would add for mortar.server.rest, served over TLS instead.
+// Leave the mortar.server.rest ports unset when they are configured, or both would be served.
+func tlsRESTListeners(deps tlsRESTDeps) error {
+	if port := deps.Config.Get(security.TLSRESTExternalPortKey); port.IsSet() {
+		rest, err := tlsRESTBuilder(deps, port.Int())
+		if err != nil {
+			return err
+		}
+		rest = rest.AddGRPCGatewayOptions(deps.GatewayOptions...).RegisterGRPCGatewayHandlers(deps.GatewayHandlers...)
+		addRESTHandlers(rest, deps.ExternalHandlers, deps.ExternalFunctions).BuildRESTPart()
+	}
+	if port := deps.Config.Get(security.TLSRESTInternalPortKey); port.IsSet() {
+		rest, err := tlsRESTBuilder(deps, port.Int())
+		if err != nil {
+			return err
+		}
+		addRESTHandlers(rest, deps.InternalHandlers, deps.InternalFunctions).BuildRESTPart()
+	}
+	return nil
+}
+
+func tlsRESTBuilder(deps tlsRESTDeps, port int) (serverInt.RESTBuilder, error) {
+	listener, err := deps.TLS.RESTListener(fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, err
+	}
+	return deps.Builder.AddRESTServerConfiguration().SetCustomListener(listener), nil
+}
+
+func addRESTHandlers(rest serverInt.RESTBuilder, handlers []serverInt.HTTPHandlerPatternPair, functions []serverInt.HTTPHandlerFuncPatternPair) serverInt.RESTBuilder {
+	for _, pair := range handlers {
+		rest = rest.AddHandler(pair.Pattern, pair.Handler)
+	}
+	for _, pair := range functions {
+		rest = rest.AddHandlerFunc(pair.Pattern, pair.HandlerFunc)
+	}
+	return rest
+}
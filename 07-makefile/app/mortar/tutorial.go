@@ -8,6 +8,7 @@ import (
 	workshop "github.com/go-masonry/tutorial/07-makefile/api"
 	"github.com/go-masonry/tutorial/07-makefile/app/controllers"
 	"github.com/go-masonry/tutorial/07-makefile/app/data"
+	"github.com/go-masonry/tutorial/07-makefile/app/security"
 	"github.com/go-masonry/tutorial/07-makefile/app/services"
 	"github.com/go-masonry/tutorial/07-makefile/app/validations"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
@@ -35,6 +36,19 @@ func TutorialAPIsAndOtherDependenciesFxOption() fx.Option {
 			Group:  groups.GRPCGatewayGeneratedHandlers + ",flatten", // "flatten" does this [][]serverInt.GRPCGatewayGeneratedHandlers -> []serverInt.GRPCGatewayGeneratedHandlers
 			Target: tutorialGRPCGatewayHandlers,
 		}),
+		// Headers the gateway passes to our gRPC services
+		fx.Provide(fx.Annotated{
+			Group: groups.GRPCGatewayMuxOptions,
+			Target: func() runtime.ServeMuxOption {
+				return runtime.WithIncomingHeaderMatcher(services.GatewayHeaderMatcher)
+			},
+		}),
+		fx.Provide(fx.Annotated{
+			Group: groups.GRPCGatewayMuxOptions,
+			Target: func() runtime.ServeMuxOption {
+				return runtime.WithOutgoingHeaderMatcher(services.GatewayOutgoingHeaderMatcher)
+			},
+		}),
 		// All other tutorial dependencies
 		tutorialDependencies(),
 	)
@@ -48,15 +62,16 @@ func tutorialGRPCServiceAPIs(deps tutorialServiceDeps) serverInt.GRPCServerAPI {
 	}
 }
 
-func tutorialGRPCGatewayHandlers() []serverInt.GRPCGatewayGeneratedHandlers {
+// tutorialGRPCGatewayHandlers dial our own gRPC server, with TLS when it's configured
+func tutorialGRPCGatewayHandlers(tls security.TLS) []serverInt.GRPCGatewayGeneratedHandlers {
 	return []serverInt.GRPCGatewayGeneratedHandlers{
 		// Register workshop REST API
 		func(mux *runtime.ServeMux, endpoint string) error {
-			return workshop.RegisterWorkshopHandlerFromEndpoint(context.Background(), mux, endpoint, []grpc.DialOption{grpc.WithInsecure()})
+			return workshop.RegisterWorkshopHandlerFromEndpoint(context.Background(), mux, endpoint, []grpc.DialOption{tls.GRPCDialOption()})
 		},
 		// Register sub workshop REST API
 		func(mux *runtime.ServeMux, endpoint string) error {
-			return workshop.RegisterSubWorkshopHandlerFromEndpoint(context.Background(), mux, endpoint, []grpc.DialOption{grpc.WithInsecure()})
+			return workshop.RegisterSubWorkshopHandlerFromEndpoint(context.Background(), mux, endpoint, []grpc.DialOption{tls.GRPCDialOption()})
 		},
 		// Any additional gRPC gateway registrations should be called here
 	}
@@ -67,8 +82,11 @@ func tutorialDependencies() fx.Option {
 		services.CreateWorkshopService,
 		services.CreateSubWorkshopService,
 		controllers.CreateWorkshopController,
+		controllers.CreateCarEvents,
 		controllers.CreateSubWorkshopController,
 		data.CreateCarDB,
+		data.CreateIdempotencyDB,
+		data.CreateCallbackQueue,
 		validations.CreateWorkshopValidations,
 		validations.CreateSubWorkshopValidations,
 	)
@@ -0,0 +1,16 @@
+// Package noop stands in for optional dependencies that aren't configured
+package noop
+
+// Gauge is a monitor.Gauge for when no metrics are configured
+type Gauge struct{}
+
+func (Gauge) Set(float64) {}
+func (Gauge) Add(float64) {}
+func (Gauge) Inc()        {}
+func (Gauge) Dec()        {}
+
+// Counter is a monitor.Counter for when no metrics are configured
+type Counter struct{}
+
+func (Counter) Inc()        {}
+func (Counter) Add(float64) {}
@@ -0,0 +1,228 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/http/client"
+	"github.com/go-masonry/mortar/interfaces/log"
+	"github.com/go-masonry/mortar/interfaces/monitor"
+	"github.com/go-masonry/tutorial/07-makefile/app/noop"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// BreakerFailuresKey is how many failed calls in a row open the circuit of a destination
+	BreakerFailuresKey = "custom.clients.breaker.failures"
+	// BreakerOpenKey is how long an open circuit rejects calls before a few are let through to check the destination
+	BreakerOpenKey = "custom.clients.breaker.open"
+	// BreakerHalfOpenRequestsKey is how many calls are let through while half open, the circuit closes once they all succeed
+	BreakerHalfOpenRequestsKey = "custom.clients.breaker.halfopen"
+
+	defaultBreakerFailures         = 5
+	defaultBreakerOpen             = 30 * time.Second
+	defaultBreakerHalfOpenRequests = 1
+)
+
+// ErrCircuitOpen is returned by REST calls to a destination that keeps failing, gRPC calls fail with Unavailable
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// State of a circuit breaker, reported as a metric
+type State int
+
+const (
+	// Closed lets every call through
+	Closed State = iota
+	// HalfOpen lets a few calls through to check if the destination recovered
+	HalfOpen
+	// Open rejects every call
+	Open
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "open"
+	}
+}
+
+// CircuitBreakers keeps a circuit breaker per destination, a host:port for REST and a target for gRPC
+type CircuitBreakers interface {
+	// State of the destination circuit, unknown destinations are Closed
+	State(destination string) State
+	RESTClientInterceptor(request *http.Request, handler client.HTTPpHandler) (*http.Response, error)
+	GRPCClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error
+}
+
+type circuitBreakersDeps struct {
+	fx.In
+
+	Config  cfg.Config
+	Logger  log.Logger
+	Metrics monitor.Metrics `optional:"true"`
+}
+
+type circuitBreakers struct {
+	sync.Mutex
+	deps             circuitBreakersDeps
+	failures         int
+	openFor          time.Duration
+	halfOpenRequests int
+	breakers         map[string]*breaker
+}
+
+type breaker struct {
+	state      State
+	generation int // changes with the state, calls that started in another state don't count
+	failures   int // in a row while closed
+	openedAt   time.Time
+	trials     int // calls let through while half open
+	successes  int // of the trials
+	stateGauge monitor.Gauge
+}
+
+// CreateCircuitBreakers is a constructor for Fx, metrics are optional
+func CreateCircuitBreakers(deps circuitBreakersDeps) CircuitBreakers {
+	c := &circuitBreakers{
+		deps:             deps,
+		failures:         defaultBreakerFailures,
+		openFor:          defaultBreakerOpen,
+		halfOpenRequests: defaultBreakerHalfOpenRequests,
+		breakers:         make(map[string]*breaker),
+	}
+	if value := deps.Config.Get(BreakerFailuresKey); value.IsSet() {
+		c.failures = value.Int()
+	}
+	if value := deps.Config.Get(BreakerOpenKey); value.IsSet() {
+		c.openFor = value.Duration()
+	}
+	if value := deps.Config.Get(BreakerHalfOpenRequestsKey); value.IsSet() {
+		c.halfOpenRequests = value.Int()
+	}
+	return c
+}
+
+func (c *circuitBreakers) State(destination string) State {
+	c.Lock()
+	defer c.Unlock()
+	if b, exists := c.breakers[destination]; exists {
+		return b.state
+	}
+	return Closed
+}
+
+func (c *circuitBreakers) RESTClientInterceptor(request *http.Request, handler client.HTTPpHandler) (*http.Response, error) {
+	done, err := c.allow(request.Context(), request.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	response, err := handler(request)
+	done(err != nil || response.StatusCode >= http.StatusInternalServerError)
+	return response, err
+}
+
+func (c *circuitBreakers) GRPCClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	done, err := c.allow(ctx, cc.Target())
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	err = invoker(ctx, method, req, reply, cc, opts...)
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown:
+		done(true)
+	default:
+		done(false)
+	}
+	return err
+}
+
+// allow fails if the destination circuit is open, otherwise done must be called with the result of the call
+func (c *circuitBreakers) allow(ctx context.Context, destination string) (done func(failed bool), err error) {
+	c.Lock()
+	defer c.Unlock()
+	b := c.breaker(destination)
+	switch b.state {
+	case Open:
+		if time.Now().Sub(b.openedAt) < c.openFor {
+			return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, destination)
+		}
+		c.transition(ctx, destination, b, HalfOpen)
+		fallthrough
+	case HalfOpen:
+		if b.trials >= c.halfOpenRequests {
+			return nil, fmt.Errorf("%w: %s is half open", ErrCircuitOpen, destination)
+		}
+		b.trials++
+	}
+	generation := b.generation
+	return func(failed bool) {
+		c.Lock()
+		defer c.Unlock()
+		if b.generation == generation {
+			c.record(ctx, destination, b, failed)
+		}
+	}, nil
+}
+
+func (c *circuitBreakers) record(ctx context.Context, destination string, b *breaker, failed bool) {
+	switch b.state {
+	case Closed:
+		if !failed {
+			b.failures = 0
+		} else if b.failures++; b.failures >= c.failures {
+			c.transition(ctx, destination, b, Open)
+		}
+	case HalfOpen:
+		if failed {
+			c.transition(ctx, destination, b, Open)
+		} else if b.successes++; b.successes >= c.halfOpenRequests {
+			c.transition(ctx, destination, b, Closed)
+		}
+	}
+}
+
+func (c *circuitBreakers) transition(ctx context.Context, destination string, b *breaker, state State) {
+	b.state = state
+	b.generation++
+	b.failures, b.trials, b.successes = 0, 0, 0
+	if state == Open {
+		b.openedAt = time.Now()
+		c.deps.Logger.Warn(ctx, "circuit breaker of %s is open for %s", destination, c.openFor)
+	} else {
+		c.deps.Logger.Info(ctx, "circuit breaker of %s is %s", destination, state)
+	}
+	b.stateGauge.Set(float64(state))
+	if c.deps.Metrics != nil {
+		if counter, err := c.deps.Metrics.Counter("client_circuit_breaker_transitions", "circuit breaker state changes").
+			WithTags(monitor.Tags{"destination": destination, "state": state.String()}); err == nil {
+			counter.Inc()
+		}
+	}
+}
+
+func (c *circuitBreakers) breaker(destination string) *breaker {
+	b, exists := c.breakers[destination]
+	if !exists {
+		b = &breaker{stateGauge: noop.Gauge{}}
+		if c.deps.Metrics != nil {
+			if gauge, err := c.deps.Metrics.Gauge("client_circuit_breaker_state", "0 closed, 1 half open, 2 open").
+				WithTags(monitor.Tags{"destination": destination}); err == nil {
+				b.stateGauge = gauge
+			}
+		}
+		c.breakers[destination] = b
+	}
+	return b
+}
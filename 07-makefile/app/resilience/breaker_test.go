@@ -0,0 +1,120 @@
+package resilience_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/tutorial/07-makefile/app/mortar"
+	"github.com/go-masonry/tutorial/07-makefile/app/resilience"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type breakerSuite struct {
+	suite.Suite
+	pwd      string
+	app      *fxtest.App
+	breakers resilience.CircuitBreakers
+}
+
+func TestCircuitBreakers(t *testing.T) {
+	suite.Run(t, new(breakerSuite))
+}
+
+func (s *breakerSuite) TestRESTCircuitOpensAndCloses() {
+	calls := 0
+	respond := func(statusCode int) func(*http.Request) (*http.Response, error) {
+		return func(*http.Request) (*http.Response, error) {
+			calls++
+			return &http.Response{StatusCode: statusCode}, nil
+		}
+	}
+	request, err := http.NewRequest(http.MethodGet, "http://paint-shop:8081/v1/subworkshop/paint", nil)
+	s.Require().NoError(err)
+	// client errors don't count
+	for i := 0; i < 3; i++ {
+		_, err = s.breakers.RESTClientInterceptor(request, respond(http.StatusBadRequest))
+		s.NoError(err)
+	}
+	s.Equal(resilience.Closed, s.breakers.State("paint-shop:8081"))
+	for i := 0; i < 2; i++ {
+		_, err = s.breakers.RESTClientInterceptor(request, respond(http.StatusServiceUnavailable))
+		s.NoError(err)
+	}
+	s.Equal(resilience.Open, s.breakers.State("paint-shop:8081"))
+	_, err = s.breakers.RESTClientInterceptor(request, respond(http.StatusOK))
+	s.True(errors.Is(err, resilience.ErrCircuitOpen))
+	s.Equal(5, calls)
+	// other destinations are not affected
+	other, err := http.NewRequest(http.MethodGet, "http://other:8081/", nil)
+	s.Require().NoError(err)
+	_, err = s.breakers.RESTClientInterceptor(other, respond(http.StatusOK))
+	s.NoError(err)
+	// a failed trial opens it again
+	time.Sleep(60 * time.Millisecond)
+	_, err = s.breakers.RESTClientInterceptor(request, respond(http.StatusBadGateway))
+	s.NoError(err)
+	s.Equal(resilience.Open, s.breakers.State("paint-shop:8081"))
+	// a successful one closes it
+	time.Sleep(60 * time.Millisecond)
+	_, err = s.breakers.RESTClientInterceptor(request, respond(http.StatusOK))
+	s.NoError(err)
+	s.Equal(resilience.Closed, s.breakers.State("paint-shop:8081"))
+}
+
+func (s *breakerSuite) TestGRPCCircuitOpens() {
+	cc, err := grpc.Dial("workshop:5380", grpc.WithInsecure())
+	s.Require().NoError(err)
+	defer cc.Close()
+	fail := func(code codes.Code) grpc.UnaryInvoker {
+		return func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+			return status.Error(code, "failed")
+		}
+	}
+	ctx := context.Background()
+	err = s.breakers.GRPCClientInterceptor(ctx, "/demo.workshop.Workshop/CarPainted", nil, nil, cc, fail(codes.FailedPrecondition))
+	s.Equal(codes.FailedPrecondition, status.Code(err))
+	for i := 0; i < 2; i++ {
+		err = s.breakers.GRPCClientInterceptor(ctx, "/demo.workshop.Workshop/CarPainted", nil, nil, cc, fail(codes.Unavailable))
+		s.Equal(codes.Unavailable, status.Code(err))
+	}
+	s.Equal(resilience.Open, s.breakers.State("workshop:5380"))
+	err = s.breakers.GRPCClientInterceptor(ctx, "/demo.workshop.Workshop/CarPainted", nil, nil, cc, fail(codes.OK))
+	s.Equal(codes.Unavailable, status.Code(err))
+	s.Contains(err.Error(), resilience.ErrCircuitOpen.Error())
+}
+
+func (s *breakerSuite) SetupSuite() {
+	var err error
+	s.pwd, err = os.Getwd()
+	s.Require().NoError(err)
+}
+
+func (s *breakerSuite) SetupTest() {
+	s.app = fxtest.New(s.T(),
+		fx.NopLogger, // remove fx debug prints
+		mortar.ViperFxOption(s.pwd+"/../../config/config.yml", s.pwd+"/../../config/config_test.yml"),
+		mortar.LoggerFxOption(),
+		fx.Invoke(func(config cfg.Config) {
+			config.Set(resilience.BreakerFailuresKey, 2)
+			config.Set(resilience.BreakerOpenKey, "50ms")
+			config.Set(resilience.BreakerHalfOpenRequestsKey, 1)
+		}),
+		fx.Provide(resilience.CreateCircuitBreakers),
+		fx.Populate(&s.breakers),
+	)
+	s.app.RequireStart()
+}
+
+func (s *breakerSuite) TearDownTest() {
+	s.app.RequireStop()
+}
@@ -0,0 +1,154 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/http/client"
+	"github.com/go-masonry/mortar/interfaces/log"
+	"github.com/go-masonry/mortar/interfaces/monitor"
+	"github.com/go-masonry/tutorial/07-makefile/app/noop"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// BulkheadMaxKey is how many calls to a single destination may run at the same time
+	BulkheadMaxKey = "custom.clients.bulkhead.max"
+	// BulkheadWaitKey is how long a call waits for one of the others to the same destination to finish, zero fails at once
+	BulkheadWaitKey = "custom.clients.bulkhead.wait"
+
+	defaultBulkheadMax = 10
+)
+
+// ErrBulkheadFull is returned by REST calls when too many calls to the same destination are running,
+// gRPC calls fail with ResourceExhausted
+var ErrBulkheadFull = errors.New("too many concurrent calls")
+
+// Bulkheads limits the concurrent calls per destination, a host:port for REST and a target for gRPC
+type Bulkheads interface {
+	RESTClientInterceptor(request *http.Request, handler client.HTTPpHandler) (*http.Response, error)
+	GRPCClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error
+}
+
+type bulkheadsDeps struct {
+	fx.In
+
+	Config  cfg.Config
+	Logger  log.Logger
+	Metrics monitor.Metrics `optional:"true"`
+}
+
+type bulkheads struct {
+	sync.Mutex
+	deps      bulkheadsDeps
+	max       int
+	wait      time.Duration
+	bulkheads map[string]*bulkhead
+}
+
+type bulkhead struct {
+	slots    chan struct{}
+	inFlight monitor.Gauge
+	rejected monitor.Counter
+}
+
+// CreateBulkheads is a constructor for Fx, metrics are optional
+func CreateBulkheads(deps bulkheadsDeps) Bulkheads {
+	b := &bulkheads{
+		deps:      deps,
+		max:       defaultBulkheadMax,
+		bulkheads: make(map[string]*bulkhead),
+	}
+	if value := deps.Config.Get(BulkheadMaxKey); value.IsSet() {
+		b.max = value.Int()
+	}
+	if value := deps.Config.Get(BulkheadWaitKey); value.IsSet() {
+		b.wait = value.Duration()
+	}
+	return b
+}
+
+func (b *bulkheads) RESTClientInterceptor(request *http.Request, handler client.HTTPpHandler) (*http.Response, error) {
+	release, err := b.acquire(request.Context(), request.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return handler(request)
+}
+
+func (b *bulkheads) GRPCClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	release, err := b.acquire(ctx, cc.Target())
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	defer release()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// acquire waits up to the configured time, or until ctx is done, for a free slot
+func (b *bulkheads) acquire(ctx context.Context, destination string) (release func(), err error) {
+	bh := b.bulkhead(destination)
+	select {
+	case bh.slots <- struct{}{}:
+	default:
+		if err = b.waitForSlot(ctx, bh); err != nil {
+			bh.rejected.Inc()
+			b.deps.Logger.WithError(err).Debug(ctx, "call to %s rejected", destination)
+			return nil, fmt.Errorf("%w: %d calls to %s", err, b.max, destination)
+		}
+	}
+	bh.inFlight.Inc()
+	return func() {
+		bh.inFlight.Dec()
+		<-bh.slots
+	}, nil
+}
+
+func (b *bulkheads) waitForSlot(ctx context.Context, bh *bulkhead) error {
+	if b.wait <= 0 {
+		return ErrBulkheadFull
+	}
+	timer := time.NewTimer(b.wait)
+	defer timer.Stop()
+	select {
+	case bh.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrBulkheadFull
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *bulkheads) bulkhead(destination string) *bulkhead {
+	b.Lock()
+	defer b.Unlock()
+	bh, exists := b.bulkheads[destination]
+	if !exists {
+		bh = &bulkhead{
+			slots:    make(chan struct{}, b.max),
+			inFlight: noop.Gauge{},
+			rejected: noop.Counter{},
+		}
+		if b.deps.Metrics != nil {
+			tags := monitor.Tags{"destination": destination}
+			if gauge, err := b.deps.Metrics.Gauge("client_bulkhead_in_flight", "concurrent calls").WithTags(tags); err == nil {
+				bh.inFlight = gauge
+			}
+			if counter, err := b.deps.Metrics.Counter("client_bulkhead_rejected", "calls rejected by the bulkhead").WithTags(tags); err == nil {
+				bh.rejected = counter
+			}
+		}
+		b.bulkheads[destination] = bh
+	}
+	return bh
+}
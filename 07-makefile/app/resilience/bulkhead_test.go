@@ -0,0 +1,137 @@
+package resilience_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/tutorial/07-makefile/app/mortar"
+	"github.com/go-masonry/tutorial/07-makefile/app/resilience"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type bulkheadSuite struct {
+	suite.Suite
+	pwd       string
+	app       *fxtest.App
+	bulkheads resilience.Bulkheads
+}
+
+func TestBulkheads(t *testing.T) {
+	suite.Run(t, new(bulkheadSuite))
+}
+
+func (s *bulkheadSuite) TestRESTCallsAreLimited() {
+	s.startApp(0)
+	blocked, release := s.blockedRESTCall("http://paint-shop:8081/")
+	defer close(release)
+	// the second call to the same destination is rejected at once
+	_, err := s.bulkheads.RESTClientInterceptor(s.request("http://paint-shop:8081/"), ok)
+	s.True(errors.Is(err, resilience.ErrBulkheadFull))
+	// others are not
+	_, err = s.bulkheads.RESTClientInterceptor(s.request("http://other:8081/"), ok)
+	s.NoError(err)
+	release <- struct{}{}
+	s.NoError(<-blocked)
+	_, err = s.bulkheads.RESTClientInterceptor(s.request("http://paint-shop:8081/"), ok)
+	s.NoError(err)
+}
+
+func (s *bulkheadSuite) TestCallsWaitForSlot() {
+	s.startApp(time.Second)
+	blocked, release := s.blockedRESTCall("http://paint-shop:8081/")
+	defer close(release)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		release <- struct{}{}
+	}()
+	_, err := s.bulkheads.RESTClientInterceptor(s.request("http://paint-shop:8081/"), ok)
+	s.NoError(err)
+	s.NoError(<-blocked)
+}
+
+func (s *bulkheadSuite) TestGRPCCallsAreLimited() {
+	s.startApp(0)
+	cc, err := grpc.Dial("workshop:5380", grpc.WithInsecure())
+	s.Require().NoError(err)
+	defer cc.Close()
+	started, release := make(chan struct{}), make(chan struct{})
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- s.bulkheads.GRPCClientInterceptor(context.Background(), "/demo.workshop.Workshop/CarPainted", nil, nil, cc,
+			func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+				close(started)
+				<-release
+				return nil
+			})
+	}()
+	<-started
+	err = s.bulkheads.GRPCClientInterceptor(context.Background(), "/demo.workshop.Workshop/CarPainted", nil, nil, cc,
+		func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+			return nil
+		})
+	s.Equal(codes.ResourceExhausted, status.Code(err))
+	close(release)
+	s.NoError(<-blocked)
+}
+
+// blockedRESTCall starts a call that takes a slot until something is sent to release
+func (s *bulkheadSuite) blockedRESTCall(url string) (<-chan error, chan struct{}) {
+	started, release := make(chan struct{}), make(chan struct{})
+	blocked := make(chan error, 1)
+	go func() {
+		_, err := s.bulkheads.RESTClientInterceptor(s.request(url), func(*http.Request) (*http.Response, error) {
+			close(started)
+			<-release
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		})
+		blocked <- err
+	}()
+	<-started
+	return blocked, release
+}
+
+func (s *bulkheadSuite) request(url string) *http.Request {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	s.Require().NoError(err)
+	return request
+}
+
+func ok(*http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func (s *bulkheadSuite) SetupSuite() {
+	var err error
+	s.pwd, err = os.Getwd()
+	s.Require().NoError(err)
+}
+
+// startApp allows a single call per destination, waiting up to wait for it to finish
+func (s *bulkheadSuite) startApp(wait time.Duration) {
+	s.app = fxtest.New(s.T(),
+		fx.NopLogger, // remove fx debug prints
+		mortar.ViperFxOption(s.pwd+"/../../config/config.yml", s.pwd+"/../../config/config_test.yml"),
+		mortar.LoggerFxOption(),
+		fx.Invoke(func(config cfg.Config) {
+			config.Set(resilience.BulkheadMaxKey, 1)
+			config.Set(resilience.BulkheadWaitKey, wait.String())
+		}),
+		fx.Provide(resilience.CreateBulkheads),
+		fx.Populate(&s.bulkheads),
+	)
+	s.app.RequireStart()
+}
+
+func (s *bulkheadSuite) TearDownTest() {
+	s.app.RequireStop()
+}
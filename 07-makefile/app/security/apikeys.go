@@ -0,0 +1,41 @@
+package security
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+)
+
+// apiKeys accepts the static keys of AuthAPIKeysKey
+type apiKeys struct {
+	names map[string]string // by key
+}
+
+// newAPIKeys returns nil when no key is configured
+func newAPIKeys(config cfg.Config) *apiKeys {
+	keys := &apiKeys{names: make(map[string]string)}
+	for name := range config.Get(AuthAPIKeysKey).StringMap() {
+		if key := config.Get(AuthAPIKeysKey + "." + name).String(); len(key) > 0 {
+			keys.names[key] = name
+		}
+	}
+	if len(keys.names) == 0 {
+		return nil
+	}
+	return keys
+}
+
+func (k *apiKeys) Authenticate(_ context.Context, credentials string) (Principal, error) {
+	// compare with every key so that the time it takes doesn't tell which one is close
+	var subject string
+	for key, name := range k.names {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(credentials)) == 1 {
+			subject = name
+		}
+	}
+	if len(subject) == 0 {
+		return Principal{}, ErrUnknownCredentials
+	}
+	return Principal{Subject: subject, Method: "apikey"}, nil
+}
@@ -0,0 +1,235 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/http/client"
+	"github.com/go-masonry/mortar/interfaces/log"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// AuthAPIKeysKey maps the name of every caller to its static API key, e.g. custom.auth.apikeys.subworkshop
+	AuthAPIKeysKey = "custom.auth.apikeys"
+	// AuthPublicMethodsKey lists gRPC methods anyone may call, path.Match patterns of the full method name,
+	// e.g. /grpc.health.v1.Health/*
+	AuthPublicMethodsKey = "custom.auth.public"
+	// AuthClientTokenKey is the API key or JWT this service presents when it calls other services
+	AuthClientTokenKey = "custom.auth.client.token"
+	// AuthInsecureKey lets every call through without credentials when no authenticator is configured,
+	// only public methods may be called then otherwise. Meant for local development.
+	AuthInsecureKey = "custom.auth.insecure"
+
+	// AuthenticatorsGroup is the Fx group of additional Authenticator implementations
+	AuthenticatorsGroup = "authenticators"
+
+	authorizationHeader = "authorization"
+	bearerScheme        = "bearer "
+)
+
+// ErrUnknownCredentials is returned by an Authenticator for credentials it doesn't handle, the next one is asked then
+var ErrUnknownCredentials = errors.New("unknown credentials")
+
+// Principal is who called us
+type Principal struct {
+	// Subject is the name of an API key or the subject of a JWT
+	Subject string
+	// Method is the authenticator that accepted the credentials, e.g. apikey or jwt
+	Method string
+	// Roles the credentials carry, API keys have none
+	Roles []string
+}
+
+// Authenticator checks the bearer credentials of a call
+type Authenticator interface {
+	Authenticate(ctx context.Context, credentials string) (Principal, error)
+}
+
+// Authentication rejects calls without valid credentials, the principal of the others is put on their context.
+// When no authenticator is configured only public methods may be called, unless AuthInsecureKey is set.
+type Authentication interface {
+	UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error)
+	StreamServerInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error
+	// RESTClientInterceptor adds AuthClientTokenKey to calls that don't have credentials
+	RESTClientInterceptor(request *http.Request, handler client.HTTPpHandler) (*http.Response, error)
+	// GRPCClientInterceptor adds AuthClientTokenKey to calls that don't have credentials
+	GRPCClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error
+}
+
+type authenticationDeps struct {
+	fx.In
+
+	Config         cfg.Config
+	Logger         log.Logger
+	Authenticators []Authenticator `group:"authenticators"`
+}
+
+type authentication struct {
+	logger         log.Logger
+	authenticators []Authenticator
+	public         []string
+	insecure       bool
+	clientToken    string
+}
+
+// CreateAuthentication is a constructor for Fx, API keys and JWT are used when they are configured
+func CreateAuthentication(deps authenticationDeps) (Authentication, error) {
+	a := &authentication{
+		logger:      deps.Logger,
+		public:      deps.Config.Get(AuthPublicMethodsKey).StringSlice(),
+		insecure:    deps.Config.Get(AuthInsecureKey).Bool(),
+		clientToken: deps.Config.Get(AuthClientTokenKey).String(),
+	}
+	for _, pattern := range a.public {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s has an invalid pattern %s, %w", AuthPublicMethodsKey, pattern, err)
+		}
+	}
+	if keys := newAPIKeys(deps.Config); keys != nil {
+		a.authenticators = append(a.authenticators, keys)
+	}
+	jwt, err := newJWTs(deps.Config)
+	if err != nil {
+		return nil, err
+	}
+	if jwt != nil {
+		a.authenticators = append(a.authenticators, jwt)
+	}
+	for _, authenticator := range deps.Authenticators {
+		if authenticator != nil {
+			a.authenticators = append(a.authenticators, authenticator)
+		}
+	}
+	switch {
+	case len(a.authenticators) > 0:
+	case a.insecure:
+		deps.Logger.Warn(context.Background(), "no authenticator is configured and %s is set, every call is let through", AuthInsecureKey)
+	default:
+		deps.Logger.Warn(context.Background(), "no authenticator is configured, only public methods may be called")
+	}
+	return a, nil
+}
+
+func (a *authentication) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authentication) StreamServerInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+func (a *authentication) RESTClientInterceptor(request *http.Request, handler client.HTTPpHandler) (*http.Response, error) {
+	if len(a.clientToken) > 0 && len(request.Header.Get(authorizationHeader)) == 0 {
+		request.Header.Set(authorizationHeader, "Bearer "+a.clientToken)
+	}
+	return handler(request)
+}
+
+func (a *authentication) GRPCClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if len(a.clientToken) > 0 {
+		if md, _ := metadata.FromOutgoingContext(ctx); len(md.Get(authorizationHeader)) == 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, authorizationHeader, "Bearer "+a.clientToken)
+		}
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// authenticate returns ctx with the principal of the caller, public methods may be called without credentials
+func (a *authentication) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if matchesAny(a.public, method) {
+		return ctx, nil
+	}
+	if len(a.authenticators) == 0 {
+		if a.insecure {
+			return ctx, nil
+		}
+		a.logger.Debug(ctx, "%s called but no authenticator is configured", method)
+		return nil, status.Errorf(codes.Unauthenticated, "%s needs credentials but none are accepted", method)
+	}
+	credentials, found := bearerCredentials(ctx)
+	if !found {
+		a.logger.Debug(ctx, "%s called without credentials", method)
+		return nil, status.Errorf(codes.Unauthenticated, "%s needs bearer credentials", method)
+	}
+	for _, authenticator := range a.authenticators {
+		principal, err := authenticator.Authenticate(ctx, credentials)
+		if errors.Is(err, ErrUnknownCredentials) {
+			continue
+		}
+		if err != nil {
+			a.logger.WithError(err).Warn(ctx, "%s called with invalid credentials", method)
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+		return WithPrincipal(ctx, principal), nil
+	}
+	a.logger.Warn(ctx, "%s called with unknown credentials", method)
+	return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+}
+
+// matchesAny tells if method matches one of the path.Match patterns
+func matchesAny(patterns []string, method string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, method); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func bearerCredentials(ctx context.Context) (string, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get(authorizationHeader) {
+		if len(value) > len(bearerScheme) && strings.EqualFold(value[:len(bearerScheme)], bearerScheme) {
+			return strings.TrimSpace(value[len(bearerScheme):]), true
+		}
+	}
+	return "", false
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx that carries principal
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the caller, found is false for public methods and when AuthInsecureKey lets calls through
+func PrincipalFromContext(ctx context.Context) (principal Principal, found bool) {
+	principal, found = ctx.Value(principalKey{}).(Principal)
+	return
+}
+
+// PrincipalContextExtractor adds the caller to every log line of a call
+func PrincipalContextExtractor(ctx context.Context) map[string]interface{} {
+	if principal, found := PrincipalFromContext(ctx); found {
+		return map[string]interface{}{"principal": principal.Subject}
+	}
+	return nil
+}
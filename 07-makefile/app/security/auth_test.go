@@ -0,0 +1,285 @@
+package security_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/tutorial/07-makefile/app/mortar"
+	"github.com/go-masonry/tutorial/07-makefile/app/security"
+	"github.com/go-masonry/tutorial/07-makefile/app/security/securitytest"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const paintCarMethod = "/tutorial.workshop.Workshop/PaintCar"
+
+type authSuite struct {
+	suite.Suite
+
+	pwd string
+	dir string
+}
+
+func TestAuth(t *testing.T) {
+	suite.Run(t, new(authSuite))
+}
+
+func (s *authSuite) SetupTest() {
+	var err error
+	s.pwd, err = os.Getwd()
+	s.Require().NoError(err)
+	s.dir, err = ioutil.TempDir("", "auth")
+	s.Require().NoError(err)
+}
+
+func (s *authSuite) TearDownTest() {
+	s.NoError(os.RemoveAll(s.dir))
+}
+
+func (s *authSuite) TestAPIKeys() {
+	auth := s.startAuth(map[string]interface{}{
+		security.AuthAPIKeysKey: map[string]interface{}{"subworkshop": "sub-key", "admin": "admin-key"},
+	})
+
+	principal, err := s.call(auth, paintCarMethod, "Bearer sub-key")
+	s.Require().NoError(err)
+	s.Equal(security.Principal{Subject: "subworkshop", Method: "apikey"}, principal)
+
+	_, err = s.call(auth, paintCarMethod, "bearer admin-key")
+	s.NoError(err, "the scheme is case insensitive")
+
+	_, err = s.call(auth, paintCarMethod, "Bearer guessed-key")
+	s.Equal(codes.Unauthenticated, status.Code(err))
+
+	_, err = s.call(auth, paintCarMethod, "Basic c3ViLWtleQ==")
+	s.Equal(codes.Unauthenticated, status.Code(err))
+
+	_, err = s.call(auth, paintCarMethod, "")
+	s.Equal(codes.Unauthenticated, status.Code(err))
+
+	// health checks are public in config.yml
+	_, err = s.call(auth, "/grpc.health.v1.Health/Check", "")
+	s.NoError(err)
+}
+
+func (s *authSuite) TestHS256() {
+	secret := []byte("hs256 secret")
+	auth := s.startAuth(map[string]interface{}{
+		security.AuthJWTSecretKey:   string(secret),
+		security.AuthJWTIssuerKey:   "tutorial",
+		security.AuthJWTAudienceKey: "workshop",
+	})
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub":   "painter",
+			"iss":   "tutorial",
+			"aud":   []string{"workshop", "subworkshop"},
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"roles": []string{"painter"},
+		}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+
+	token, err := securitytest.SignHS256(secret, claims(nil))
+	s.Require().NoError(err)
+	principal, err := s.call(auth, paintCarMethod, "Bearer "+token)
+	s.Require().NoError(err)
+	s.Equal(security.Principal{Subject: "painter", Method: "jwt", Roles: []string{"painter"}}, principal)
+
+	for name, overrides := range map[string]map[string]interface{}{
+		"expired":        {"exp": time.Now().Add(-time.Hour).Unix()},
+		"never expires":  {"exp": nil},
+		"not valid yet":  {"nbf": time.Now().Add(time.Hour).Unix()},
+		"other issuer":   {"iss": "someone else"},
+		"other audience": {"aud": "subworkshop"},
+		"no subject":     {"sub": ""},
+	} {
+		token, err = securitytest.SignHS256(secret, claims(overrides))
+		s.Require().NoError(err)
+		_, err = s.call(auth, paintCarMethod, "Bearer "+token)
+		s.Equal(codes.Unauthenticated, status.Code(err), name)
+	}
+
+	token, err = securitytest.SignHS256([]byte("other secret"), claims(nil))
+	s.Require().NoError(err)
+	_, err = s.call(auth, paintCarMethod, "Bearer "+token)
+	s.Equal(codes.Unauthenticated, status.Code(err), "signed with another secret")
+
+	// unsigned tokens are never accepted
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + strings.Split(token, ".")[1] + "."
+	_, err = s.call(auth, paintCarMethod, "Bearer "+unsigned)
+	s.Equal(codes.Unauthenticated, status.Code(err), "alg none")
+}
+
+func (s *authSuite) TestRS256() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	s.Require().NoError(err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	s.Require().NoError(err)
+	jwks := filepath.Join(s.dir, "jwks.json")
+	s.Require().NoError(securitytest.WriteJWKS(jwks, map[string]*rsa.PublicKey{"key1": &key.PublicKey}))
+	auth := s.startAuth(map[string]interface{}{
+		security.AuthJWTKeySetKey: jwks,
+	})
+	claims := map[string]interface{}{"sub": "workshop", "exp": time.Now().Add(time.Hour).Unix()}
+
+	token, err := securitytest.SignRS256(key, "key1", claims)
+	s.Require().NoError(err)
+	principal, err := s.call(auth, paintCarMethod, "Bearer "+token)
+	s.Require().NoError(err)
+	s.Equal("workshop", principal.Subject)
+
+	token, err = securitytest.SignRS256(key, "", claims)
+	s.Require().NoError(err)
+	_, err = s.call(auth, paintCarMethod, "Bearer "+token)
+	s.NoError(err, "the only key is used when there is no kid")
+
+	token, err = securitytest.SignRS256(otherKey, "key1", claims)
+	s.Require().NoError(err)
+	_, err = s.call(auth, paintCarMethod, "Bearer "+token)
+	s.Equal(codes.Unauthenticated, status.Code(err), "signed with another key")
+
+	token, err = securitytest.SignRS256(key, "key2", claims)
+	s.Require().NoError(err)
+	_, err = s.call(auth, paintCarMethod, "Bearer "+token)
+	s.Equal(codes.Unauthenticated, status.Code(err), "unknown kid")
+
+	// HS256 isn't configured, the public key can't be used as a secret
+	token, err = securitytest.SignHS256(key.PublicKey.N.Bytes(), claims)
+	s.Require().NoError(err)
+	_, err = s.call(auth, paintCarMethod, "Bearer "+token)
+	s.Equal(codes.Unauthenticated, status.Code(err), "HS256")
+}
+
+func (s *authSuite) TestStreams() {
+	auth := s.startAuth(map[string]interface{}{
+		security.AuthAPIKeysKey: map[string]interface{}{"watcher": "watch-key"},
+	})
+	info := &grpc.StreamServerInfo{FullMethod: "/tutorial.workshop.Workshop/WatchCars", IsServerStream: true}
+	var principal security.Principal
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		principal, _ = security.PrincipalFromContext(stream.Context())
+		return nil
+	}
+
+	err := auth.StreamServerInterceptor(nil, &fakeServerStream{ctx: incoming("Bearer watch-key")}, info, handler)
+	s.NoError(err)
+	s.Equal("watcher", principal.Subject)
+
+	err = auth.StreamServerInterceptor(nil, &fakeServerStream{ctx: incoming("")}, info, handler)
+	s.Equal(codes.Unauthenticated, status.Code(err))
+}
+
+func (s *authSuite) TestWithoutAuthenticators() {
+	// without the development key of config.yml
+	noKeys := map[string]interface{}{}
+	auth := s.startAuth(map[string]interface{}{
+		security.AuthAPIKeysKey: noKeys,
+	})
+	_, err := s.call(auth, paintCarMethod, "")
+	s.Equal(codes.Unauthenticated, status.Code(err))
+	_, err = s.call(auth, "/grpc.health.v1.Health/Check", "")
+	s.NoError(err)
+
+	auth = s.startAuth(map[string]interface{}{
+		security.AuthAPIKeysKey:  noKeys,
+		security.AuthInsecureKey: true,
+	})
+	principal, err := s.call(auth, paintCarMethod, "")
+	s.NoError(err)
+	s.Empty(principal.Subject)
+}
+
+func (s *authSuite) TestClientToken() {
+	auth := s.startAuth(map[string]interface{}{
+		security.AuthClientTokenKey: "client-key",
+	})
+
+	request, err := http.NewRequest(http.MethodPost, "http://subworkshop/v1/subworkshop/paint", nil)
+	s.Require().NoError(err)
+	_, err = auth.RESTClientInterceptor(request, func(*http.Request) (*http.Response, error) { return nil, nil })
+	s.NoError(err)
+	s.Equal("Bearer client-key", request.Header.Get("Authorization"))
+
+	var md metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	s.NoError(auth.GRPCClientInterceptor(context.Background(), paintCarMethod, nil, nil, nil, invoker))
+	s.Equal([]string{"Bearer client-key"}, md.Get("authorization"))
+
+	// credentials of the caller are kept
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer user-key")
+	s.NoError(auth.GRPCClientInterceptor(ctx, paintCarMethod, nil, nil, nil, invoker))
+	s.Equal([]string{"Bearer user-key"}, md.Get("authorization"))
+}
+
+func (s *authSuite) TestPrincipalContextExtractor() {
+	s.Empty(security.PrincipalContextExtractor(context.Background()))
+	ctx := security.WithPrincipal(context.Background(), security.Principal{Subject: "painter", Method: "jwt"})
+	s.Equal(map[string]interface{}{"principal": "painter"}, security.PrincipalContextExtractor(ctx))
+}
+
+func (s *authSuite) startAuth(settings map[string]interface{}) security.Authentication {
+	var auth security.Authentication
+	app := fxtest.New(s.T(),
+		fx.NopLogger,
+		mortar.ViperFxOption(s.pwd+"/../../config/config.yml", s.pwd+"/../../config/config_test.yml"),
+		mortar.LoggerFxOption(),
+		fx.Invoke(func(config cfg.Config) {
+			for key, value := range settings {
+				config.Set(key, value)
+			}
+		}),
+		fx.Provide(security.CreateAuthentication),
+		fx.Populate(&auth),
+	)
+	app.RequireStart()
+	s.T().Cleanup(app.RequireStop)
+	return auth
+}
+
+// call returns the principal the handler of method sees
+func (s *authSuite) call(auth security.Authentication, method, authorization string) (principal security.Principal, err error) {
+	_, err = auth.UnaryServerInterceptor(incoming(authorization), nil, &grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, _ interface{}) (interface{}, error) {
+			principal, _ = security.PrincipalFromContext(ctx)
+			return nil, nil
+		})
+	return
+}
+
+func incoming(authorization string) context.Context {
+	if len(authorization) == 0 {
+		return context.Background()
+	}
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", authorization))
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (f *fakeServerStream) Context() context.Context {
+	return f.ctx
+}
@@ -0,0 +1,228 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/log"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// AuthzRulesKey lists who may call which gRPC methods, see AuthorizationRule. The first rule that matches a method is used.
+	AuthzRulesKey = "custom.authz.rules"
+	// AuthzSubjectRolesKey grants roles to subjects on top of the roles their credentials carry,
+	// e.g. custom.authz.subjects.subworkshop: [subworkshop]
+	AuthzSubjectRolesKey = "custom.authz.subjects"
+	// AuthzDefaultKey is what happens to calls of methods no rule matches, allow or deny
+	AuthzDefaultKey = "custom.authz.default"
+
+	// OwnersGroup is the Fx group of OwnerLookup implementations
+	OwnersGroup = "owners"
+	// AnyRole in a rule lets every authenticated caller through
+	AnyRole = "*"
+
+	authzAllow   = "allow"
+	authzDeny    = "deny"
+	defaultAuthz = authzDeny
+)
+
+// AuthorizationRule lets callers with any of Roles, or the owner of the resource the request is about, call Method
+type AuthorizationRule struct {
+	// Method is a path.Match pattern of full gRPC method names, e.g. /tutorial.workshop.Workshop/*
+	Method string
+	Roles  []string
+	Owner  *OwnershipRule
+}
+
+// OwnershipRule finds the resource of a request
+type OwnershipRule struct {
+	// Kind of the resource, one of the OwnerLookup kinds
+	Kind string
+	// Field of the request with the resource ID, e.g. car_number, fields of messages are separated by dots, e.g. car.number
+	Field string
+}
+
+// OwnerLookup finds who owns the resources of a kind, provide it to the OwnersGroup
+type OwnerLookup struct {
+	Kind  string
+	Owner func(ctx context.Context, id string) (subject string, err error)
+}
+
+// Authorization rejects calls the policy doesn't allow with PermissionDenied, every decision is logged for auditing.
+// Calls without a principal are rejected unless their method is public, see AuthPublicMethodsKey,
+// or AuthInsecureKey lets them through.
+type Authorization interface {
+	UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error)
+	// StreamServerInterceptor checks roles only, the request of a stream isn't read yet so ownership can't be checked
+	StreamServerInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error
+}
+
+type authorizationDeps struct {
+	fx.In
+
+	Config cfg.Config
+	Logger log.Logger
+	Owners []OwnerLookup `group:"owners"`
+}
+
+type authorization struct {
+	logger       log.Logger
+	rules        []AuthorizationRule
+	subjectRoles map[string][]string
+	public       []string
+	insecure     bool // calls without a principal are let through
+	allowByRule  bool // when no rule matches
+	owners       map[string]OwnerLookup
+}
+
+// CreateAuthorization is a constructor for Fx, it fails if the policy refers to unknown resource kinds
+func CreateAuthorization(deps authorizationDeps) (Authorization, error) {
+	a := &authorization{
+		logger:       deps.Logger,
+		subjectRoles: make(map[string][]string),
+		public:       deps.Config.Get(AuthPublicMethodsKey).StringSlice(),
+		insecure:     deps.Config.Get(AuthInsecureKey).Bool(),
+		owners:       make(map[string]OwnerLookup),
+	}
+	for _, owner := range deps.Owners {
+		a.owners[owner.Kind] = owner
+	}
+	if err := deps.Config.Get(AuthzRulesKey).Unmarshal(&a.rules); err != nil {
+		return nil, fmt.Errorf("can't read %s, %w", AuthzRulesKey, err)
+	}
+	for i, rule := range a.rules {
+		if _, err := path.Match(rule.Method, ""); err != nil || len(rule.Method) == 0 {
+			return nil, fmt.Errorf("rule %d of %s has an invalid method %q", i, AuthzRulesKey, rule.Method)
+		}
+		if rule.Owner != nil {
+			if _, exists := a.owners[rule.Owner.Kind]; !exists {
+				return nil, fmt.Errorf("rule %d of %s refers to unknown resources %q", i, AuthzRulesKey, rule.Owner.Kind)
+			}
+			if len(rule.Owner.Field) == 0 {
+				return nil, fmt.Errorf("rule %d of %s doesn't say which field has the %s", i, AuthzRulesKey, rule.Owner.Kind)
+			}
+		}
+	}
+	for subject := range deps.Config.Get(AuthzSubjectRolesKey).StringMap() {
+		a.subjectRoles[subject] = deps.Config.Get(AuthzSubjectRolesKey + "." + subject).StringSlice()
+	}
+	switch decision := deps.Config.Get(AuthzDefaultKey).String(); decision {
+	case authzAllow:
+		a.allowByRule = true
+	case authzDeny, "":
+	default:
+		return nil, fmt.Errorf("%s must be %s or %s, not %q", AuthzDefaultKey, authzAllow, authzDeny, decision)
+	}
+	return a, nil
+}
+
+func (a *authorization) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := a.authorize(ctx, info.FullMethod, req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authorization) StreamServerInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.authorize(stream.Context(), info.FullMethod, nil); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+func (a *authorization) authorize(ctx context.Context, method string, req interface{}) error {
+	principal, found := PrincipalFromContext(ctx)
+	if !found {
+		if a.insecure || matchesAny(a.public, method) {
+			return nil
+		}
+		a.logger.WithField("audit", "authorization").WithField("method", method).WithField("allowed", false).
+			Warn(ctx, "anonymous caller may not call %s, it isn't public", method)
+		return status.Errorf(codes.PermissionDenied, "%s isn't public", method)
+	}
+	allowed, reason := a.decide(ctx, principal, method, req)
+	audit := a.logger.WithField("audit", "authorization").WithField("method", method).WithField("allowed", allowed)
+	if allowed {
+		audit.Info(ctx, "%s may call %s, %s", principal.Subject, method, reason)
+		return nil
+	}
+	audit.Warn(ctx, "%s may not call %s, %s", principal.Subject, method, reason)
+	return status.Errorf(codes.PermissionDenied, "%s may not call %s", principal.Subject, method)
+}
+
+// decide returns if principal may call method and why
+func (a *authorization) decide(ctx context.Context, principal Principal, method string, req interface{}) (allowed bool, reason string) {
+	rule := a.rule(method)
+	if rule == nil {
+		if a.allowByRule {
+			return true, "no rule denies it"
+		}
+		return false, "no rule allows it"
+	}
+	roles := append(append([]string(nil), principal.Roles...), a.subjectRoles[principal.Subject]...)
+	for _, role := range rule.Roles {
+		if role == AnyRole {
+			return true, "any caller is allowed"
+		}
+		if contains(roles, role) {
+			return true, "it has role " + role
+		}
+	}
+	if rule.Owner == nil {
+		return false, fmt.Sprintf("it has none of the roles %v", rule.Roles)
+	}
+	if req == nil {
+		return false, "ownership of streams can't be checked"
+	}
+	id, found := stringField(req, rule.Owner.Field)
+	if !found {
+		return false, fmt.Sprintf("the request has no %s", rule.Owner.Field)
+	}
+	owner, err := a.owners[rule.Owner.Kind].Owner(ctx, id)
+	if err != nil {
+		return false, fmt.Sprintf("the owner of %s %s is unknown, %v", rule.Owner.Kind, id, err)
+	}
+	if len(owner) > 0 && owner == principal.Subject {
+		return true, fmt.Sprintf("it owns %s %s", rule.Owner.Kind, id)
+	}
+	return false, fmt.Sprintf("it doesn't own %s %s and has none of the roles %v", rule.Owner.Kind, id, rule.Roles)
+}
+
+func (a *authorization) rule(method string) *AuthorizationRule {
+	for i := range a.rules {
+		if matched, _ := path.Match(a.rules[i].Method, method); matched {
+			return &a.rules[i]
+		}
+	}
+	return nil
+}
+
+// stringField returns the value of a string field of a proto request, field may be a dotted path through messages
+func stringField(req interface{}, field string) (string, bool) {
+	message, ok := req.(protoreflect.ProtoMessage)
+	if !ok {
+		return "", false
+	}
+	reflected := message.ProtoReflect()
+	names := strings.Split(field, ".")
+	for _, name := range names[:len(names)-1] {
+		descriptor := reflected.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if descriptor == nil || descriptor.Kind() != protoreflect.MessageKind || descriptor.IsList() || descriptor.IsMap() {
+			return "", false
+		}
+		reflected = reflected.Get(descriptor).Message()
+	}
+	descriptor := reflected.Descriptor().Fields().ByName(protoreflect.Name(names[len(names)-1]))
+	if descriptor == nil || descriptor.Kind() != protoreflect.StringKind || descriptor.IsList() {
+		return "", false
+	}
+	return reflected.Get(descriptor).String(), true
+}
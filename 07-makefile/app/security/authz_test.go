@@ -0,0 +1,179 @@
+package security_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	workshop "github.com/go-masonry/tutorial/07-makefile/api"
+	"github.com/go-masonry/tutorial/07-makefile/app/mortar"
+	"github.com/go-masonry/tutorial/07-makefile/app/security"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	subWorkshop = security.Principal{Subject: "subworkshop", Method: "apikey"}
+	frontDesk   = security.Principal{Subject: "dana", Method: "jwt", Roles: []string{"frontdesk"}}
+	owner       = security.Principal{Subject: "alice", Method: "jwt"}
+)
+
+type authzSuite struct {
+	suite.Suite
+
+	pwd string
+}
+
+func TestAuthz(t *testing.T) {
+	suite.Run(t, new(authzSuite))
+}
+
+func (s *authzSuite) SetupTest() {
+	var err error
+	s.pwd, err = os.Getwd()
+	s.Require().NoError(err)
+}
+
+// TestPolicy checks the policy of config.yml
+func (s *authzSuite) TestPolicy() {
+	authz, err := s.startAuthz(nil)
+	s.Require().NoError(err)
+
+	for _, test := range []struct {
+		principal security.Principal
+		method    string
+		req       interface{}
+		code      codes.Code
+	}{
+		{subWorkshop, "/tutorial.workshop.Workshop/CarPainted", &workshop.PaintFinishedRequest{}, codes.OK},
+		{frontDesk, "/tutorial.workshop.Workshop/CarPainted", &workshop.PaintFinishedRequest{}, codes.PermissionDenied},
+		{owner, "/tutorial.workshop.Workshop/CarPainted", &workshop.PaintFinishedRequest{}, codes.PermissionDenied},
+		{frontDesk, "/tutorial.workshop.Workshop/AcceptCar", &workshop.Car{}, codes.OK},
+		{subWorkshop, "/tutorial.workshop.Workshop/AcceptCar", &workshop.Car{}, codes.PermissionDenied},
+		{owner, "/tutorial.workshop.Workshop/RetrieveCar", &workshop.RetrieveCarRequest{CarNumber: "alice-car"}, codes.OK},
+		{owner, "/tutorial.workshop.Workshop/RetrieveCar", &workshop.RetrieveCarRequest{CarNumber: "bob-car"}, codes.PermissionDenied},
+		{owner, "/tutorial.workshop.Workshop/RetrieveCar", &workshop.RetrieveCarRequest{CarNumber: "no-such-car"}, codes.PermissionDenied},
+		{frontDesk, "/tutorial.workshop.Workshop/RetrieveCar", &workshop.RetrieveCarRequest{CarNumber: "bob-car"}, codes.OK},
+		{owner, "/tutorial.workshop.Workshop/UpdateCar", &workshop.UpdateCarRequest{Car: &workshop.Car{Number: "alice-car"}}, codes.OK},
+		{owner, "/tutorial.workshop.Workshop/UpdateCar", &workshop.UpdateCarRequest{Car: &workshop.Car{Number: "bob-car"}}, codes.PermissionDenied},
+		{owner, "/tutorial.workshop.Workshop/UpdateCar", &workshop.UpdateCarRequest{}, codes.PermissionDenied},
+		{frontDesk, "/tutorial.workshop.Workshop/UpdateCar", &workshop.UpdateCarRequest{Car: &workshop.Car{Number: "bob-car"}}, codes.OK},
+		{owner, "/tutorial.workshop.Workshop/GetCarHistory", &workshop.GetCarHistoryRequest{CarNumber: "alice-car"}, codes.OK},
+		{owner, "/tutorial.workshop.Workshop/GetCarHistory", &workshop.GetCarHistoryRequest{CarNumber: "bob-car"}, codes.PermissionDenied},
+		{frontDesk, "/tutorial.workshop.Workshop/GetCarHistory", &workshop.GetCarHistoryRequest{CarNumber: "bob-car"}, codes.OK},
+		{owner, "/tutorial.workshop.Workshop/ListCars", &workshop.ListCarsRequest{}, codes.OK},
+		{owner, "/tutorial.workshop.SubWorkshop/PaintCar", &workshop.SubPaintCarRequest{}, codes.PermissionDenied},
+		{owner, "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo", nil, codes.PermissionDenied},
+	} {
+		_, err := authz.UnaryServerInterceptor(security.WithPrincipal(context.Background(), test.principal), test.req,
+			&grpc.UnaryServerInfo{FullMethod: test.method}, func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+		s.Equal(test.code, status.Code(err), "%s calling %s with %v", test.principal.Subject, test.method, test.req)
+	}
+
+}
+
+func (s *authzSuite) TestAnonymousCallers() {
+	authz, err := s.startAuthz(nil)
+	s.Require().NoError(err)
+	handler := func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+
+	// only public methods may be called without a principal
+	_, err = authz.UnaryServerInterceptor(context.Background(), &workshop.Car{}, &grpc.UnaryServerInfo{FullMethod: "/tutorial.workshop.Workshop/AcceptCar"}, handler)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+	_, err = authz.UnaryServerInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+	s.NoError(err)
+	err = authz.StreamServerInterceptor(nil, &fakeServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/tutorial.workshop.Workshop/WatchCars"},
+		func(interface{}, grpc.ServerStream) error { return nil })
+	s.Equal(codes.PermissionDenied, status.Code(err))
+	err = authz.StreamServerInterceptor(nil, &fakeServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/grpc.health.v1.Health/Watch"},
+		func(interface{}, grpc.ServerStream) error { return nil })
+	s.NoError(err)
+
+	// unless authentication is explicitly off
+	authz, err = s.startAuthz(map[string]interface{}{
+		security.AuthInsecureKey: true,
+	})
+	s.Require().NoError(err)
+	_, err = authz.UnaryServerInterceptor(context.Background(), &workshop.Car{}, &grpc.UnaryServerInfo{FullMethod: "/tutorial.workshop.Workshop/AcceptCar"}, handler)
+	s.NoError(err)
+}
+
+func (s *authzSuite) TestStreams() {
+	authz, err := s.startAuthz(map[string]interface{}{
+		security.AuthzRulesKey: []interface{}{
+			map[string]interface{}{"method": "/tutorial.workshop.Workshop/WatchCars", "roles": []string{"frontdesk"}, "owner": map[string]interface{}{"kind": "car", "field": "car_number"}},
+		},
+	})
+	s.Require().NoError(err)
+	info := &grpc.StreamServerInfo{FullMethod: "/tutorial.workshop.Workshop/WatchCars", IsServerStream: true}
+	handler := func(interface{}, grpc.ServerStream) error { return nil }
+
+	err = authz.StreamServerInterceptor(nil, &fakeServerStream{ctx: security.WithPrincipal(context.Background(), frontDesk)}, info, handler)
+	s.NoError(err)
+	err = authz.StreamServerInterceptor(nil, &fakeServerStream{ctx: security.WithPrincipal(context.Background(), owner)}, info, handler)
+	s.Equal(codes.PermissionDenied, status.Code(err), "ownership of streams can't be checked")
+}
+
+func (s *authzSuite) TestDefaultAllow() {
+	authz, err := s.startAuthz(map[string]interface{}{
+		security.AuthzDefaultKey: "allow",
+	})
+	s.Require().NoError(err)
+	_, err = authz.UnaryServerInterceptor(security.WithPrincipal(context.Background(), owner), nil,
+		&grpc.UnaryServerInfo{FullMethod: "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo"},
+		func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+	s.NoError(err)
+}
+
+func (s *authzSuite) TestInvalidPolicy() {
+	_, err := s.startAuthz(map[string]interface{}{
+		security.AuthzRulesKey: []interface{}{
+			map[string]interface{}{"method": "/tutorial.workshop.Workshop/RetrieveCar", "owner": map[string]interface{}{"kind": "boat", "field": "boat_number"}},
+		},
+	})
+	s.Require().Error(err)
+	s.Contains(err.Error(), `rule 0 of custom.authz.rules refers to unknown resources "boat"`)
+
+	_, err = s.startAuthz(map[string]interface{}{
+		security.AuthzDefaultKey: "maybe",
+	})
+	s.Require().Error(err)
+	s.Contains(err.Error(), `custom.authz.default must be allow or deny, not "maybe"`)
+}
+
+func (s *authzSuite) startAuthz(settings map[string]interface{}) (security.Authorization, error) {
+	var authz security.Authorization
+	app := fx.New(
+		fx.NopLogger,
+		mortar.ViperFxOption(s.pwd+"/../../config/config.yml", s.pwd+"/../../config/config_test.yml"),
+		mortar.LoggerFxOption(),
+		fx.Invoke(func(config cfg.Config) {
+			for key, value := range settings {
+				config.Set(key, value)
+			}
+		}),
+		fx.Provide(fx.Annotated{
+			Group: security.OwnersGroup,
+			Target: func() security.OwnerLookup {
+				return security.OwnerLookup{Kind: "car", Owner: func(_ context.Context, carNumber string) (string, error) {
+					owners := map[string]string{"alice-car": "alice", "bob-car": "bob"}
+					if owner, exists := owners[carNumber]; exists {
+						return owner, nil
+					}
+					return "", errors.New("not found")
+				}}
+			},
+		}),
+		fx.Provide(security.CreateAuthorization),
+		fx.Populate(&authz),
+	)
+	if err := app.Err(); err != nil {
+		return nil, err
+	}
+	return authz, nil
+}
@@ -0,0 +1,34 @@
+package security
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// reloadingCredentials are gRPC TLS credentials of the latest certificates, every handshake gets them anew
+type reloadingCredentials struct {
+	files *tlsFiles
+}
+
+func (r *reloadingCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return credentials.NewTLS(r.files.ClientConfig()).ClientHandshake(ctx, authority, rawConn)
+}
+
+func (r *reloadingCredentials) ServerHandshake(rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return credentials.NewTLS(r.files.ServerConfig()).ServerHandshake(rawConn)
+}
+
+func (r *reloadingCredentials) Info() credentials.ProtocolInfo {
+	return credentials.NewTLS(nil).Info()
+}
+
+func (r *reloadingCredentials) Clone() credentials.TransportCredentials {
+	return &reloadingCredentials{files: r.files}
+}
+
+// OverrideServerName isn't supported, use TLSServerNameKey instead
+func (r *reloadingCredentials) OverrideServerName(string) error {
+	return nil
+}
@@ -0,0 +1,238 @@
+package security
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+)
+
+const (
+	// AuthJWTSecretKey verifies HS256 tokens, they are rejected when it's not set
+	AuthJWTSecretKey = "custom.auth.jwt.secret"
+	// AuthJWTKeySetKey is a JWKS file with the RSA public keys that verify RS256 tokens, they are rejected when it's not set
+	AuthJWTKeySetKey = "custom.auth.jwt.jwks"
+	// AuthJWTIssuerKey is the iss tokens must have, any issuer is accepted when it's not set
+	AuthJWTIssuerKey = "custom.auth.jwt.issuer"
+	// AuthJWTAudienceKey must be one of the aud of tokens, any audience is accepted when it's not set
+	AuthJWTAudienceKey = "custom.auth.jwt.audience"
+
+	// jwtLeeway tolerates clocks that are a little apart
+	jwtLeeway = time.Minute
+)
+
+var errInvalidJWT = errors.New("invalid JWT")
+
+// jwts accepts HS256 and RS256 JSON web tokens, see https://tools.ietf.org/html/rfc7519
+type jwts struct {
+	secret   []byte
+	keys     map[string]*rsa.PublicKey // by kid
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Subject   string    `json:"sub"`
+	Issuer    string    `json:"iss"`
+	Audience  audiences `json:"aud"`
+	ExpiresAt int64     `json:"exp"`
+	NotBefore int64     `json:"nbf"`
+	Roles     []string  `json:"roles"`
+}
+
+// audiences is either a string or a list of them
+type audiences []string
+
+func (a *audiences) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = audiences{single}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(a))
+}
+
+// newJWTs returns nil when neither a secret nor a key set is configured
+func newJWTs(config cfg.Config) (*jwts, error) {
+	j := &jwts{
+		secret:   []byte(config.Get(AuthJWTSecretKey).String()),
+		issuer:   config.Get(AuthJWTIssuerKey).String(),
+		audience: config.Get(AuthJWTAudienceKey).String(),
+		now:      time.Now,
+	}
+	if file := config.Get(AuthJWTKeySetKey).String(); len(file) > 0 {
+		keys, err := loadJWKS(file)
+		if err != nil {
+			return nil, err
+		}
+		j.keys = keys
+	}
+	if len(j.secret) == 0 && len(j.keys) == 0 {
+		return nil, nil
+	}
+	return j, nil
+}
+
+func (j *jwts) Authenticate(_ context.Context, credentials string) (Principal, error) {
+	parts := strings.Split(credentials, ".")
+	if len(parts) != 3 {
+		return Principal{}, ErrUnknownCredentials
+	}
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return Principal{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: malformed signature", errInvalidJWT)
+	}
+	if err = j.verify(header, parts[0]+"."+parts[1], signature); err != nil {
+		return Principal{}, err
+	}
+	var claims jwtClaims
+	if err = decodeJWTPart(parts[1], &claims); err != nil {
+		return Principal{}, err
+	}
+	if err = j.validate(claims); err != nil {
+		return Principal{}, err
+	}
+	return Principal{Subject: claims.Subject, Method: "jwt", Roles: claims.Roles}, nil
+}
+
+// verify checks the signature with the key of the algorithm in the header, the header is trusted for nothing else
+func (j *jwts) verify(header jwtHeader, signed string, signature []byte) error {
+	switch header.Algorithm {
+	case "HS256":
+		if len(j.secret) == 0 {
+			return fmt.Errorf("%w: HS256 isn't accepted", errInvalidJWT)
+		}
+		mac := hmac.New(sha256.New, j.secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("%w: bad signature", errInvalidJWT)
+		}
+		return nil
+	case "RS256":
+		key, err := j.key(header.KeyID)
+		if err != nil {
+			return err
+		}
+		digest := sha256.Sum256([]byte(signed))
+		if err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("%w: bad signature", errInvalidJWT)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: algorithm %q isn't accepted", errInvalidJWT, header.Algorithm)
+	}
+}
+
+// key returns the key of kid, tokens without a kid may be used when there is a single key
+func (j *jwts) key(kid string) (*rsa.PublicKey, error) {
+	if key, exists := j.keys[kid]; exists {
+		return key, nil
+	}
+	if len(kid) == 0 && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown key %q", errInvalidJWT, kid)
+}
+
+func (j *jwts) validate(claims jwtClaims) error {
+	now := j.now()
+	if len(claims.Subject) == 0 {
+		return fmt.Errorf("%w: no subject", errInvalidJWT)
+	}
+	// tokens that never expire aren't accepted
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(jwtLeeway)) {
+		return fmt.Errorf("%w: expired", errInvalidJWT)
+	}
+	if claims.NotBefore != 0 && now.Add(jwtLeeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return fmt.Errorf("%w: not valid yet", errInvalidJWT)
+	}
+	if len(j.issuer) > 0 && claims.Issuer != j.issuer {
+		return fmt.Errorf("%w: issued by %q", errInvalidJWT, claims.Issuer)
+	}
+	if len(j.audience) > 0 && !contains(claims.Audience, j.audience) {
+		return fmt.Errorf("%w: not meant for %q", errInvalidJWT, j.audience)
+	}
+	return nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("%w: malformed", errInvalidJWT)
+	}
+	if err = json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%w: malformed", errInvalidJWT)
+	}
+	return nil
+}
+
+// loadJWKS reads the RSA keys of a JSON web key set, see https://tools.ietf.org/html/rfc7517
+func loadJWKS(file string) (map[string]*rsa.PublicKey, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			Use     string `json:"use"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+		} `json:"keys"`
+	}
+	if err = json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("can't parse %s, %w", file, err)
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, key := range set.Keys {
+		if key.KeyType != "RSA" || (len(key.Use) > 0 && key.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %q of %s has a malformed modulus", key.KeyID, file)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("key %q of %s has a malformed exponent", key.KeyID, file)
+		}
+		keys[key.KeyID] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s has no RSA signing keys", file)
+	}
+	return keys, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,291 @@
+package security
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/log"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+)
+
+const (
+	// TLSCertificateKey is the PEM file with the certificate chain of this service, TLS is off when it's not set
+	TLSCertificateKey = "custom.tls.certificate"
+	// TLSKeyKey is the PEM file with the private key of TLSCertificateKey
+	TLSKeyKey = "custom.tls.key"
+	// TLSCAKey is the PEM bundle of the CAs that sign the certificates of our peers, the system roots are used when it's not set
+	TLSCAKey = "custom.tls.ca"
+	// TLSMutualKey makes servers require client certificates signed by TLSCAKey and clients present TLSCertificateKey
+	TLSMutualKey = "custom.tls.mutual"
+	// TLSServerNameKey is the name clients expect in server certificates instead of the host they dial
+	TLSServerNameKey = "custom.tls.servername"
+	// TLSReloadIntervalKey is how often the files are checked for changes, they are loaded again without a restart
+	TLSReloadIntervalKey = "custom.tls.reload"
+	// TLSRESTExternalPortKey is the port of the external REST listener when it's served over TLS, in place of
+	// mortar.server.rest.external.port which Mortar serves in plain text. REST calls use https only when it's set,
+	// the REST listeners of our peers are expected to be served alike.
+	TLSRESTExternalPortKey = "custom.tls.rest.external.port"
+	// TLSRESTInternalPortKey is the port of the internal REST listener when it's served over TLS, in place of
+	// mortar.server.rest.internal.port
+	TLSRESTInternalPortKey = "custom.tls.rest.internal.port"
+
+	defaultTLSReloadInterval = time.Minute
+)
+
+// TLS keeps the certificates of this service up to date, every connection uses the latest ones
+type TLS interface {
+	// Enabled is false when no certificate is configured, connections are plain text then
+	Enabled() bool
+	// ServerConfig of the latest certificates, nil when disabled
+	ServerConfig() *tls.Config
+	// ClientConfig of the latest certificates, nil when disabled
+	ClientConfig() *tls.Config
+	// GRPCServerOptions secure a gRPC server
+	GRPCServerOptions() []grpc.ServerOption
+	// GRPCDialOption secures a gRPC client, it's grpc.WithInsecure() when disabled
+	GRPCDialOption() grpc.DialOption
+	// HTTPClient secures REST calls, it's a default client when disabled
+	HTTPClient() *http.Client
+	// RESTListener listens on address and serves TLS with the latest certificates, clients must present theirs when mutual
+	RESTListener(address string) (net.Listener, error)
+	// Scheme of REST calls, the one REST listeners are served with, https only when TLSRESTExternalPortKey is set
+	Scheme() string
+}
+
+type tlsDeps struct {
+	fx.In
+
+	Config    cfg.Config
+	Logger    log.Logger
+	Lifecycle fx.Lifecycle
+}
+
+type tlsFiles struct {
+	sync.RWMutex
+	logger     log.Logger
+	certFile   string
+	keyFile    string
+	caFile     string
+	mutual     bool
+	serverName string
+	rest       bool
+	interval   time.Duration
+	modified   map[string]time.Time // of every file when it was loaded
+	server     *tls.Config
+	client     *tls.Config
+	stopping   chan struct{}
+	done       chan struct{}
+}
+
+// CreateTLS is a constructor for Fx, it fails if the configured files can't be loaded
+func CreateTLS(deps tlsDeps) (TLS, error) {
+	t := &tlsFiles{
+		logger:     deps.Logger,
+		certFile:   deps.Config.Get(TLSCertificateKey).String(),
+		keyFile:    deps.Config.Get(TLSKeyKey).String(),
+		caFile:     deps.Config.Get(TLSCAKey).String(),
+		mutual:     deps.Config.Get(TLSMutualKey).Bool(),
+		serverName: deps.Config.Get(TLSServerNameKey).String(),
+		rest:       deps.Config.Get(TLSRESTExternalPortKey).IsSet(),
+		interval:   defaultTLSReloadInterval,
+		stopping:   make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if !t.Enabled() {
+		for _, key := range []string{TLSRESTExternalPortKey, TLSRESTInternalPortKey} {
+			if deps.Config.Get(key).IsSet() {
+				return nil, fmt.Errorf("%s needs %s to serve REST over TLS", key, TLSCertificateKey)
+			}
+		}
+		return t, nil
+	}
+	if t.mutual && len(t.caFile) == 0 {
+		return nil, fmt.Errorf("%s needs %s to verify client certificates", TLSMutualKey, TLSCAKey)
+	}
+	if value := deps.Config.Get(TLSReloadIntervalKey); value.IsSet() {
+		t.interval = value.Duration()
+	}
+	if err := t.load(); err != nil {
+		return nil, err
+	}
+	deps.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go t.watch()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(t.stopping)
+			select {
+			case <-t.done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+	return t, nil
+}
+
+func (t *tlsFiles) Enabled() bool {
+	return len(t.certFile) > 0
+}
+
+func (t *tlsFiles) ServerConfig() *tls.Config {
+	t.RLock()
+	defer t.RUnlock()
+	return t.server
+}
+
+func (t *tlsFiles) ClientConfig() *tls.Config {
+	t.RLock()
+	defer t.RUnlock()
+	return t.client
+}
+
+func (t *tlsFiles) GRPCServerOptions() []grpc.ServerOption {
+	if !t.Enabled() {
+		return nil
+	}
+	return []grpc.ServerOption{grpc.Creds(&reloadingCredentials{files: t})}
+}
+
+func (t *tlsFiles) GRPCDialOption() grpc.DialOption {
+	if !t.Enabled() {
+		return grpc.WithInsecure()
+	}
+	return grpc.WithTransportCredentials(&reloadingCredentials{files: t})
+}
+
+func (t *tlsFiles) HTTPClient() *http.Client {
+	if !t.Enabled() {
+		return &http.Client{}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialTLS = func(network, address string) (net.Conn, error) {
+		config := t.ClientConfig().Clone()
+		if len(config.ServerName) == 0 {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return nil, err
+			}
+			config.ServerName = host
+		}
+		return tls.Dial(network, address, config)
+	}
+	return &http.Client{Transport: transport}
+}
+
+func (t *tlsFiles) RESTListener(address string) (net.Listener, error) {
+	if !t.Enabled() {
+		return nil, fmt.Errorf("REST can't be served over TLS without %s", TLSCertificateKey)
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return tls.NewListener(listener, &tls.Config{
+		// every handshake gets the latest certificates
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return t.ServerConfig(), nil
+		},
+	}), nil
+}
+
+func (t *tlsFiles) Scheme() string {
+	if t.rest {
+		return "https"
+	}
+	return "http"
+}
+
+// watch loads the files again whenever one of them changes, until the application stops
+func (t *tlsFiles) watch() {
+	defer close(t.done)
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	ctx := context.Background()
+	for {
+		select {
+		case <-t.stopping:
+			return
+		case <-ticker.C:
+		}
+		if !t.changed() {
+			continue
+		}
+		if err := t.load(); err != nil {
+			// keep using the previous certificates, the files may be in the middle of being replaced
+			t.logger.WithError(err).Warn(ctx, "failed to reload TLS certificates")
+			continue
+		}
+		t.logger.Info(ctx, "TLS certificates reloaded from %s", t.certFile)
+	}
+}
+
+func (t *tlsFiles) changed() bool {
+	t.RLock()
+	defer t.RUnlock()
+	for file, modified := range t.modified {
+		if info, err := os.Stat(file); err == nil && !info.ModTime().Equal(modified) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tlsFiles) load() error {
+	modified := make(map[string]time.Time)
+	for _, file := range []string{t.certFile, t.keyFile, t.caFile} {
+		if len(file) == 0 {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modified[file] = info.ModTime()
+	}
+	certificate, err := tls.LoadX509KeyPair(t.certFile, t.keyFile)
+	if err != nil {
+		return fmt.Errorf("can't load TLS certificate, %w", err)
+	}
+	var pool *x509.CertPool // system roots
+	if len(t.caFile) > 0 {
+		bundle, err := ioutil.ReadFile(t.caFile)
+		if err != nil {
+			return err
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bundle) {
+			return fmt.Errorf("no certificates found in %s", t.caFile)
+		}
+	}
+	server := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+	client := &tls.Config{
+		RootCAs:    pool,
+		ServerName: t.serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if t.mutual {
+		server.ClientAuth = tls.RequireAndVerifyClientCert
+		server.ClientCAs = pool
+		client.Certificates = []tls.Certificate{certificate}
+	}
+	t.Lock()
+	defer t.Unlock()
+	t.server, t.client, t.modified = server, client, modified
+	return nil
+}
@@ -0,0 +1,242 @@
+package security_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/tutorial/07-makefile/app/mortar"
+	"github.com/go-masonry/tutorial/07-makefile/app/security"
+	"github.com/go-masonry/tutorial/07-makefile/app/security/securitytest"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type tlsSuite struct {
+	suite.Suite
+
+	pwd   string
+	dir   string
+	files securitytest.Files
+	ca    *securitytest.CA
+}
+
+func TestTLS(t *testing.T) {
+	suite.Run(t, new(tlsSuite))
+}
+
+func (s *tlsSuite) SetupTest() {
+	var err error
+	s.pwd, err = os.Getwd()
+	s.Require().NoError(err)
+	s.dir, err = ioutil.TempDir("", "tls")
+	s.Require().NoError(err)
+	s.ca, err = securitytest.NewCA("tutorial")
+	s.Require().NoError(err)
+	s.files, err = s.ca.WriteFiles(s.dir, "workshop", "localhost", "127.0.0.1")
+	s.Require().NoError(err)
+}
+
+func (s *tlsSuite) TearDownTest() {
+	s.NoError(os.RemoveAll(s.dir))
+}
+
+func (s *tlsSuite) TestMutualTLS() {
+	t := s.startTLS(true)
+	address := s.startGRPCServer(t)
+
+	s.NoError(s.check(address, t.GRPCDialOption()))
+
+	// the server certificate is trusted, but the client doesn't have one
+	pool := x509.NewCertPool()
+	s.True(pool.AppendCertsFromPEM(s.ca.PEM))
+	err := s.check(address, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool})))
+	s.Equal(codes.Unavailable, status.Code(err))
+
+	err = s.check(address, grpc.WithInsecure())
+	s.Equal(codes.Unavailable, status.Code(err))
+}
+
+func (s *tlsSuite) TestCertificatesAreReloaded() {
+	t := s.startTLS(true)
+	address := s.startGRPCServer(t)
+	previous := t.ServerConfig()
+
+	// both sides move to a new CA, clients that trust only the previous one are refused
+	oldCA := s.ca
+	var err error
+	s.ca, err = securitytest.NewCA("tutorial-rotated")
+	s.Require().NoError(err)
+	_, err = s.ca.WriteFiles(s.dir, "workshop", "localhost", "127.0.0.1")
+	s.Require().NoError(err)
+	s.Eventually(func() bool { return t.ServerConfig() != previous }, time.Second, 10*time.Millisecond)
+
+	s.NoError(s.check(address, t.GRPCDialOption()))
+	certificate, key, err := oldCA.Issue("stale", "localhost")
+	s.Require().NoError(err)
+	stale, err := tls.X509KeyPair(certificate, key)
+	s.Require().NoError(err)
+	pool := x509.NewCertPool()
+	s.True(pool.AppendCertsFromPEM(s.ca.PEM))
+	err = s.check(address, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool, Certificates: []tls.Certificate{stale}})))
+	s.Equal(codes.Unavailable, status.Code(err))
+}
+
+func (s *tlsSuite) TestRESTListener() {
+	t := s.startTLS(true, func(config cfg.Config) {
+		config.Set(security.TLSRESTExternalPortKey, 5381)
+	})
+	listener, err := t.RESTListener("127.0.0.1:0")
+	s.Require().NoError(err)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+	address := listener.Addr().String()
+
+	s.Equal("https", t.Scheme())
+	s.roundTrip(t, address)
+
+	// the server certificate is trusted, but the client doesn't have one
+	pool := x509.NewCertPool()
+	s.True(pool.AppendCertsFromPEM(s.ca.PEM))
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	_, err = client.Get("https://" + address)
+	s.Error(err)
+	// plain text is answered with an error by net/http
+	response, err := http.Get("http://" + address)
+	s.Require().NoError(err)
+	response.Body.Close()
+	s.Equal(http.StatusBadRequest, response.StatusCode)
+}
+
+// TestRESTRoundTrip calls a plain text REST listener, as Mortar serves it, with TLS enabled
+func (s *tlsSuite) TestRESTRoundTrip() {
+	t := s.startTLS(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	s.Equal("http", t.Scheme())
+	s.roundTrip(t, server.Listener.Addr().String())
+}
+
+func (s *tlsSuite) TestDisabled() {
+	app := fxtest.New(s.T(),
+		fx.NopLogger,
+		mortar.ViperFxOption(s.pwd+"/../../config/config.yml", s.pwd+"/../../config/config_test.yml"),
+		mortar.LoggerFxOption(),
+		fx.Provide(security.CreateTLS),
+		fx.Invoke(func(t security.TLS) {
+			s.False(t.Enabled())
+			s.Equal("http", t.Scheme())
+			s.Empty(t.GRPCServerOptions())
+		}),
+	)
+	app.RequireStart()
+	app.RequireStop()
+}
+
+func (s *tlsSuite) TestRESTNeedsCertificate() {
+	app := fx.New(
+		fx.NopLogger,
+		mortar.ViperFxOption(s.pwd+"/../../config/config.yml", s.pwd+"/../../config/config_test.yml"),
+		mortar.LoggerFxOption(),
+		fx.Invoke(func(config cfg.Config) {
+			config.Set(security.TLSRESTExternalPortKey, 5381)
+		}),
+		fx.Provide(security.CreateTLS),
+		fx.Invoke(func(security.TLS) {}),
+	)
+	s.Require().Error(app.Err())
+	s.Contains(app.Err().Error(), "custom.tls.rest.external.port needs custom.tls.certificate to serve REST over TLS")
+}
+
+func (s *tlsSuite) TestMutualTLSNeedsCA() {
+	app := fx.New(
+		fx.NopLogger,
+		mortar.ViperFxOption(s.pwd+"/../../config/config.yml", s.pwd+"/../../config/config_test.yml"),
+		mortar.LoggerFxOption(),
+		fx.Invoke(func(config cfg.Config) {
+			config.Set(security.TLSCertificateKey, s.files.Certificate)
+			config.Set(security.TLSKeyKey, s.files.Key)
+			config.Set(security.TLSMutualKey, true)
+		}),
+		fx.Provide(security.CreateTLS),
+		fx.Invoke(func(security.TLS) {}),
+	)
+	s.Require().Error(app.Err())
+	s.Contains(app.Err().Error(), "custom.tls.mutual needs custom.tls.ca to verify client certificates")
+}
+
+// startTLS with the test certificates, configure changes the rest of the configuration
+func (s *tlsSuite) startTLS(mutual bool, configure ...func(config cfg.Config)) security.TLS {
+	var t security.TLS
+	app := fxtest.New(s.T(),
+		fx.NopLogger,
+		mortar.ViperFxOption(s.pwd+"/../../config/config.yml", s.pwd+"/../../config/config_test.yml"),
+		mortar.LoggerFxOption(),
+		fx.Invoke(func(config cfg.Config) {
+			config.Set(security.TLSCertificateKey, s.files.Certificate)
+			config.Set(security.TLSKeyKey, s.files.Key)
+			config.Set(security.TLSCAKey, s.files.CA)
+			config.Set(security.TLSMutualKey, mutual)
+			config.Set(security.TLSReloadIntervalKey, "10ms")
+			for _, change := range configure {
+				change(config)
+			}
+		}),
+		fx.Provide(security.CreateTLS),
+		fx.Populate(&t),
+	)
+	app.RequireStart()
+	s.T().Cleanup(app.RequireStop)
+	return t
+}
+
+func (s *tlsSuite) startGRPCServer(t security.TLS) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	server := grpc.NewServer(t.GRPCServerOptions()...)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(listener)
+	s.T().Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+// roundTrip makes a REST call the way the sub workshop client does
+func (s *tlsSuite) roundTrip(t security.TLS, address string) {
+	response, err := t.HTTPClient().Get(t.Scheme() + "://" + address)
+	s.Require().NoError(err)
+	defer response.Body.Close()
+	s.Equal(http.StatusNoContent, response.StatusCode)
+}
+
+func (s *tlsSuite) check(address string, dialOption grpc.DialOption) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, address, dialOption)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(false))
+	return err
+}
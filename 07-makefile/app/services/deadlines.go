@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/log"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// ServerTimeoutKey limits every unary RPC we serve, there is no limit when it's not set or zero.
+	// Callers can always ask for a shorter deadline.
+	ServerTimeoutKey = "custom.server.timeout.default"
+	// ServerMethodTimeoutsKey overrides ServerTimeoutKey by service and method name, e.g.
+	// custom.server.timeout.methods.workshop.paintcar
+	ServerMethodTimeoutsKey = "custom.server.timeout.methods"
+)
+
+// Deadlines sets a deadline on every unary RPC according to the configuration
+type Deadlines interface {
+	UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error)
+}
+
+type deadlinesDeps struct {
+	fx.In
+
+	Config cfg.Config
+	Logger log.Logger
+}
+
+type deadlines struct {
+	logger         log.Logger
+	defaultTimeout time.Duration
+	methodTimeouts map[string]time.Duration // by lower case service/method
+}
+
+// CreateDeadlines is a constructor for Fx
+func CreateDeadlines(deps deadlinesDeps) Deadlines {
+	d := &deadlines{
+		logger:         deps.Logger,
+		methodTimeouts: make(map[string]time.Duration),
+	}
+	if value := deps.Config.Get(ServerTimeoutKey); value.IsSet() {
+		d.defaultTimeout = value.Duration()
+	}
+	for service := range deps.Config.Get(ServerMethodTimeoutsKey).StringMap() {
+		serviceKey := ServerMethodTimeoutsKey + "." + service
+		for method := range deps.Config.Get(serviceKey).StringMap() {
+			d.methodTimeouts[strings.ToLower(service+"/"+method)] = deps.Config.Get(serviceKey + "." + method).Duration()
+		}
+	}
+	return d
+}
+
+func (d *deadlines) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	if timeout := d.timeout(info.FullMethod); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	resp, err := handler(ctx, req)
+	if err == nil {
+		return resp, nil
+	}
+	if ctx.Err() == context.DeadlineExceeded || errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded {
+		deadline, _ := ctx.Deadline()
+		d.logger.WithError(err).Warn(ctx, "%s ran out of time after %s, its deadline was %s", info.FullMethod, time.Since(start), deadline.Format(time.RFC3339Nano))
+		if s, ok := status.FromError(err); ok && s.Code() == codes.DeadlineExceeded {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.DeadlineExceeded, "%s didn't finish in time: %v", info.FullMethod, err)
+	}
+	return resp, err
+}
+
+// timeout of a full method name such as /tutorial.workshop.Workshop/PaintCar
+func (d *deadlines) timeout(fullMethod string) time.Duration {
+	serviceAndMethod := strings.TrimPrefix(fullMethod, "/")
+	if dot := strings.LastIndex(serviceAndMethod, "."); dot >= 0 {
+		serviceAndMethod = serviceAndMethod[dot+1:]
+	}
+	if timeout, exists := d.methodTimeouts[strings.ToLower(serviceAndMethod)]; exists {
+		return timeout
+	}
+	return d.defaultTimeout
+}
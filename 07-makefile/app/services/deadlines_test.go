@@ -0,0 +1,71 @@
+package services_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/tutorial/07-makefile/app/mortar"
+	"github.com/go-masonry/tutorial/07-makefile/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDeadlines(t *testing.T) {
+	pwd, err := os.Getwd()
+	require.NoError(t, err)
+	var deadlines services.Deadlines
+	app := fxtest.New(t,
+		fx.NopLogger, // remove fx debug prints
+		mortar.ViperFxOption(pwd+"/../../config/config.yml", pwd+"/../../config/config_test.yml"),
+		mortar.LoggerFxOption(),
+		fx.Invoke(func(config cfg.Config) {
+			config.Set(services.ServerTimeoutKey, "1s")
+			config.Set(services.ServerMethodTimeoutsKey, map[string]interface{}{
+				"workshop": map[string]interface{}{"paintcar": "20ms"},
+			})
+		}),
+		fx.Provide(services.CreateDeadlines),
+		fx.Populate(&deadlines),
+	)
+	app.RequireStart()
+	defer app.RequireStop()
+
+	hang := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	start := time.Now()
+	_, err = deadlines.UnaryServerInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/tutorial.workshop.Workshop/PaintCar"}, hang)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Less(t, int64(time.Since(start)), int64(time.Second), "method timeout is used")
+
+	// callers may ask for less than the default
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = deadlines.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/tutorial.workshop.Workshop/RetrieveCar"}, hang)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	var deadline time.Time
+	_, err = deadlines.UnaryServerInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/tutorial.workshop.Workshop/RetrieveCar"},
+		func(ctx context.Context, _ interface{}) (interface{}, error) {
+			deadline, _ = ctx.Deadline()
+			return nil, nil
+		})
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+
+	// downstream calls that run out of time are reported as is
+	_, err = deadlines.UnaryServerInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/tutorial.workshop.Workshop/CarPainted"},
+		func(context.Context, interface{}) (interface{}, error) {
+			return nil, status.Error(codes.DeadlineExceeded, "sub workshop didn't answer in time")
+		})
+	assert.EqualError(t, err, "rpc error: code = DeadlineExceeded desc = sub workshop didn't answer in time")
+}
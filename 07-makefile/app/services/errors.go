@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-masonry/tutorial/07-makefile/app/controllers"
+	"github.com/go-masonry/tutorial/07-makefile/app/data"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorCodes maps domain errors to the gRPC codes returned to clients
+var errorCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{data.ErrCarNotFound, codes.NotFound},
+	{data.ErrCarAlreadyExists, codes.AlreadyExists},
+	{data.ErrPaintJobNotFound, codes.NotFound},
+	{data.ErrIllegalCarTransition, codes.FailedPrecondition},
+	{data.ErrCarChanged, codes.Aborted},
+	{controllers.ErrCarNotPainted, codes.FailedPrecondition},
+	{controllers.ErrCarNotPaintable, codes.FailedPrecondition},
+	{controllers.ErrCarNotBeingPainted, codes.FailedPrecondition},
+	{controllers.ErrInvalidPageToken, codes.InvalidArgument},
+	{controllers.ErrEventsExpired, codes.OutOfRange},
+	{controllers.ErrWatcherTooSlow, codes.Unavailable},
+	{controllers.ErrPaintJobDone, codes.FailedPrecondition},
+	{controllers.ErrPaintQueueFull, codes.ResourceExhausted},
+	{controllers.ErrSubWorkshopStopping, codes.Unavailable},
+	{controllers.ErrInvalidCallbackToken, codes.PermissionDenied},
+	{controllers.ErrCallbackReplayed, codes.PermissionDenied},
+	{controllers.ErrCallbackNotAllowed, codes.PermissionDenied},
+	{controllers.ErrInvalidETag, codes.InvalidArgument},
+	{controllers.ErrInvalidIdempotencyKey, codes.InvalidArgument},
+	{controllers.ErrIdempotencyKeyReused, codes.InvalidArgument},
+	{controllers.ErrIdempotencyKeyInUse, codes.Aborted},
+	{context.DeadlineExceeded, codes.DeadlineExceeded},
+	{context.Canceled, codes.Canceled},
+}
+
+// ToStatusError converts domain errors to gRPC status errors, errors that already carry a status are returned as is
+func ToStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	for _, mapping := range errorCodes {
+		if errors.Is(err, mapping.err) {
+			return status.Error(mapping.code, err.Error())
+		}
+	}
+	return err
+}
+
+// ErrorsUnaryServerInterceptor converts errors returned by our services to gRPC status errors
+func ErrorsUnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	return resp, ToStatusError(err)
+}
+
+// ErrorsStreamServerInterceptor is ErrorsUnaryServerInterceptor for streams
+func ErrorsStreamServerInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return ToStatusError(handler(srv, stream))
+}
+
+// HTTPErrorHandler is the default gRPC Gateway error handler, except FailedPrecondition which is returned as 412
+func HTTPErrorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	if status.Code(err) == codes.FailedPrecondition {
+		w = &statusCodeOverride{ResponseWriter: w, statusCode: http.StatusPreconditionFailed}
+	}
+	runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, w, r, err)
+}
+
+type statusCodeOverride struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (s *statusCodeOverride) WriteHeader(int) {
+	s.ResponseWriter.WriteHeader(s.statusCode)
+}
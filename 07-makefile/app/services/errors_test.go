@@ -0,0 +1,83 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-masonry/tutorial/07-makefile/app/controllers"
+	"github.com/go-masonry/tutorial/07-makefile/app/data"
+	"github.com/go-masonry/tutorial/07-makefile/app/services"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestToStatusError(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{fmt.Errorf("%w: 1234", data.ErrCarNotFound), codes.NotFound},
+		{fmt.Errorf("%w: 1234", data.ErrCarAlreadyExists), codes.AlreadyExists},
+		{fmt.Errorf("%w: 1234", controllers.ErrCarNotPainted), codes.FailedPrecondition},
+		{fmt.Errorf("%w: 1234 is at version 3, not 2", data.ErrCarChanged), codes.Aborted},
+		{status.Error(codes.InvalidArgument, "bad input"), codes.InvalidArgument},
+		{fmt.Errorf("%w: key-1", controllers.ErrIdempotencyKeyReused), codes.InvalidArgument},
+		{fmt.Errorf("%w: W/\"1\"", controllers.ErrInvalidETag), codes.InvalidArgument},
+		{fmt.Errorf("%w: key-1", controllers.ErrIdempotencyKeyInUse), codes.Aborted},
+		{fmt.Errorf("failed to paint, %w", context.DeadlineExceeded), codes.DeadlineExceeded},
+		{fmt.Errorf("something else"), codes.Unknown},
+	}
+	for _, test := range tests {
+		err := services.ToStatusError(test.err)
+		assert.Equal(t, test.code, status.Code(err), test.err.Error())
+		assert.Contains(t, err.Error(), test.err.Error())
+	}
+	assert.NoError(t, services.ToStatusError(nil))
+}
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		code       codes.Code
+		httpStatus int
+	}{
+		{codes.NotFound, http.StatusNotFound},
+		{codes.AlreadyExists, http.StatusConflict},
+		{codes.FailedPrecondition, http.StatusPreconditionFailed},
+		{codes.InvalidArgument, http.StatusBadRequest},
+		{codes.Aborted, http.StatusConflict},
+	}
+	mux := runtime.NewServeMux()
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/v1/workshop/cars/1234", nil)
+		services.HTTPErrorHandler(context.Background(), mux, &runtime.JSONPb{}, recorder, request, status.Error(test.code, "oops"))
+		assert.Equal(t, test.httpStatus, recorder.Code, test.code.String())
+	}
+}
+
+func TestGatewayHeaderMatcher(t *testing.T) {
+	name, forwarded := services.GatewayHeaderMatcher("Idempotency-Key")
+	assert.True(t, forwarded)
+	assert.Equal(t, controllers.IdempotencyKeyHeader, name)
+	_, forwarded = services.GatewayHeaderMatcher("Authorization")
+	assert.True(t, forwarded)
+	name, forwarded = services.GatewayHeaderMatcher("If-Match")
+	assert.True(t, forwarded)
+	assert.Equal(t, controllers.IfMatchHeader, name)
+	_, forwarded = services.GatewayHeaderMatcher("X-Unknown")
+	assert.False(t, forwarded)
+}
+
+func TestGatewayOutgoingHeaderMatcher(t *testing.T) {
+	name, forwarded := services.GatewayOutgoingHeaderMatcher(controllers.ETagHeader)
+	assert.True(t, forwarded)
+	assert.Equal(t, "ETag", name)
+	name, forwarded = services.GatewayOutgoingHeaderMatcher("x-request-id")
+	assert.True(t, forwarded)
+	assert.Equal(t, runtime.MetadataHeaderPrefix+"x-request-id", name)
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"net/textproto"
+
+	"github.com/go-masonry/tutorial/07-makefile/app/controllers"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// gatewayHeaders are passed by the gateway to our gRPC services as is, on top of the headers it passes by default
+var gatewayHeaders = map[string]string{
+	textproto.CanonicalMIMEHeaderKey(controllers.IdempotencyKeyHeader): controllers.IdempotencyKeyHeader,
+	textproto.CanonicalMIMEHeaderKey(controllers.IfMatchHeader):        controllers.IfMatchHeader,
+}
+
+// gatewayResponseHeaders are gRPC metadata the gateway returns as HTTP headers as is, other metadata gets
+// the runtime.MetadataHeaderPrefix
+var gatewayResponseHeaders = map[string]string{
+	controllers.ETagHeader: "ETag",
+}
+
+// GatewayHeaderMatcher decides which HTTP headers become gRPC metadata
+func GatewayHeaderMatcher(key string) (string, bool) {
+	if name, exists := gatewayHeaders[textproto.CanonicalMIMEHeaderKey(key)]; exists {
+		return name, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// GatewayOutgoingHeaderMatcher decides which gRPC metadata become HTTP response headers
+func GatewayOutgoingHeaderMatcher(key string) (string, bool) {
+	if name, exists := gatewayResponseHeaders[key]; exists {
+		return name, true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
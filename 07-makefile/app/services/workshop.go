@@ -38,7 +38,7 @@ func (w *workshopImpl) AcceptCar(ctx context.Context, car *workshop.Car) (*empty
 	return w.deps.Controller.AcceptCar(ctx, car)
 }
 
-func (w *workshopImpl) PaintCar(ctx context.Context, request *workshop.PaintCarRequest) (*empty.Empty, error) {
+func (w *workshopImpl) PaintCar(ctx context.Context, request *workshop.PaintCarRequest) (*workshop.PaintJob, error) {
 	if err := w.deps.Validations.PaintCar(ctx, request); err != nil {
 		return nil, err
 	}
@@ -54,6 +54,70 @@ func (w *workshopImpl) RetrieveCar(ctx context.Context, request *workshop.Retrie
 	return w.deps.Controller.RetrieveCar(ctx, request)
 }
 
+func (w *workshopImpl) UpdateCar(ctx context.Context, request *workshop.UpdateCarRequest) (*workshop.Car, error) {
+	if err := w.deps.Validations.UpdateCar(ctx, request); err != nil {
+		return nil, err
+	}
+	w.deps.Logger.WithField("mask", request.GetUpdateMask().GetPaths()).Debug(ctx, "updating car")
+	return w.deps.Controller.UpdateCar(ctx, request)
+}
+
+func (w *workshopImpl) GetCarHistory(ctx context.Context, request *workshop.GetCarHistoryRequest) (*workshop.GetCarHistoryResponse, error) {
+	if err := w.deps.Validations.GetCarHistory(ctx, request); err != nil {
+		return nil, err
+	}
+	w.deps.Logger.Debug(ctx, "getting car history")
+	return w.deps.Controller.GetCarHistory(ctx, request)
+}
+
+func (w *workshopImpl) ListCars(ctx context.Context, request *workshop.ListCarsRequest) (*workshop.ListCarsResponse, error) {
+	if err := w.deps.Validations.ListCars(ctx, request); err != nil {
+		return nil, err
+	}
+	w.deps.Logger.Debug(ctx, "listing cars")
+	return w.deps.Controller.ListCars(ctx, request)
+}
+
+func (w *workshopImpl) WatchCars(request *workshop.WatchCarsRequest, stream workshop.Workshop_WatchCarsServer) error {
+	if err := w.deps.Validations.WatchCars(stream.Context(), request); err != nil {
+		return err
+	}
+	w.deps.Logger.Debug(stream.Context(), "watching cars")
+	return w.deps.Controller.WatchCars(request, stream)
+}
+
+func (w *workshopImpl) GetPaintJob(ctx context.Context, request *workshop.GetPaintJobRequest) (*workshop.PaintJob, error) {
+	if err := w.deps.Validations.GetPaintJob(ctx, request); err != nil {
+		return nil, err
+	}
+	w.deps.Logger.Debug(ctx, "getting paint job")
+	return w.deps.Controller.GetPaintJob(ctx, request)
+}
+
+func (w *workshopImpl) ListPaintJobs(ctx context.Context, request *workshop.ListPaintJobsRequest) (*workshop.ListPaintJobsResponse, error) {
+	if err := w.deps.Validations.ListPaintJobs(ctx, request); err != nil {
+		return nil, err
+	}
+	w.deps.Logger.Debug(ctx, "listing paint jobs")
+	return w.deps.Controller.ListPaintJobs(ctx, request)
+}
+
+func (w *workshopImpl) CancelPaintJob(ctx context.Context, request *workshop.CancelPaintJobRequest) (*workshop.PaintJob, error) {
+	if err := w.deps.Validations.CancelPaintJob(ctx, request); err != nil {
+		return nil, err
+	}
+	w.deps.Logger.Debug(ctx, "cancelling paint job")
+	return w.deps.Controller.CancelPaintJob(ctx, request)
+}
+
+func (w *workshopImpl) RegisterSubWorkshop(ctx context.Context, request *workshop.SubWorkshopRegistration) (*empty.Empty, error) {
+	if err := w.deps.Validations.RegisterSubWorkshop(ctx, request); err != nil {
+		return nil, err
+	}
+	w.deps.Logger.Debug(ctx, "registering sub workshop")
+	return w.deps.Controller.RegisterSubWorkshop(ctx, request)
+}
+
 func (w *workshopImpl) CarPainted(ctx context.Context, request *workshop.PaintFinishedRequest) (*empty.Empty, error) {
 	if err := w.deps.Validations.CarPainted(ctx, request); err != nil {
 		return nil, err
@@ -2,6 +2,8 @@ package validations
 
 import (
 	"context"
+	"net"
+	"strconv"
 	"strings"
 
 	"google.golang.org/grpc/codes"
@@ -14,7 +16,15 @@ type WorkshopValidations interface {
 	AcceptCar(ctx context.Context, car *workshop.Car) error
 	PaintCar(ctx context.Context, request *workshop.PaintCarRequest) error
 	RetrieveCar(ctx context.Context, request *workshop.RetrieveCarRequest) error
+	UpdateCar(ctx context.Context, request *workshop.UpdateCarRequest) error
+	GetCarHistory(ctx context.Context, request *workshop.GetCarHistoryRequest) error
 	CarPainted(ctx context.Context, request *workshop.PaintFinishedRequest) error
+	ListCars(ctx context.Context, request *workshop.ListCarsRequest) error
+	WatchCars(ctx context.Context, request *workshop.WatchCarsRequest) error
+	GetPaintJob(ctx context.Context, request *workshop.GetPaintJobRequest) error
+	ListPaintJobs(ctx context.Context, request *workshop.ListPaintJobsRequest) error
+	CancelPaintJob(ctx context.Context, request *workshop.CancelPaintJobRequest) error
+	RegisterSubWorkshop(ctx context.Context, request *workshop.SubWorkshopRegistration) error
 }
 
 type workshopValidations struct {
@@ -40,10 +50,120 @@ func (w *workshopValidations) RetrieveCar(ctx context.Context, request *workshop
 	return carIdValidation(request.GetCarNumber())
 }
 
+func (w *workshopValidations) UpdateCar(ctx context.Context, request *workshop.UpdateCarRequest) error {
+	car := request.GetCar()
+	if err := carIdValidation(car.GetNumber()); err != nil {
+		return err
+	}
+	if etag := car.GetEtag(); len(etag) > 0 {
+		if version, err := strconv.ParseInt(etag, 10, 64); err != nil || version <= 0 {
+			return status.Errorf(codes.InvalidArgument, "etag %s wasn't issued by the workshop", etag)
+		}
+	}
+	if _, known := workshop.CarBody_name[int32(car.GetBodyStyle())]; !known {
+		return status.Errorf(codes.InvalidArgument, "unknown body style %d", car.GetBodyStyle())
+	}
+	paths := request.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		// every field that is set is updated
+		if len(car.GetColor()) > 0 {
+			paths = append(paths, "color")
+		}
+		if car.GetState() != workshop.Car_UNKNOWN {
+			paths = append(paths, "state")
+		}
+	}
+	for _, path := range paths {
+		switch path {
+		case "owner", "body_style", "etag":
+		case "number", "color", "state":
+			return status.Errorf(codes.InvalidArgument, "car %s can't be changed", path)
+		default:
+			return status.Errorf(codes.InvalidArgument, "car has no %s field", path)
+		}
+	}
+	return nil
+}
+
+func (w *workshopValidations) GetCarHistory(ctx context.Context, request *workshop.GetCarHistoryRequest) error {
+	if err := carIdValidation(request.GetCarNumber()); err != nil {
+		return err
+	}
+	return pageSizeValidation(request.GetPageSize())
+}
+
 func (w *workshopValidations) CarPainted(ctx context.Context, request *workshop.PaintFinishedRequest) error {
 	return carIdValidation(request.GetCarNumber())
 }
 
+func (w *workshopValidations) ListCars(ctx context.Context, request *workshop.ListCarsRequest) error {
+	if err := pageSizeValidation(request.GetPageSize()); err != nil {
+		return err
+	}
+	for _, bodyStyle := range request.GetBodyStyles() {
+		if _, known := workshop.CarBody_name[int32(bodyStyle)]; !known {
+			return status.Errorf(codes.InvalidArgument, "unknown body style %d", bodyStyle)
+		}
+	}
+	return nil
+}
+
+func (w *workshopValidations) WatchCars(ctx context.Context, request *workshop.WatchCarsRequest) error {
+	if len(request.GetCarNumber()) == 0 {
+		return nil
+	}
+	return carIdValidation(request.GetCarNumber())
+}
+
+func (w *workshopValidations) GetPaintJob(ctx context.Context, request *workshop.GetPaintJobRequest) error {
+	return paintJobIdValidation(request.GetId())
+}
+
+func (w *workshopValidations) ListPaintJobs(ctx context.Context, request *workshop.ListPaintJobsRequest) error {
+	if len(request.GetCarNumber()) > 0 {
+		if err := carIdValidation(request.GetCarNumber()); err != nil {
+			return err
+		}
+	}
+	return pageSizeValidation(request.GetPageSize())
+}
+
+func (w *workshopValidations) CancelPaintJob(ctx context.Context, request *workshop.CancelPaintJobRequest) error {
+	return paintJobIdValidation(request.GetId())
+}
+
+func (w *workshopValidations) RegisterSubWorkshop(ctx context.Context, request *workshop.SubWorkshopRegistration) error {
+	if len(request.GetName()) == 0 {
+		return status.Errorf(codes.InvalidArgument, "sub workshop name can't be empty")
+	}
+	if _, _, err := net.SplitHostPort(request.GetAddress()); err != nil {
+		return status.Errorf(codes.InvalidArgument, "sub workshop address %s should be host:port", request.GetAddress())
+	}
+	if _, known := workshop.SubWorkshopRegistrationTransport_name[int32(request.GetTransportType())]; !known {
+		return status.Errorf(codes.InvalidArgument, "unknown transport %d", request.GetTransportType())
+	}
+	for _, bodyStyle := range request.GetBodyStyles() {
+		if _, known := workshop.CarBody_name[int32(bodyStyle)]; !known {
+			return status.Errorf(codes.InvalidArgument, "unknown body style %d", bodyStyle)
+		}
+	}
+	return nil
+}
+
+func paintJobIdValidation(id string) error {
+	if len(id) == 0 {
+		return status.Errorf(codes.InvalidArgument, "paint job id can't be empty")
+	}
+	return nil
+}
+
+func pageSizeValidation(pageSize int32) error {
+	if pageSize < 0 {
+		return status.Errorf(codes.InvalidArgument, "page size %d can't be negative", pageSize)
+	}
+	return nil
+}
+
 func carIdValidation(carID string) error {
 	if len(carID) != 8 {
 		return status.Errorf(codes.InvalidArgument, "%s should be 8 chars long", carID)
@@ -13,9 +13,11 @@ require (
 	github.com/golang/protobuf v1.4.2
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.0.0
 	github.com/magiconair/properties v1.8.2 // indirect
+	github.com/mattn/go-sqlite3 v1.14.0
 	github.com/opentracing/opentracing-go v1.2.0
 	github.com/spf13/afero v1.3.4 // indirect
 	github.com/stretchr/testify v1.6.1
+	go.etcd.io/bbolt v1.3.5
 	go.uber.org/fx v1.13.1
 	google.golang.org/genproto v0.0.0-20201007142714-5c0e72c5e71e
 	google.golang.org/grpc v1.32.0
@@ -1,17 +1,35 @@
 package main
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/alecthomas/kong"
 	"github.com/go-masonry/mortar/providers"
+	"github.com/go-masonry/tutorial/07-makefile/app/data"
 	"github.com/go-masonry/tutorial/07-makefile/app/mortar"
 	"go.uber.org/fx"
 )
 
+type configFiles struct {
+	Path            string   `arg:"" required:"" help:"Path to config file." type:"existingfile"`
+	AdditionalFiles []string `optional:"" help:"Additional configuration files to merge, comma separated" type:"existingfile"`
+}
+
 var CLI struct {
-	Config struct {
-		Path            string   `arg:"" required:"" help:"Path to config file." type:"existingfile"`
-		AdditionalFiles []string `optional:"" help:"Additional configuration files to merge, comma separated" type:"existingfile"`
-	} `cmd:"" help:"Path to config file."`
+	Config  configFiles `cmd:"" help:"Path to config file."`
+	Migrate struct {
+		Up struct {
+			configFiles
+		} `cmd:"" help:"Apply all pending migrations."`
+		Down struct {
+			configFiles
+			Steps int `default:"1" help:"Number of migrations to revert."`
+		} `cmd:"" help:"Revert applied migrations."`
+		Status struct {
+			configFiles
+		} `cmd:"" help:"Show applied and pending migrations."`
+	} `cmd:"" help:"Manage SQL car db schema."`
 }
 
 func main() {
@@ -20,6 +38,19 @@ func main() {
 	case "config <path>":
 		app := createApplication(CLI.Config.Path, CLI.Config.AdditionalFiles)
 		app.Run()
+	case "migrate up <path>":
+		err := runMigrator(CLI.Migrate.Up.configFiles, func(ctx context.Context, migrator data.Migrator) error {
+			return migrator.Up(ctx)
+		})
+		ctx.FatalIfErrorf(err)
+	case "migrate down <path>":
+		err := runMigrator(CLI.Migrate.Down.configFiles, func(ctx context.Context, migrator data.Migrator) error {
+			return migrator.Down(ctx, CLI.Migrate.Down.Steps)
+		})
+		ctx.FatalIfErrorf(err)
+	case "migrate status <path>":
+		err := runMigrator(CLI.Migrate.Status.configFiles, printMigrationStatus)
+		ctx.FatalIfErrorf(err)
 	default:
 		ctx.Fatalf("unknown option %s", cmd)
 	}
@@ -32,7 +63,13 @@ func createApplication(configFilePath string, additionalFiles []string) *fx.App
 		mortar.TracerFxOption(),                                  // Jaeger tracing
 		mortar.PrometheusFxOption(),                              // Prometheus
 		mortar.HttpClientFxOptions(),
+		mortar.BulkheadFxOption(),       // limit concurrent calls per destination
+		mortar.CircuitBreakerFxOption(), // stop calling failing destinations
 		mortar.HttpServerFxOptions(),
+		mortar.TLSFxOption(),       // certificates of the gRPC server and clients
+		mortar.AuthFxOption(),      // API keys, JWT and who may call what
+		mortar.ErrorsFxOption(),    // domain errors to gRPC/HTTP codes
+		mortar.DeadlinesFxOption(), // per RPC timeouts
 		mortar.InternalHttpHandlersFxOptions(),
 		// Tutorial service dependencies
 		mortar.TutorialAPIsAndOtherDependenciesFxOption(), // register tutorial APIs
@@ -40,3 +77,36 @@ func createApplication(configFilePath string, additionalFiles []string) *fx.App
 		providers.BuildMortarWebServiceFxOption(), // http server invoker
 	)
 }
+
+// runMigrator builds only what is needed to access the SQL car db and calls action with it
+func runMigrator(files configFiles, action func(context.Context, data.Migrator) error) error {
+	var migrator data.Migrator
+	app := fx.New(
+		fx.NopLogger,
+		mortar.ViperFxOption(files.Path, files.AdditionalFiles...),
+		mortar.LoggerFxOption(),
+		fx.Provide(data.CreateMigrator),
+		fx.Populate(&migrator),
+	)
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		return err
+	}
+	defer app.Stop(ctx)
+	return action(ctx, migrator)
+}
+
+func printMigrationStatus(ctx context.Context, migrator data.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		applied := "pending"
+		if status.AppliedAt != nil {
+			applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%4d  %-20s  %s\n", status.Version, applied, status.Name)
+	}
+	return nil
+}
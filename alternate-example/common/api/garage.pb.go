@@ -9,7 +9,9 @@ package workshop
 import (
 	context "context"
 	proto "github.com/golang/protobuf/proto"
+	duration "github.com/golang/protobuf/ptypes/duration"
 	empty "github.com/golang/protobuf/ptypes/empty"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	_ "google.golang.org/genproto/googleapis/api/annotations"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
@@ -80,6 +82,52 @@ func (CarBody) EnumDescriptor() ([]byte, []int) {
 	return file_api_garage_proto_rawDescGZIP(), []int{0, 0}
 }
 
+type SubWorkshopRegistrationTransport int32
+
+const (
+	SubWorkshopRegistration_REST SubWorkshopRegistrationTransport = 0
+	SubWorkshopRegistration_GRPC SubWorkshopRegistrationTransport = 1
+)
+
+// Enum value maps for SubWorkshopRegistrationTransport.
+var (
+	SubWorkshopRegistrationTransport_name = map[int32]string{
+		0: "REST",
+		1: "GRPC",
+	}
+	SubWorkshopRegistrationTransport_value = map[string]int32{
+		"REST": 0,
+		"GRPC": 1,
+	}
+)
+
+func (x SubWorkshopRegistrationTransport) Enum() *SubWorkshopRegistrationTransport {
+	p := new(SubWorkshopRegistrationTransport)
+	*p = x
+	return p
+}
+
+func (x SubWorkshopRegistrationTransport) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SubWorkshopRegistrationTransport) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_garage_proto_enumTypes[1].Descriptor()
+}
+
+func (SubWorkshopRegistrationTransport) Type() protoreflect.EnumType {
+	return &file_api_garage_proto_enumTypes[1]
+}
+
+func (x SubWorkshopRegistrationTransport) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SubWorkshopRegistrationTransport.Descriptor instead.
+func (SubWorkshopRegistrationTransport) EnumDescriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{5, 0}
+}
+
 type Car struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -371,6 +419,396 @@ func (x *SubPaintCarRequest) GetCallbackServiceAddress() string {
 	return ""
 }
 
+type SubWorkshopRegistration struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// registering a name again replaces the previous registration
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// host:port the workshop sends cars to, the REST or gRPC port depending on the transport
+	Address       string                           `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	TransportType SubWorkshopRegistrationTransport `protobuf:"varint,3,opt,name=transport_type,json=transportType,proto3,enum=demo.workshop.SubWorkshopRegistrationTransport" json:"transport_type,omitempty"`
+	// cars it can paint at the same time
+	Capacity int32 `protobuf:"varint,4,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	// colors it can paint, any color if empty
+	Colors []string `protobuf:"bytes,5,rep,name=colors,proto3" json:"colors,omitempty"`
+}
+
+func (x *SubWorkshopRegistration) Reset() {
+	*x = SubWorkshopRegistration{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubWorkshopRegistration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubWorkshopRegistration) ProtoMessage() {}
+
+func (x *SubWorkshopRegistration) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubWorkshopRegistration.ProtoReflect.Descriptor instead.
+func (*SubWorkshopRegistration) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SubWorkshopRegistration) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SubWorkshopRegistration) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *SubWorkshopRegistration) GetTransportType() SubWorkshopRegistrationTransport {
+	if x != nil {
+		return x.TransportType
+	}
+	return SubWorkshopRegistration_REST
+}
+
+func (x *SubWorkshopRegistration) GetCapacity() int32 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+func (x *SubWorkshopRegistration) GetColors() []string {
+	if x != nil {
+		return x.Colors
+	}
+	return nil
+}
+
+type RegisterResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// a sub workshop is removed when it misses heartbeats, it should send one at least this often
+	HeartbeatInterval *duration.Duration `protobuf:"bytes,1,opt,name=heartbeat_interval,json=heartbeatInterval,proto3" json:"heartbeat_interval,omitempty"`
+}
+
+func (x *RegisterResponse) Reset() {
+	*x = RegisterResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterResponse) ProtoMessage() {}
+
+func (x *RegisterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterResponse.ProtoReflect.Descriptor instead.
+func (*RegisterResponse) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RegisterResponse) GetHeartbeatInterval() *duration.Duration {
+	if x != nil {
+		return x.HeartbeatInterval
+	}
+	return nil
+}
+
+type HeartbeatRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name     string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Capacity int32    `protobuf:"varint,2,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	Colors   []string `protobuf:"bytes,3,rep,name=colors,proto3" json:"colors,omitempty"`
+}
+
+func (x *HeartbeatRequest) Reset() {
+	*x = HeartbeatRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HeartbeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatRequest) ProtoMessage() {}
+
+func (x *HeartbeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatRequest.ProtoReflect.Descriptor instead.
+func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HeartbeatRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetCapacity() int32 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+func (x *HeartbeatRequest) GetColors() []string {
+	if x != nil {
+		return x.Colors
+	}
+	return nil
+}
+
+type DeregisterRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (x *DeregisterRequest) Reset() {
+	*x = DeregisterRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeregisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeregisterRequest) ProtoMessage() {}
+
+func (x *DeregisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeregisterRequest.ProtoReflect.Descriptor instead.
+func (*DeregisterRequest) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeregisterRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ListSubWorkshopsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListSubWorkshopsRequest) Reset() {
+	*x = ListSubWorkshopsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListSubWorkshopsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSubWorkshopsRequest) ProtoMessage() {}
+
+func (x *ListSubWorkshopsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSubWorkshopsRequest.ProtoReflect.Descriptor instead.
+func (*ListSubWorkshopsRequest) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{9}
+}
+
+type RegisteredSubWorkshop struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Registration  *SubWorkshopRegistration `protobuf:"bytes,1,opt,name=registration,proto3" json:"registration,omitempty"`
+	RegisterTime  *timestamp.Timestamp     `protobuf:"bytes,2,opt,name=register_time,json=registerTime,proto3" json:"register_time,omitempty"`
+	LastHeartbeat *timestamp.Timestamp     `protobuf:"bytes,3,opt,name=last_heartbeat,json=lastHeartbeat,proto3" json:"last_heartbeat,omitempty"`
+}
+
+func (x *RegisteredSubWorkshop) Reset() {
+	*x = RegisteredSubWorkshop{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisteredSubWorkshop) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisteredSubWorkshop) ProtoMessage() {}
+
+func (x *RegisteredSubWorkshop) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisteredSubWorkshop.ProtoReflect.Descriptor instead.
+func (*RegisteredSubWorkshop) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RegisteredSubWorkshop) GetRegistration() *SubWorkshopRegistration {
+	if x != nil {
+		return x.Registration
+	}
+	return nil
+}
+
+func (x *RegisteredSubWorkshop) GetRegisterTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.RegisterTime
+	}
+	return nil
+}
+
+func (x *RegisteredSubWorkshop) GetLastHeartbeat() *timestamp.Timestamp {
+	if x != nil {
+		return x.LastHeartbeat
+	}
+	return nil
+}
+
+type ListSubWorkshopsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// live sub workshops by name
+	SubWorkshops []*RegisteredSubWorkshop `protobuf:"bytes,1,rep,name=sub_workshops,json=subWorkshops,proto3" json:"sub_workshops,omitempty"`
+}
+
+func (x *ListSubWorkshopsResponse) Reset() {
+	*x = ListSubWorkshopsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_garage_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListSubWorkshopsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSubWorkshopsResponse) ProtoMessage() {}
+
+func (x *ListSubWorkshopsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_garage_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSubWorkshopsResponse.ProtoReflect.Descriptor instead.
+func (*ListSubWorkshopsResponse) Descriptor() ([]byte, []int) {
+	return file_api_garage_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListSubWorkshopsResponse) GetSubWorkshops() []*RegisteredSubWorkshop {
+	if x != nil {
+		return x.SubWorkshops
+	}
+	return nil
+}
+
 var File_api_garage_proto protoreflect.FileDescriptor
 
 var file_api_garage_proto_rawDesc = []byte{
@@ -379,77 +817,155 @@ var file_api_garage_proto_rawDesc = []byte{
 	0x70, 0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x61, 0x6e,
 	0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a,
 	0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
-	0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xb0, 0x01, 0x0a,
-	0x03, 0x43, 0x61, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05,
-	0x6f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6f, 0x77, 0x6e,
-	0x65, 0x72, 0x12, 0x36, 0x0a, 0x0a, 0x62, 0x6f, 0x64, 0x79, 0x5f, 0x73, 0x74, 0x79, 0x6c, 0x65,
-	0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x77, 0x6f,
-	0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72, 0x2e, 0x62, 0x6f, 0x64, 0x79, 0x52,
-	0x09, 0x62, 0x6f, 0x64, 0x79, 0x53, 0x74, 0x79, 0x6c, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f,
-	0x6c, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72,
-	0x22, 0x2d, 0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x12, 0x09, 0x0a, 0x05, 0x53, 0x45, 0x44, 0x41,
-	0x4e, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x50, 0x48, 0x41, 0x45, 0x54, 0x4f, 0x4e, 0x10, 0x01,
-	0x12, 0x0d, 0x0a, 0x09, 0x48, 0x41, 0x54, 0x43, 0x48, 0x42, 0x41, 0x43, 0x4b, 0x10, 0x02, 0x22,
-	0x55, 0x0a, 0x0f, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x61, 0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x61, 0x72, 0x4e, 0x75, 0x6d, 0x62, 0x65,
-	0x72, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64, 0x5f, 0x63, 0x6f, 0x6c,
-	0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65,
-	0x64, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x22, 0x5a, 0x0a, 0x14, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x46,
-	0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d,
-	0x0a, 0x0a, 0x63, 0x61, 0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x09, 0x63, 0x61, 0x72, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x23, 0x0a,
-	0x0d, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64, 0x5f, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64, 0x43, 0x6f, 0x6c,
-	0x6f, 0x72, 0x22, 0x33, 0x0a, 0x12, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x43, 0x61,
-	0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x61, 0x72, 0x5f,
-	0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x61,
-	0x72, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x22, 0x99, 0x01, 0x0a, 0x12, 0x53, 0x75, 0x62, 0x50,
-	0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x24,
-	0x0a, 0x03, 0x63, 0x61, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x64, 0x65,
-	0x6d, 0x6f, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72, 0x52,
-	0x03, 0x63, 0x61, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64, 0x5f,
-	0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65, 0x73,
-	0x69, 0x72, 0x65, 0x64, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x38, 0x0a, 0x18, 0x63, 0x61, 0x6c,
-	0x6c, 0x62, 0x61, 0x63, 0x6b, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x61, 0x64,
-	0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x16, 0x63, 0x61, 0x6c,
-	0x6c, 0x62, 0x61, 0x63, 0x6b, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x41, 0x64, 0x64, 0x72,
-	0x65, 0x73, 0x73, 0x32, 0x8f, 0x03, 0x0a, 0x08, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70,
-	0x12, 0x55, 0x0a, 0x09, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x43, 0x61, 0x72, 0x12, 0x12, 0x2e,
-	0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61,
-	0x72, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x1c, 0x82, 0xd3, 0xe4, 0x93, 0x02,
-	0x16, 0x22, 0x11, 0x2f, 0x76, 0x31, 0x2f, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f,
-	0x63, 0x61, 0x72, 0x73, 0x3a, 0x01, 0x2a, 0x12, 0x73, 0x0a, 0x08, 0x50, 0x61, 0x69, 0x6e, 0x74,
-	0x43, 0x61, 0x72, 0x12, 0x1e, 0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73,
-	0x68, 0x6f, 0x70, 0x2e, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x2f, 0x82, 0xd3, 0xe4,
-	0x93, 0x02, 0x29, 0x1a, 0x24, 0x2f, 0x76, 0x31, 0x2f, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f,
-	0x70, 0x2f, 0x63, 0x61, 0x72, 0x73, 0x2f, 0x7b, 0x63, 0x61, 0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62,
-	0x65, 0x72, 0x7d, 0x2f, 0x70, 0x61, 0x69, 0x6e, 0x74, 0x3a, 0x01, 0x2a, 0x12, 0x6c, 0x0a, 0x0b,
-	0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x43, 0x61, 0x72, 0x12, 0x21, 0x2e, 0x64, 0x65,
-	0x6d, 0x6f, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x52, 0x65, 0x74, 0x72,
-	0x69, 0x65, 0x76, 0x65, 0x43, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12,
-	0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43,
-	0x61, 0x72, 0x22, 0x26, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x20, 0x12, 0x1e, 0x2f, 0x76, 0x31, 0x2f,
-	0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f, 0x63, 0x61, 0x72, 0x73, 0x2f, 0x7b, 0x63,
-	0x61, 0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x7d, 0x12, 0x49, 0x0a, 0x0a, 0x43, 0x61,
-	0x72, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x65, 0x64, 0x12, 0x23, 0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e,
-	0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x46, 0x69,
-	0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e,
-	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
-	0x45, 0x6d, 0x70, 0x74, 0x79, 0x32, 0x76, 0x0a, 0x0b, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b,
-	0x73, 0x68, 0x6f, 0x70, 0x12, 0x67, 0x0a, 0x08, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72,
-	0x12, 0x21, 0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70,
-	0x2e, 0x53, 0x75, 0x62, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x20, 0x82, 0xd3, 0xe4,
-	0x93, 0x02, 0x1a, 0x22, 0x15, 0x2f, 0x76, 0x31, 0x2f, 0x73, 0x75, 0x62, 0x77, 0x6f, 0x72, 0x6b,
-	0x73, 0x68, 0x6f, 0x70, 0x2f, 0x70, 0x61, 0x69, 0x6e, 0x74, 0x3a, 0x01, 0x2a, 0x42, 0x0c, 0x5a,
-	0x0a, 0x2e, 0x3b, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x62, 0x06, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x33,
+	0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x75,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xb0, 0x01,
+	0x0a, 0x03, 0x43, 0x61, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x14, 0x0a,
+	0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6f, 0x77,
+	0x6e, 0x65, 0x72, 0x12, 0x36, 0x0a, 0x0a, 0x62, 0x6f, 0x64, 0x79, 0x5f, 0x73, 0x74, 0x79, 0x6c,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x77,
+	0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72, 0x2e, 0x62, 0x6f, 0x64, 0x79,
+	0x52, 0x09, 0x62, 0x6f, 0x64, 0x79, 0x53, 0x74, 0x79, 0x6c, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63,
+	0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x63, 0x6f, 0x6c, 0x6f,
+	0x72, 0x22, 0x2d, 0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x12, 0x09, 0x0a, 0x05, 0x53, 0x45, 0x44,
+	0x41, 0x4e, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x50, 0x48, 0x41, 0x45, 0x54, 0x4f, 0x4e, 0x10,
+	0x01, 0x12, 0x0d, 0x0a, 0x09, 0x48, 0x41, 0x54, 0x43, 0x48, 0x42, 0x41, 0x43, 0x4b, 0x10, 0x02,
+	0x22, 0x55, 0x0a, 0x0f, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x61, 0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x61, 0x72, 0x4e, 0x75, 0x6d, 0x62,
+	0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64, 0x5f, 0x63, 0x6f,
+	0x6c, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65, 0x73, 0x69, 0x72,
+	0x65, 0x64, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x22, 0x5a, 0x0a, 0x14, 0x50, 0x61, 0x69, 0x6e, 0x74,
+	0x46, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x1d, 0x0a, 0x0a, 0x63, 0x61, 0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x61, 0x72, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x23,
+	0x0a, 0x0d, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64, 0x5f, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64, 0x43, 0x6f,
+	0x6c, 0x6f, 0x72, 0x22, 0x33, 0x0a, 0x12, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x43,
+	0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x61, 0x72,
+	0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63,
+	0x61, 0x72, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x22, 0x99, 0x01, 0x0a, 0x12, 0x53, 0x75, 0x62,
+	0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x24, 0x0a, 0x03, 0x63, 0x61, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x64,
+	0x65, 0x6d, 0x6f, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72,
+	0x52, 0x03, 0x63, 0x61, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x73, 0x69, 0x72, 0x65, 0x64,
+	0x5f, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65,
+	0x73, 0x69, 0x72, 0x65, 0x64, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x38, 0x0a, 0x18, 0x63, 0x61,
+	0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x61,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x16, 0x63, 0x61,
+	0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x41, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x22, 0xf5, 0x01, 0x0a, 0x17, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b,
+	0x73, 0x68, 0x6f, 0x70, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x57,
+	0x0a, 0x0e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x30, 0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x77, 0x6f,
+	0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x68,
+	0x6f, 0x70, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x0d, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70,
+	0x6f, 0x72, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63,
+	0x69, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63,
+	0x69, 0x74, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x73, 0x18, 0x05, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x06, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x73, 0x22, 0x1f, 0x0a, 0x09, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x08, 0x0a, 0x04, 0x52, 0x45, 0x53, 0x54,
+	0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x47, 0x52, 0x50, 0x43, 0x10, 0x01, 0x22, 0x5c, 0x0a, 0x10,
+	0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x48, 0x0a, 0x12, 0x68, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x5f, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44,
+	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x11, 0x68, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65,
+	0x61, 0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x22, 0x5a, 0x0a, 0x10, 0x48, 0x65,
+	0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12,
+	0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x12, 0x16,
+	0x0a, 0x06, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06,
+	0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x73, 0x22, 0x27, 0x0a, 0x11, 0x44, 0x65, 0x72, 0x65, 0x67, 0x69,
+	0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22,
+	0x19, 0x0a, 0x17, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x68,
+	0x6f, 0x70, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0xe7, 0x01, 0x0a, 0x15, 0x52,
+	0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x65, 0x64, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b,
+	0x73, 0x68, 0x6f, 0x70, 0x12, 0x4a, 0x0a, 0x0c, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x64, 0x65, 0x6d,
+	0x6f, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x53, 0x75, 0x62, 0x57, 0x6f,
+	0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x0c, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x3f, 0x0a, 0x0d, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x5f, 0x74, 0x69, 0x6d,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x0c, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x54, 0x69, 0x6d,
+	0x65, 0x12, 0x41, 0x0a, 0x0e, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x68, 0x65, 0x61, 0x72, 0x74, 0x62,
+	0x65, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0d, 0x6c, 0x61, 0x73, 0x74, 0x48, 0x65, 0x61, 0x72, 0x74,
+	0x62, 0x65, 0x61, 0x74, 0x22, 0x65, 0x0a, 0x18, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x75, 0x62, 0x57,
+	0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x49, 0x0a, 0x0d, 0x73, 0x75, 0x62, 0x5f, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x77,
+	0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72,
+	0x65, 0x64, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x52, 0x0c, 0x73,
+	0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x73, 0x32, 0x8f, 0x03, 0x0a, 0x08,
+	0x57, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x12, 0x55, 0x0a, 0x09, 0x41, 0x63, 0x63, 0x65,
+	0x70, 0x74, 0x43, 0x61, 0x72, 0x12, 0x12, 0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x77, 0x6f, 0x72,
+	0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x22, 0x1c, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x16, 0x22, 0x11, 0x2f, 0x76, 0x31, 0x2f, 0x77,
+	0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f, 0x63, 0x61, 0x72, 0x73, 0x3a, 0x01, 0x2a, 0x12,
+	0x73, 0x0a, 0x08, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72, 0x12, 0x1e, 0x2e, 0x64, 0x65,
+	0x6d, 0x6f, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x50, 0x61, 0x69, 0x6e,
+	0x74, 0x43, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x22, 0x2f, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x29, 0x1a, 0x24, 0x2f, 0x76, 0x31,
+	0x2f, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f, 0x63, 0x61, 0x72, 0x73, 0x2f, 0x7b,
+	0x63, 0x61, 0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x7d, 0x2f, 0x70, 0x61, 0x69, 0x6e,
+	0x74, 0x3a, 0x01, 0x2a, 0x12, 0x6c, 0x0a, 0x0b, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65,
+	0x43, 0x61, 0x72, 0x12, 0x21, 0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73,
+	0x68, 0x6f, 0x70, 0x2e, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x43, 0x61, 0x72, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x77, 0x6f,
+	0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x43, 0x61, 0x72, 0x22, 0x26, 0x82, 0xd3, 0xe4, 0x93,
+	0x02, 0x20, 0x12, 0x1e, 0x2f, 0x76, 0x31, 0x2f, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70,
+	0x2f, 0x63, 0x61, 0x72, 0x73, 0x2f, 0x7b, 0x63, 0x61, 0x72, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x7d, 0x12, 0x49, 0x0a, 0x0a, 0x43, 0x61, 0x72, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x65, 0x64,
+	0x12, 0x23, 0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70,
+	0x2e, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x32, 0x76, 0x0a,
+	0x0b, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x12, 0x67, 0x0a, 0x08,
+	0x50, 0x61, 0x69, 0x6e, 0x74, 0x43, 0x61, 0x72, 0x12, 0x21, 0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e,
+	0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x53, 0x75, 0x62, 0x50, 0x61, 0x69, 0x6e,
+	0x74, 0x43, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x22, 0x20, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1a, 0x22, 0x15, 0x2f, 0x76, 0x31,
+	0x2f, 0x73, 0x75, 0x62, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2f, 0x70, 0x61, 0x69,
+	0x6e, 0x74, 0x3a, 0x01, 0x2a, 0x32, 0xdd, 0x02, 0x0a, 0x13, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72,
+	0x6b, 0x73, 0x68, 0x6f, 0x70, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x12, 0x53, 0x0a,
+	0x08, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x26, 0x2e, 0x64, 0x65, 0x6d, 0x6f,
+	0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72,
+	0x6b, 0x73, 0x68, 0x6f, 0x70, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x1a, 0x1f, 0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f,
+	0x70, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x44, 0x0a, 0x09, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x12,
+	0x1f, 0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e,
+	0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x46, 0x0a, 0x0a, 0x44, 0x65, 0x72, 0x65,
+	0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x20, 0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x77, 0x6f,
+	0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x44, 0x65, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65,
+	0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
+	0x12, 0x63, 0x0a, 0x10, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b, 0x73,
+	0x68, 0x6f, 0x70, 0x73, 0x12, 0x26, 0x2e, 0x64, 0x65, 0x6d, 0x6f, 0x2e, 0x77, 0x6f, 0x72, 0x6b,
+	0x73, 0x68, 0x6f, 0x70, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b,
+	0x73, 0x68, 0x6f, 0x70, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x27, 0x2e, 0x64,
+	0x65, 0x6d, 0x6f, 0x2e, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x4c, 0x69, 0x73,
+	0x74, 0x53, 0x75, 0x62, 0x57, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x3b, 0x77, 0x6f, 0x72, 0x6b, 0x73,
+	0x68, 0x6f, 0x70, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -464,35 +980,59 @@ func file_api_garage_proto_rawDescGZIP() []byte {
 	return file_api_garage_proto_rawDescData
 }
 
-var file_api_garage_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_api_garage_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_api_garage_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_api_garage_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_api_garage_proto_goTypes = []interface{}{
-	(CarBody)(0),                 // 0: demo.workshop.Car.body
-	(*Car)(nil),                  // 1: demo.workshop.Car
-	(*PaintCarRequest)(nil),      // 2: demo.workshop.PaintCarRequest
-	(*PaintFinishedRequest)(nil), // 3: demo.workshop.PaintFinishedRequest
-	(*RetrieveCarRequest)(nil),   // 4: demo.workshop.RetrieveCarRequest
-	(*SubPaintCarRequest)(nil),   // 5: demo.workshop.SubPaintCarRequest
-	(*empty.Empty)(nil),          // 6: google.protobuf.Empty
+	(CarBody)(0),                          // 0: demo.workshop.Car.body
+	(SubWorkshopRegistrationTransport)(0), // 1: demo.workshop.SubWorkshopRegistration.transport
+	(*Car)(nil),                           // 2: demo.workshop.Car
+	(*PaintCarRequest)(nil),               // 3: demo.workshop.PaintCarRequest
+	(*PaintFinishedRequest)(nil),          // 4: demo.workshop.PaintFinishedRequest
+	(*RetrieveCarRequest)(nil),            // 5: demo.workshop.RetrieveCarRequest
+	(*SubPaintCarRequest)(nil),            // 6: demo.workshop.SubPaintCarRequest
+	(*SubWorkshopRegistration)(nil),       // 7: demo.workshop.SubWorkshopRegistration
+	(*RegisterResponse)(nil),              // 8: demo.workshop.RegisterResponse
+	(*HeartbeatRequest)(nil),              // 9: demo.workshop.HeartbeatRequest
+	(*DeregisterRequest)(nil),             // 10: demo.workshop.DeregisterRequest
+	(*ListSubWorkshopsRequest)(nil),       // 11: demo.workshop.ListSubWorkshopsRequest
+	(*RegisteredSubWorkshop)(nil),         // 12: demo.workshop.RegisteredSubWorkshop
+	(*ListSubWorkshopsResponse)(nil),      // 13: demo.workshop.ListSubWorkshopsResponse
+	(*duration.Duration)(nil),             // 14: google.protobuf.Duration
+	(*timestamp.Timestamp)(nil),           // 15: google.protobuf.Timestamp
+	(*empty.Empty)(nil),                   // 16: google.protobuf.Empty
 }
 var file_api_garage_proto_depIdxs = []int32{
-	0, // 0: demo.workshop.Car.body_style:type_name -> demo.workshop.Car.body
-	1, // 1: demo.workshop.SubPaintCarRequest.car:type_name -> demo.workshop.Car
-	1, // 2: demo.workshop.Workshop.AcceptCar:input_type -> demo.workshop.Car
-	2, // 3: demo.workshop.Workshop.PaintCar:input_type -> demo.workshop.PaintCarRequest
-	4, // 4: demo.workshop.Workshop.RetrieveCar:input_type -> demo.workshop.RetrieveCarRequest
-	3, // 5: demo.workshop.Workshop.CarPainted:input_type -> demo.workshop.PaintFinishedRequest
-	5, // 6: demo.workshop.SubWorkshop.PaintCar:input_type -> demo.workshop.SubPaintCarRequest
-	6, // 7: demo.workshop.Workshop.AcceptCar:output_type -> google.protobuf.Empty
-	6, // 8: demo.workshop.Workshop.PaintCar:output_type -> google.protobuf.Empty
-	1, // 9: demo.workshop.Workshop.RetrieveCar:output_type -> demo.workshop.Car
-	6, // 10: demo.workshop.Workshop.CarPainted:output_type -> google.protobuf.Empty
-	6, // 11: demo.workshop.SubWorkshop.PaintCar:output_type -> google.protobuf.Empty
-	7, // [7:12] is the sub-list for method output_type
-	2, // [2:7] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	0,  // 0: demo.workshop.Car.body_style:type_name -> demo.workshop.Car.body
+	2,  // 1: demo.workshop.SubPaintCarRequest.car:type_name -> demo.workshop.Car
+	1,  // 2: demo.workshop.SubWorkshopRegistration.transport_type:type_name -> demo.workshop.SubWorkshopRegistration.transport
+	14, // 3: demo.workshop.RegisterResponse.heartbeat_interval:type_name -> google.protobuf.Duration
+	7,  // 4: demo.workshop.RegisteredSubWorkshop.registration:type_name -> demo.workshop.SubWorkshopRegistration
+	15, // 5: demo.workshop.RegisteredSubWorkshop.register_time:type_name -> google.protobuf.Timestamp
+	15, // 6: demo.workshop.RegisteredSubWorkshop.last_heartbeat:type_name -> google.protobuf.Timestamp
+	12, // 7: demo.workshop.ListSubWorkshopsResponse.sub_workshops:type_name -> demo.workshop.RegisteredSubWorkshop
+	2,  // 8: demo.workshop.Workshop.AcceptCar:input_type -> demo.workshop.Car
+	3,  // 9: demo.workshop.Workshop.PaintCar:input_type -> demo.workshop.PaintCarRequest
+	5,  // 10: demo.workshop.Workshop.RetrieveCar:input_type -> demo.workshop.RetrieveCarRequest
+	4,  // 11: demo.workshop.Workshop.CarPainted:input_type -> demo.workshop.PaintFinishedRequest
+	6,  // 12: demo.workshop.SubWorkshop.PaintCar:input_type -> demo.workshop.SubPaintCarRequest
+	7,  // 13: demo.workshop.SubWorkshopRegistry.Register:input_type -> demo.workshop.SubWorkshopRegistration
+	9,  // 14: demo.workshop.SubWorkshopRegistry.Heartbeat:input_type -> demo.workshop.HeartbeatRequest
+	10, // 15: demo.workshop.SubWorkshopRegistry.Deregister:input_type -> demo.workshop.DeregisterRequest
+	11, // 16: demo.workshop.SubWorkshopRegistry.ListSubWorkshops:input_type -> demo.workshop.ListSubWorkshopsRequest
+	16, // 17: demo.workshop.Workshop.AcceptCar:output_type -> google.protobuf.Empty
+	16, // 18: demo.workshop.Workshop.PaintCar:output_type -> google.protobuf.Empty
+	2,  // 19: demo.workshop.Workshop.RetrieveCar:output_type -> demo.workshop.Car
+	16, // 20: demo.workshop.Workshop.CarPainted:output_type -> google.protobuf.Empty
+	16, // 21: demo.workshop.SubWorkshop.PaintCar:output_type -> google.protobuf.Empty
+	8,  // 22: demo.workshop.SubWorkshopRegistry.Register:output_type -> demo.workshop.RegisterResponse
+	16, // 23: demo.workshop.SubWorkshopRegistry.Heartbeat:output_type -> google.protobuf.Empty
+	16, // 24: demo.workshop.SubWorkshopRegistry.Deregister:output_type -> google.protobuf.Empty
+	13, // 25: demo.workshop.SubWorkshopRegistry.ListSubWorkshops:output_type -> demo.workshop.ListSubWorkshopsResponse
+	17, // [17:26] is the sub-list for method output_type
+	8,  // [8:17] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_api_garage_proto_init() }
@@ -561,16 +1101,100 @@ func file_api_garage_proto_init() {
 				return nil
 			}
 		}
+		file_api_garage_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubWorkshopRegistration); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RegisterResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HeartbeatRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeregisterRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListSubWorkshopsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RegisteredSubWorkshop); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_garage_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListSubWorkshopsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_api_garage_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   5,
+			NumEnums:      2,
+			NumMessages:   12,
 			NumExtensions: 0,
-			NumServices:   2,
+			NumServices:   3,
 		},
 		GoTypes:           file_api_garage_proto_goTypes,
 		DependencyIndexes: file_api_garage_proto_depIdxs,
@@ -842,3 +1466,185 @@ var _SubWorkshop_serviceDesc = grpc.ServiceDesc{
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/garage.proto",
 }
+
+// SubWorkshopRegistryClient is the client API for SubWorkshopRegistry service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type SubWorkshopRegistryClient interface {
+	Register(ctx context.Context, in *SubWorkshopRegistration, opts ...grpc.CallOption) (*RegisterResponse, error)
+	// fails with NOT_FOUND when the sub workshop isn't registered, it should register again
+	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	Deregister(ctx context.Context, in *DeregisterRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	ListSubWorkshops(ctx context.Context, in *ListSubWorkshopsRequest, opts ...grpc.CallOption) (*ListSubWorkshopsResponse, error)
+}
+
+type subWorkshopRegistryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSubWorkshopRegistryClient(cc grpc.ClientConnInterface) SubWorkshopRegistryClient {
+	return &subWorkshopRegistryClient{cc}
+}
+
+func (c *subWorkshopRegistryClient) Register(ctx context.Context, in *SubWorkshopRegistration, opts ...grpc.CallOption) (*RegisterResponse, error) {
+	out := new(RegisterResponse)
+	err := c.cc.Invoke(ctx, "/demo.workshop.SubWorkshopRegistry/Register", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *subWorkshopRegistryClient) Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/demo.workshop.SubWorkshopRegistry/Heartbeat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *subWorkshopRegistryClient) Deregister(ctx context.Context, in *DeregisterRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/demo.workshop.SubWorkshopRegistry/Deregister", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *subWorkshopRegistryClient) ListSubWorkshops(ctx context.Context, in *ListSubWorkshopsRequest, opts ...grpc.CallOption) (*ListSubWorkshopsResponse, error) {
+	out := new(ListSubWorkshopsResponse)
+	err := c.cc.Invoke(ctx, "/demo.workshop.SubWorkshopRegistry/ListSubWorkshops", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SubWorkshopRegistryServer is the server API for SubWorkshopRegistry service.
+type SubWorkshopRegistryServer interface {
+	Register(context.Context, *SubWorkshopRegistration) (*RegisterResponse, error)
+	// fails with NOT_FOUND when the sub workshop isn't registered, it should register again
+	Heartbeat(context.Context, *HeartbeatRequest) (*empty.Empty, error)
+	Deregister(context.Context, *DeregisterRequest) (*empty.Empty, error)
+	ListSubWorkshops(context.Context, *ListSubWorkshopsRequest) (*ListSubWorkshopsResponse, error)
+}
+
+// UnimplementedSubWorkshopRegistryServer can be embedded to have forward compatible implementations.
+type UnimplementedSubWorkshopRegistryServer struct {
+}
+
+func (*UnimplementedSubWorkshopRegistryServer) Register(context.Context, *SubWorkshopRegistration) (*RegisterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (*UnimplementedSubWorkshopRegistryServer) Heartbeat(context.Context, *HeartbeatRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
+}
+func (*UnimplementedSubWorkshopRegistryServer) Deregister(context.Context, *DeregisterRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Deregister not implemented")
+}
+func (*UnimplementedSubWorkshopRegistryServer) ListSubWorkshops(context.Context, *ListSubWorkshopsRequest) (*ListSubWorkshopsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSubWorkshops not implemented")
+}
+
+func RegisterSubWorkshopRegistryServer(s *grpc.Server, srv SubWorkshopRegistryServer) {
+	s.RegisterService(&_SubWorkshopRegistry_serviceDesc, srv)
+}
+
+func _SubWorkshopRegistry_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubWorkshopRegistration)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SubWorkshopRegistryServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/demo.workshop.SubWorkshopRegistry/Register",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SubWorkshopRegistryServer).Register(ctx, req.(*SubWorkshopRegistration))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SubWorkshopRegistry_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SubWorkshopRegistryServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/demo.workshop.SubWorkshopRegistry/Heartbeat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SubWorkshopRegistryServer).Heartbeat(ctx, req.(*HeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SubWorkshopRegistry_Deregister_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeregisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SubWorkshopRegistryServer).Deregister(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/demo.workshop.SubWorkshopRegistry/Deregister",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SubWorkshopRegistryServer).Deregister(ctx, req.(*DeregisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SubWorkshopRegistry_ListSubWorkshops_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSubWorkshopsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SubWorkshopRegistryServer).ListSubWorkshops(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/demo.workshop.SubWorkshopRegistry/ListSubWorkshops",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SubWorkshopRegistryServer).ListSubWorkshops(ctx, req.(*ListSubWorkshopsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _SubWorkshopRegistry_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "demo.workshop.SubWorkshopRegistry",
+	HandlerType: (*SubWorkshopRegistryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _SubWorkshopRegistry_Register_Handler,
+		},
+		{
+			MethodName: "Heartbeat",
+			Handler:    _SubWorkshopRegistry_Heartbeat_Handler,
+		},
+		{
+			MethodName: "Deregister",
+			Handler:    _SubWorkshopRegistry_Deregister_Handler,
+		},
+		{
+			MethodName: "ListSubWorkshops",
+			Handler:    _SubWorkshopRegistry_ListSubWorkshops_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/garage.proto",
+}
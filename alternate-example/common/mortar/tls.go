@@ -0,0 +1,30 @@
+package mortar
+
+import (
+	"fmt"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	// TLSCAKey is the PEM file of the CA the gRPC servers this service calls are verified with,
+	// gRPC calls are made in plain text when it isn't set
+	TLSCAKey = "custom.tls.ca"
+	// TLSServerNameKey is expected in server certificates instead of the dialed host
+	TLSServerNameKey = "custom.tls.servername"
+)
+
+// GRPCDialOption returns the transport credentials of gRPC calls to other services
+func GRPCDialOption(config cfg.Config) (grpc.DialOption, error) {
+	ca := config.Get(TLSCAKey).String()
+	if len(ca) == 0 {
+		return grpc.WithInsecure(), nil
+	}
+	creds, err := credentials.NewClientTLSFromFile(ca, config.Get(TLSServerNameKey).String())
+	if err != nil {
+		return nil, fmt.Errorf("can't load %s, %w", TLSCAKey, err)
+	}
+	return grpc.WithTransportCredentials(creds), nil
+}
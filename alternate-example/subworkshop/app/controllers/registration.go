@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/http/client"
+	"github.com/go-masonry/mortar/interfaces/log"
+	"github.com/go-masonry/mortar/mortar"
+	"github.com/golang/protobuf/ptypes"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	api "workshop-common/api"
+	common "workshop-common/mortar"
+)
+
+const (
+	// RegistryAddressKey is the gRPC address of the workshop this sub workshop registers with, it doesn't register when it's empty
+	RegistryAddressKey = "custom.registry.address"
+	// RegistryNameKey names this sub workshop in the registry, by default it's mortar.name
+	RegistryNameKey = "custom.registry.name"
+	// AdvertisedAddressKey is the host:port the workshop sends cars to, it must be reachable from the workshop.
+	// By default it's this service REST or gRPC port on localhost, depending on the transport.
+	AdvertisedAddressKey = "custom.registry.advertise.address"
+	// AdvertisedTransportKey tells the workshop how to send cars, "rest" (default) or "grpc"
+	AdvertisedTransportKey = "custom.registry.advertise.transport"
+	// CapacityKey is how many cars are painted at the same time
+	CapacityKey = "custom.registry.capacity"
+	// ColorsKey lists the colors this sub workshop paints, any color if empty
+	ColorsKey = "custom.registry.colors"
+	// HeartbeatIntervalKey is how often heartbeats are sent, by default it's the interval the workshop asks for
+	HeartbeatIntervalKey = "custom.registry.heartbeat"
+
+	defaultHeartbeatInterval = 10 * time.Second
+	registryCallTimeout      = 5 * time.Second
+)
+
+type registrationDeps struct {
+	fx.In
+
+	Config            cfg.Config
+	Logger            log.Logger
+	Lifecycle         fx.Lifecycle
+	GRPCClientBuilder client.GRPCClientConnectionBuilder
+}
+
+// registration keeps this sub workshop registered with the workshop
+type registration struct {
+	deps       registrationDeps
+	address    string
+	conn       grpc.ClientConnInterface // to the workshop, shared by all the calls
+	request    *api.SubWorkshopRegistration
+	configured time.Duration // heartbeat interval from the configuration, zero if not set
+	interval   time.Duration
+	registered bool
+	stopping   chan struct{}
+	done       chan struct{}
+}
+
+// RegisterWithWorkshop registers this sub workshop once the application starts, sends heartbeats while it runs
+// and deregisters it when the application stops. It's meant to be invoked by Fx.
+func RegisterWithWorkshop(deps registrationDeps) error {
+	ctx := context.Background()
+	address := deps.Config.Get(RegistryAddressKey).String()
+	if len(address) == 0 {
+		deps.Logger.Info(ctx, "%s isn't set, not registering with the workshop", RegistryAddressKey)
+		return nil
+	}
+	request, err := registrationRequest(deps.Config)
+	if err != nil {
+		return err
+	}
+	dialOption, err := common.GRPCDialOption(deps.Config)
+	if err != nil {
+		return err
+	}
+	// not blocking, the connection is established by the first call
+	conn, err := deps.GRPCClientBuilder.Build().Dial(ctx, address, dialOption)
+	if err != nil {
+		return fmt.Errorf("can't reach workshop at %s, %w", address, err)
+	}
+	r := &registration{
+		deps:     deps,
+		address:  address,
+		conn:     conn,
+		request:  request,
+		interval: defaultHeartbeatInterval,
+		stopping: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if value := deps.Config.Get(HeartbeatIntervalKey); value.IsSet() {
+		r.configured = value.Duration()
+		r.interval = r.configured
+	}
+	deps.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go r.run()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(r.stopping)
+			select {
+			case <-r.done:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			defer r.close(ctx)
+			if !r.registered {
+				return nil
+			}
+			err := r.call(ctx, func(ctx context.Context, registry api.SubWorkshopRegistryClient) (err error) {
+				_, err = registry.Deregister(ctx, &api.DeregisterRequest{Name: r.request.GetName()})
+				return
+			})
+			r.deps.Logger.WithError(err).Info(ctx, "sub workshop %s deregistered", r.request.GetName())
+			return err
+		},
+	})
+	return nil
+}
+
+func registrationRequest(config cfg.Config) (*api.SubWorkshopRegistration, error) {
+	request := &api.SubWorkshopRegistration{
+		Name:     config.Get(mortar.Name).String(),
+		Address:  "localhost:" + config.Get(mortar.ServerRESTExternalPort).String(),
+		Capacity: 1,
+		Colors:   config.Get(ColorsKey).StringSlice(),
+	}
+	if value := config.Get(RegistryNameKey); value.IsSet() {
+		request.Name = value.String()
+	}
+	switch transport := config.Get(AdvertisedTransportKey).String(); transport {
+	case "", "rest":
+	case "grpc":
+		request.TransportType = api.SubWorkshopRegistration_GRPC
+		request.Address = "localhost:" + config.Get(mortar.ServerGRPCPort).String()
+	default:
+		return nil, fmt.Errorf("unknown transport %s", transport)
+	}
+	if value := config.Get(AdvertisedAddressKey); value.IsSet() {
+		request.Address = value.String()
+	}
+	if value := config.Get(CapacityKey); value.IsSet() {
+		request.Capacity = int32(value.Int())
+	}
+	return request, nil
+}
+
+func (r *registration) run() {
+	defer close(r.done)
+	ctx := context.Background()
+	timer := time.NewTimer(r.beat(ctx))
+	defer timer.Stop()
+	for {
+		select {
+		case <-r.stopping:
+			return
+		case <-timer.C:
+			timer.Reset(r.beat(ctx))
+		}
+	}
+}
+
+// beat registers or sends a heartbeat, it returns when it should be called again
+func (r *registration) beat(ctx context.Context) time.Duration {
+	if r.registered {
+		err := r.call(ctx, func(ctx context.Context, registry api.SubWorkshopRegistryClient) (err error) {
+			_, err = registry.Heartbeat(ctx, &api.HeartbeatRequest{
+				Name:     r.request.GetName(),
+				Capacity: r.request.GetCapacity(),
+				Colors:   r.request.GetColors(),
+			})
+			return
+		})
+		if status.Code(err) != codes.NotFound {
+			r.deps.Logger.WithError(err).Debug(ctx, "heartbeat sent to %s", r.address)
+			return r.interval
+		}
+		r.deps.Logger.Warn(ctx, "workshop at %s forgot sub workshop %s, registering again", r.address, r.request.GetName())
+		r.registered = false
+	}
+	err := r.call(ctx, func(ctx context.Context, registry api.SubWorkshopRegistryClient) error {
+		response, err := registry.Register(ctx, r.request)
+		if err != nil {
+			return err
+		}
+		if interval, err := ptypes.Duration(response.GetHeartbeatInterval()); err == nil && interval > 0 && r.configured == 0 {
+			r.interval = interval
+		}
+		return nil
+	})
+	if err != nil {
+		r.deps.Logger.WithError(err).Warn(ctx, "failed to register with workshop at %s", r.address)
+		return r.interval
+	}
+	r.registered = true
+	r.deps.Logger.Info(ctx, "sub workshop %s registered with workshop at %s", r.request.GetName(), r.address)
+	return r.interval
+}
+
+func (r *registration) call(ctx context.Context, method func(context.Context, api.SubWorkshopRegistryClient) error) error {
+	ctx, cancel := context.WithTimeout(ctx, registryCallTimeout)
+	defer cancel()
+	return method(ctx, api.NewSubWorkshopRegistryClient(r.conn))
+}
+
+func (r *registration) close(ctx context.Context) {
+	if closer, ok := r.conn.(io.Closer); ok {
+		err := closer.Close()
+		r.deps.Logger.WithError(err).Debug(ctx, "connection to workshop at %s closed", r.address)
+	}
+}
@@ -0,0 +1,186 @@
+package controllers_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+	common "workshop-common/mortar"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/http/client"
+	mock_client "github.com/go-masonry/mortar/interfaces/http/client/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"subworkshop/app/controllers"
+	api "workshop-common/api"
+)
+
+type registrationSuite struct {
+	suite.Suite
+	pwd                 string
+	ctrl                *gomock.Controller
+	grpcConnBuilderMock *mock_client.MockGRPCClientConnectionBuilder
+	gRPCWrapperMock     *mock_client.MockGRPCClientConnectionWrapper
+	registry            *fakeRegistry
+}
+
+func TestRegistration(t *testing.T) {
+	suite.Run(t, new(registrationSuite))
+}
+
+func (s *registrationSuite) TestRegisterHeartbeatAndDeregister() {
+	app := s.startApp(fx.Invoke(func(config cfg.Config) {
+		config.Set(controllers.RegistryAddressKey, "workshop:5380")
+		config.Set(controllers.AdvertisedAddressKey, "subworkshop-1:5480")
+		config.Set(controllers.AdvertisedTransportKey, "grpc")
+		config.Set(controllers.CapacityKey, 4)
+		config.Set(controllers.ColorsKey, []string{"red", "blue"})
+	}))
+	// the workshop asks for a heartbeat every 10ms
+	s.Eventually(func() bool { return len(s.registry.calls("Heartbeat")) >= 2 }, time.Second, time.Millisecond)
+	registrations := s.registry.calls("Register")
+	s.Require().Len(registrations, 1)
+	registration := registrations[0].(*api.SubWorkshopRegistration)
+	s.Equal("tutorial_test", registration.GetName())
+	s.Equal("subworkshop-1:5480", registration.GetAddress())
+	s.Equal(api.SubWorkshopRegistration_GRPC, registration.GetTransportType())
+	s.Equal(int32(4), registration.GetCapacity())
+	s.Equal([]string{"red", "blue"}, registration.GetColors())
+	heartbeat := s.registry.calls("Heartbeat")[0].(*api.HeartbeatRequest)
+	s.Equal("tutorial_test", heartbeat.GetName())
+	s.Equal(int32(4), heartbeat.GetCapacity())
+	s.False(s.registry.isClosed())
+
+	app.RequireStop()
+	deregistrations := s.registry.calls("Deregister")
+	s.Require().Len(deregistrations, 1)
+	s.Equal("tutorial_test", deregistrations[0].(*api.DeregisterRequest).GetName())
+	// every call used the same connection, closed after deregistering
+	s.Equal(1, s.registry.dials())
+	s.True(s.registry.isClosed())
+}
+
+func (s *registrationSuite) TestRegisterAgainWhenForgotten() {
+	app := s.startApp(fx.Invoke(func(config cfg.Config) {
+		config.Set(controllers.RegistryAddressKey, "workshop:5380")
+		config.Set(controllers.RegistryNameKey, "forgotten")
+	}))
+	defer app.RequireStop()
+	s.Eventually(func() bool { return len(s.registry.calls("Register")) == 1 }, time.Second, time.Millisecond)
+	s.registry.setError("Heartbeat", status.Error(codes.NotFound, "sub workshop forgotten is not registered"))
+	s.Eventually(func() bool { return len(s.registry.calls("Register")) == 2 }, time.Second, time.Millisecond)
+}
+
+func (s *registrationSuite) TestNotRegisteredWithoutAddress() {
+	app := s.startApp()
+	// nothing is dialed, the mocks would fail otherwise
+	app.RequireStop()
+	s.Empty(s.registry.calls("Register"))
+}
+
+func (s *registrationSuite) SetupSuite() {
+	var err error
+	s.pwd, err = os.Getwd()
+	s.Require().NoError(err)
+}
+
+func (s *registrationSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.grpcConnBuilderMock = mock_client.NewMockGRPCClientConnectionBuilder(s.ctrl)
+	s.gRPCWrapperMock = mock_client.NewMockGRPCClientConnectionWrapper(s.ctrl)
+	s.registry = &fakeRegistry{received: make(map[string][]interface{}), errors: make(map[string]error)}
+}
+
+func (s *registrationSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *registrationSuite) startApp(options ...fx.Option) *fxtest.App {
+	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), "workshop:5380", gomock.Any()).DoAndReturn(
+		func(context.Context, string, ...grpc.DialOption) (grpc.ClientConnInterface, error) {
+			s.registry.dialed()
+			return s.registry, nil
+		}).AnyTimes()
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock).AnyTimes()
+	app := fxtest.New(s.T(),
+		fx.NopLogger, // remove fx debug prints
+		common.ViperFxOption(s.pwd+"/../../config/config.yml", s.pwd+"/../../config/config_test.yml"),
+		common.LoggerFxOption(),
+		fx.Options(options...),
+		fx.Provide(func() client.GRPCClientConnectionBuilder {
+			return s.grpcConnBuilderMock
+		}),
+		fx.Invoke(controllers.RegisterWithWorkshop),
+	)
+	app.RequireStart()
+	return app
+}
+
+// fakeRegistry is a gRPC connection to the workshop registry, it records requests by method name
+type fakeRegistry struct {
+	sync.Mutex
+	received  map[string][]interface{}
+	errors    map[string]error
+	dialCount int
+	closed    bool
+}
+
+func (f *fakeRegistry) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	f.Lock()
+	defer f.Unlock()
+	name := method[len("/demo.workshop.SubWorkshopRegistry/"):]
+	f.received[name] = append(f.received[name], args)
+	if response, ok := reply.(*api.RegisterResponse); ok {
+		response.HeartbeatInterval = ptypes.DurationProto(10 * time.Millisecond)
+	}
+	return f.errors[name]
+}
+
+func (f *fakeRegistry) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	panic("implement me")
+}
+
+func (f *fakeRegistry) calls(method string) []interface{} {
+	f.Lock()
+	defer f.Unlock()
+	return append([]interface{}(nil), f.received[method]...)
+}
+
+func (f *fakeRegistry) setError(method string, err error) {
+	f.Lock()
+	defer f.Unlock()
+	f.errors[method] = err
+}
+
+func (f *fakeRegistry) dialed() {
+	f.Lock()
+	defer f.Unlock()
+	f.dialCount++
+}
+
+func (f *fakeRegistry) dials() int {
+	f.Lock()
+	defer f.Unlock()
+	return f.dialCount
+}
+
+func (f *fakeRegistry) Close() error {
+	f.Lock()
+	defer f.Unlock()
+	f.closed = true
+	return nil
+}
+
+func (f *fakeRegistry) isClosed() bool {
+	f.Lock()
+	defer f.Unlock()
+	return f.closed
+}
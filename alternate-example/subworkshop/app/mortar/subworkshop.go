@@ -56,9 +56,13 @@ func gRPCGatewayHandlers() []serverInt.GRPCGatewayGeneratedHandlers {
 }
 
 func serviceDependencies() fx.Option {
-	return fx.Provide(
-		services.CreateSubWorkshopService,
-		controllers.CreateSubWorkshopController,
-		validations.CreateSubWorkshopValidations,
+	return fx.Options(
+		fx.Provide(
+			services.CreateSubWorkshopService,
+			controllers.CreateSubWorkshopController,
+			validations.CreateSubWorkshopValidations,
+		),
+		// Register with the workshop once started
+		fx.Invoke(controllers.RegisterWithWorkshop),
 	)
 }
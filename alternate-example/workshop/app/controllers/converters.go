@@ -1,6 +1,10 @@
 package controllers
 
 import (
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	api "workshop-common/api"
 	"workshop/app/data"
 )
@@ -31,3 +35,47 @@ func FromModelCarToProtoCar(car *data.CarEntity) *api.Car {
 		Color:     car.CurrentColor,
 	}
 }
+
+// FromProtoRegistrationToModelSubWorkshop converts a sub workshop registration to our data Entity
+func FromProtoRegistrationToModelSubWorkshop(registration *api.SubWorkshopRegistration) *data.SubWorkshopEntity {
+	if registration == nil {
+		return nil
+	}
+	return &data.SubWorkshopEntity{
+		Name:      registration.GetName(),
+		Address:   registration.GetAddress(),
+		Transport: api.SubWorkshopRegistrationTransport_name[int32(registration.GetTransportType())],
+		Capacity:  registration.GetCapacity(),
+		Colors:    registration.GetColors(),
+	}
+}
+
+// FromModelSubWorkshopToProtoRegisteredSubWorkshop converts our data Entity to a registry proto model
+func FromModelSubWorkshopToProtoRegisteredSubWorkshop(subWorkshop *data.SubWorkshopEntity) *api.RegisteredSubWorkshop {
+	if subWorkshop == nil {
+		return nil
+	}
+	return &api.RegisteredSubWorkshop{
+		Registration: &api.SubWorkshopRegistration{
+			Name:          subWorkshop.Name,
+			Address:       subWorkshop.Address,
+			TransportType: api.SubWorkshopRegistrationTransport(api.SubWorkshopRegistrationTransport_value[subWorkshop.Transport]),
+			Capacity:      subWorkshop.Capacity,
+			Colors:        subWorkshop.Colors,
+		},
+		RegisterTime:  toProtoTimestamp(subWorkshop.RegisterTime),
+		LastHeartbeat: toProtoTimestamp(subWorkshop.LastHeartbeat),
+	}
+}
+
+// toProtoTimestamp leaves zero times unset
+func toProtoTimestamp(t time.Time) *timestamp.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	ts, err := ptypes.TimestampProto(t)
+	if err != nil {
+		return nil
+	}
+	return ts
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/log"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"go.uber.org/fx"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	api "workshop-common/api"
+	"workshop/app/data"
+)
+
+const (
+	// RegistryTTLKey is how long a sub workshop stays registered without a heartbeat
+	RegistryTTLKey = "custom.registry.ttl"
+
+	defaultRegistryTTL = 30 * time.Second
+	// sub workshops are asked to send heartbeats a few times within the TTL, so missing one isn't fatal
+	heartbeatsPerTTL = 3
+)
+
+// SubWorkshopRegistryController keeps track of the sub workshops that are alive
+type SubWorkshopRegistryController interface {
+	api.SubWorkshopRegistryServer
+}
+
+type subWorkshopRegistryControllerDeps struct {
+	fx.In
+
+	Config cfg.Config
+	DB     data.SubWorkshopDB
+	Logger log.Logger
+}
+
+type subWorkshopRegistryController struct {
+	deps subWorkshopRegistryControllerDeps
+	ttl  time.Duration
+}
+
+// CreateSubWorkshopRegistryController is a constructor for Fx
+func CreateSubWorkshopRegistryController(deps subWorkshopRegistryControllerDeps) SubWorkshopRegistryController {
+	r := &subWorkshopRegistryController{
+		deps: deps,
+		ttl:  defaultRegistryTTL,
+	}
+	if value := deps.Config.Get(RegistryTTLKey); value.IsSet() {
+		r.ttl = value.Duration()
+	}
+	return r
+}
+
+func (r *subWorkshopRegistryController) Register(ctx context.Context, request *api.SubWorkshopRegistration) (*api.RegisterResponse, error) {
+	subWorkshop := FromProtoRegistrationToModelSubWorkshop(request)
+	subWorkshop.RegisterTime = time.Now().UTC()
+	subWorkshop.LastHeartbeat = subWorkshop.RegisterTime
+	if err := r.deps.DB.UpsertSubWorkshop(ctx, subWorkshop); err != nil {
+		return nil, err
+	}
+	r.deps.Logger.Info(ctx, "sub workshop %s registered at %s", subWorkshop.Name, subWorkshop.Address)
+	return &api.RegisterResponse{HeartbeatInterval: ptypes.DurationProto(r.ttl / heartbeatsPerTTL)}, nil
+}
+
+func (r *subWorkshopRegistryController) Heartbeat(ctx context.Context, request *api.HeartbeatRequest) (*empty.Empty, error) {
+	// expired sub workshops must register again
+	r.removeExpired(ctx)
+	err := r.deps.DB.HeartbeatSubWorkshop(ctx, request.GetName(), request.GetCapacity(), request.GetColors(), time.Now().UTC())
+	if errors.Is(err, data.ErrSubWorkshopNotRegistered) {
+		return nil, status.Errorf(codes.NotFound, "sub workshop %s is not registered", request.GetName())
+	}
+	return &empty.Empty{}, err
+}
+
+func (r *subWorkshopRegistryController) Deregister(ctx context.Context, request *api.DeregisterRequest) (*empty.Empty, error) {
+	err := r.deps.DB.RemoveSubWorkshop(ctx, request.GetName())
+	if errors.Is(err, data.ErrSubWorkshopNotRegistered) {
+		return nil, status.Errorf(codes.NotFound, "sub workshop %s is not registered", request.GetName())
+	}
+	r.deps.Logger.WithError(err).Info(ctx, "sub workshop %s deregistered", request.GetName())
+	return &empty.Empty{}, err
+}
+
+func (r *subWorkshopRegistryController) ListSubWorkshops(ctx context.Context, request *api.ListSubWorkshopsRequest) (*api.ListSubWorkshopsResponse, error) {
+	r.removeExpired(ctx)
+	subWorkshops, err := r.deps.DB.ListSubWorkshops(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response := &api.ListSubWorkshopsResponse{}
+	for _, subWorkshop := range subWorkshops {
+		response.SubWorkshops = append(response.SubWorkshops, FromModelSubWorkshopToProtoRegisteredSubWorkshop(subWorkshop))
+	}
+	return response, nil
+}
+
+func (r *subWorkshopRegistryController) removeExpired(ctx context.Context) {
+	expired, err := r.deps.DB.RemoveSubWorkshopsBefore(ctx, time.Now().Add(-r.ttl))
+	if err != nil {
+		r.deps.Logger.WithError(err).Warn(ctx, "failed to remove expired sub workshops")
+		return
+	}
+	for _, subWorkshop := range expired {
+		r.deps.Logger.Warn(ctx, "sub workshop %s expired, last heartbeat at %s", subWorkshop.Name, subWorkshop.LastHeartbeat)
+	}
+}
@@ -0,0 +1,134 @@
+package controllers_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+	common "workshop-common/mortar"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	api "workshop-common/api"
+	"workshop/app/controllers"
+	"workshop/app/data"
+	"workshop/app/services"
+)
+
+type registrySuite struct {
+	suite.Suite
+	pwd        string
+	app        *fxtest.App
+	controller controllers.SubWorkshopRegistryController
+}
+
+func TestSubWorkshopRegistry(t *testing.T) {
+	suite.Run(t, new(registrySuite))
+}
+
+func (s *registrySuite) TestRegister() {
+	response, err := s.controller.Register(context.Background(), &api.SubWorkshopRegistration{
+		Name:          "east",
+		Address:       "east:5480",
+		TransportType: api.SubWorkshopRegistration_GRPC,
+		Capacity:      4,
+		Colors:        []string{"red", "blue"},
+	})
+	s.Require().NoError(err)
+	interval, err := ptypes.Duration(response.GetHeartbeatInterval())
+	s.NoError(err)
+	s.Equal(100*time.Millisecond, interval)
+	list, err := s.controller.ListSubWorkshops(context.Background(), &api.ListSubWorkshopsRequest{})
+	s.Require().NoError(err)
+	s.Require().Len(list.GetSubWorkshops(), 1)
+	registered := list.GetSubWorkshops()[0]
+	s.Equal("east", registered.GetRegistration().GetName())
+	s.Equal("east:5480", registered.GetRegistration().GetAddress())
+	s.Equal(api.SubWorkshopRegistration_GRPC, registered.GetRegistration().GetTransportType())
+	s.Equal(int32(4), registered.GetRegistration().GetCapacity())
+	s.Equal([]string{"red", "blue"}, registered.GetRegistration().GetColors())
+	s.NotNil(registered.GetRegisterTime())
+	s.NotNil(registered.GetLastHeartbeat())
+}
+
+func (s *registrySuite) TestHeartbeat() {
+	_, err := s.controller.Heartbeat(context.Background(), &api.HeartbeatRequest{Name: "west"})
+	s.Equal(codes.NotFound, status.Code(err))
+	_, err = s.controller.Register(context.Background(), &api.SubWorkshopRegistration{Name: "west", Address: "west:5481", Capacity: 1})
+	s.Require().NoError(err)
+	// sub workshops that keep sending heartbeats stay registered
+	for i := 0; i < 4; i++ {
+		time.Sleep(100 * time.Millisecond)
+		_, err = s.controller.Heartbeat(context.Background(), &api.HeartbeatRequest{Name: "west", Capacity: 2, Colors: []string{"green"}})
+		s.Require().NoError(err)
+	}
+	list, err := s.controller.ListSubWorkshops(context.Background(), &api.ListSubWorkshopsRequest{})
+	s.Require().NoError(err)
+	s.Require().Len(list.GetSubWorkshops(), 1)
+	s.Equal(int32(2), list.GetSubWorkshops()[0].GetRegistration().GetCapacity())
+	s.Equal([]string{"green"}, list.GetSubWorkshops()[0].GetRegistration().GetColors())
+	// others expire and must register again
+	time.Sleep(400 * time.Millisecond)
+	list, err = s.controller.ListSubWorkshops(context.Background(), &api.ListSubWorkshopsRequest{})
+	s.Require().NoError(err)
+	s.Empty(list.GetSubWorkshops())
+	_, err = s.controller.Heartbeat(context.Background(), &api.HeartbeatRequest{Name: "west"})
+	s.Equal(codes.NotFound, status.Code(err))
+}
+
+func (s *registrySuite) TestDeregister() {
+	_, err := s.controller.Register(context.Background(), &api.SubWorkshopRegistration{Name: "north", Address: "north:5481"})
+	s.Require().NoError(err)
+	_, err = s.controller.Deregister(context.Background(), &api.DeregisterRequest{Name: "north"})
+	s.NoError(err)
+	list, err := s.controller.ListSubWorkshops(context.Background(), &api.ListSubWorkshopsRequest{})
+	s.Require().NoError(err)
+	s.Empty(list.GetSubWorkshops())
+	_, err = s.controller.Deregister(context.Background(), &api.DeregisterRequest{Name: "north"})
+	s.Equal(codes.NotFound, status.Code(err))
+}
+
+func (s *registrySuite) TestAdminHandler() {
+	_, err := s.controller.Register(context.Background(), &api.SubWorkshopRegistration{Name: "south", Address: "south:5481", Capacity: 3})
+	s.Require().NoError(err)
+	recorder := httptest.NewRecorder()
+	services.SubWorkshopsHandler(s.controller).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admin/subworkshops", nil))
+	s.Equal(http.StatusOK, recorder.Code)
+	body, err := ioutil.ReadAll(recorder.Body)
+	s.Require().NoError(err)
+	s.Contains(string(body), `"name":"south"`)
+	s.Contains(string(body), `"capacity":3`)
+}
+
+func (s *registrySuite) SetupSuite() {
+	var err error
+	s.pwd, err = os.Getwd()
+	s.Require().NoError(err)
+}
+
+func (s *registrySuite) SetupTest() {
+	s.app = fxtest.New(s.T(),
+		fx.NopLogger, // remove fx debug prints
+		common.ViperFxOption(s.pwd+"/../../config/config.yml", s.pwd+"/../../config/config_test.yml"),
+		common.LoggerFxOption(),
+		fx.Invoke(func(config cfg.Config) {
+			config.Set(controllers.RegistryTTLKey, "300ms")
+		}),
+		fx.Provide(data.CreateSubWorkshopDB),
+		fx.Provide(controllers.CreateSubWorkshopRegistryController),
+		fx.Populate(&s.controller),
+	)
+	s.app.RequireStart()
+}
+
+func (s *registrySuite) TearDownTest() {
+	s.app.RequireStop()
+}
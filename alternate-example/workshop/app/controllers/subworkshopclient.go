@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-masonry/mortar/interfaces/cfg"
+	"github.com/go-masonry/mortar/interfaces/http/client"
+	"github.com/go-masonry/mortar/mortar"
+	"github.com/golang/protobuf/jsonpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	api "workshop-common/api"
+)
+
+const (
+	// SubWorkshopAddressKey is the host:port of the sub workshop, its REST or gRPC port depending on the transport
+	SubWorkshopAddressKey = "custom.subworkshop.address"
+	// SubWorkshopTransportKey selects how paint requests are sent, one of SubWorkshopTransportREST (default) or SubWorkshopTransportGRPC
+	SubWorkshopTransportKey = "custom.subworkshop.transport"
+	// SubWorkshopTimeoutKey limits every call to the sub workshop, painting included
+	SubWorkshopTimeoutKey = "custom.subworkshop.timeout"
+	// CallbackAddressKey is the gRPC address the sub workshop calls back when a car is painted,
+	// it must be reachable from the sub workshop. By default it's this service gRPC port on localhost.
+	CallbackAddressKey = "custom.callback.address"
+
+	// SubWorkshopTransportREST sends paint requests to the sub workshop gRPC gateway
+	SubWorkshopTransportREST = "rest"
+	// SubWorkshopTransportGRPC sends paint requests with the SubWorkshop gRPC client
+	SubWorkshopTransportGRPC = "grpc"
+
+	defaultSubWorkshopTimeout = 30 * time.Second
+)
+
+// subWorkshopClient sends paint requests to the sub workshop
+type subWorkshopClient interface {
+	PaintCar(ctx context.Context, request *api.SubPaintCarRequest) error
+	// Close releases the connection to the sub workshop, calls made after it fail
+	Close() error
+}
+
+func newSubWorkshopClient(config cfg.Config, httpClient *http.Client, grpcBuilder client.GRPCClientConnectionBuilder, dialOption grpc.DialOption) (subWorkshopClient, error) {
+	address := config.Get(SubWorkshopAddressKey).String()
+	if len(address) == 0 {
+		return nil, fmt.Errorf("%s must be set", SubWorkshopAddressKey)
+	}
+	timeout := defaultSubWorkshopTimeout
+	if value := config.Get(SubWorkshopTimeoutKey); value.IsSet() {
+		timeout = value.Duration()
+	}
+	switch transport := config.Get(SubWorkshopTransportKey).String(); transport {
+	case "", SubWorkshopTransportREST:
+		return &restSubWorkshopClient{
+			client:  httpClient,
+			encoder: &jsonpb.Marshaler{OrigName: true},
+			url:     fmt.Sprintf("http://%s/v1/subworkshop/paint", address),
+			timeout: timeout,
+		}, nil
+	case SubWorkshopTransportGRPC:
+		return &grpcSubWorkshopClient{
+			builder:    grpcBuilder,
+			address:    address,
+			timeout:    timeout,
+			dialOption: dialOption,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown sub workshop transport %s", transport)
+	}
+}
+
+// callbackAddress is where the sub workshop reports painted cars
+func callbackAddress(config cfg.Config) string {
+	if value := config.Get(CallbackAddressKey); value.IsSet() {
+		return value.String()
+	}
+	return "localhost:" + config.Get(mortar.ServerGRPCPort).String()
+}
+
+type restSubWorkshopClient struct {
+	client  *http.Client
+	encoder *jsonpb.Marshaler
+	url     string
+	timeout time.Duration
+}
+
+func (r *restSubWorkshopClient) PaintCar(ctx context.Context, request *api.SubPaintCarRequest) error {
+	body := new(bytes.Buffer)
+	if err := r.encoder.Marshal(body, request); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	httpReq, err := http.NewRequest(http.MethodPost, r.url, body)
+	if err != nil {
+		return err
+	}
+	response, err := r.client.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return status.Errorf(codes.DeadlineExceeded, "sub workshop at %s didn't answer in time, %v", r.url, err)
+		}
+		return status.Errorf(codes.Unavailable, "can't reach sub workshop at %s, %v", r.url, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return status.Errorf(codeFromHTTPStatus(response.StatusCode), "painting failed with status %d", response.StatusCode)
+	}
+	return nil
+}
+
+// Close does nothing, the HTTP client isn't owned by the sub workshop client
+func (r *restSubWorkshopClient) Close() error {
+	return nil
+}
+
+// grpcSubWorkshopClient dials the sub workshop on its first call and reuses the connection until it's closed
+type grpcSubWorkshopClient struct {
+	sync.Mutex
+	builder    client.GRPCClientConnectionBuilder
+	address    string
+	timeout    time.Duration
+	dialOption grpc.DialOption
+	conn       grpc.ClientConnInterface
+	closed     bool
+}
+
+func (g *grpcSubWorkshopClient) PaintCar(ctx context.Context, request *api.SubPaintCarRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+	conn, err := g.connection(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = api.NewSubWorkshopClient(conn).PaintCar(ctx, request)
+	return err
+}
+
+// connection dials only once, failed dials are tried again by the next call
+func (g *grpcSubWorkshopClient) connection(ctx context.Context) (grpc.ClientConnInterface, error) {
+	g.Lock()
+	defer g.Unlock()
+	if g.closed {
+		return nil, status.Errorf(codes.Unavailable, "connection to sub workshop at %s is closed", g.address)
+	}
+	if g.conn == nil {
+		conn, err := g.builder.Build().Dial(ctx, g.address, g.dialOption)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "can't reach sub workshop at %s, %v", g.address, err)
+		}
+		g.conn = conn
+	}
+	return g.conn, nil
+}
+
+func (g *grpcSubWorkshopClient) Close() error {
+	g.Lock()
+	defer g.Unlock()
+	g.closed = true
+	if closer, ok := g.conn.(io.Closer); ok {
+		g.conn = nil
+		return closer.Close()
+	}
+	return nil
+}
+
+// codeFromHTTPStatus maps the status of the sub workshop REST gateway back to the gRPC code it was made from
+func codeFromHTTPStatus(statusCode int) codes.Code {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		if statusCode >= http.StatusInternalServerError {
+			return codes.Internal
+		}
+		return codes.Unknown
+	}
+}
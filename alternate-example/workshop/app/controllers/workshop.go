@@ -1,25 +1,19 @@
 package controllers
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"net/http"
 
+	"github.com/go-masonry/mortar/interfaces/cfg"
 	"github.com/go-masonry/mortar/interfaces/http/client"
 	"github.com/go-masonry/mortar/interfaces/log"
-	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/ptypes/empty"
 	"go.uber.org/fx"
 	api "workshop-common/api"
+	common "workshop-common/mortar"
 	"workshop/app/data"
 )
 
-const (
-	selfGRPCServerPort  = "5380"
-	subWorkshopRestPort = "5481"
-)
-
 // WorkshopController responsible for the business logic of our Workshop
 type WorkshopController interface {
 	api.WorkshopServer
@@ -28,26 +22,40 @@ type WorkshopController interface {
 type workshopControllerDeps struct {
 	fx.In
 
+	Config            cfg.Config
 	DB                data.CarDB
 	Logger            log.Logger
+	Lifecycle         fx.Lifecycle
 	HTTPClientBuilder client.NewHTTPClientBuilder
+	GRPCClientBuilder client.GRPCClientConnectionBuilder
 }
 
 type workshopController struct {
-	deps    workshopControllerDeps
-	client  *http.Client
-	encoder *jsonpb.Marshaler
+	deps            workshopControllerDeps
+	subWorkshop     subWorkshopClient
+	callbackAddress string
 }
 
 // CreateWorkshopController is a constructor for Fx
-func CreateWorkshopController(deps workshopControllerDeps) WorkshopController {
-	client := deps.HTTPClientBuilder().Build()
-	encoder := &jsonpb.Marshaler{OrigName: true}
-	return &workshopController{
-		deps:    deps,
-		client:  client,
-		encoder: encoder,
+func CreateWorkshopController(deps workshopControllerDeps) (WorkshopController, error) {
+	dialOption, err := common.GRPCDialOption(deps.Config)
+	if err != nil {
+		return nil, err
+	}
+	subWorkshop, err := newSubWorkshopClient(deps.Config, deps.HTTPClientBuilder().Build(), deps.GRPCClientBuilder, dialOption)
+	if err != nil {
+		return nil, err
 	}
+	deps.Lifecycle.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return subWorkshop.Close()
+		},
+	})
+	return &workshopController{
+		deps:            deps,
+		subWorkshop:     subWorkshop,
+		callbackAddress: callbackAddress(deps.Config),
+	}, nil
 }
 
 func (w *workshopController) AcceptCar(ctx context.Context, car *api.Car) (*empty.Empty, error) {
@@ -61,19 +69,15 @@ func (w *workshopController) PaintCar(ctx context.Context, request *api.PaintCar
 	if err != nil {
 		return nil, err
 	}
-	httpReq, err := w.makePaintRestRequest(ctx, car, request)
-	if err != nil {
-		return nil, err
-	}
-	response, err := w.client.Do(httpReq)
+	err = w.subWorkshop.PaintCar(ctx, &api.SubPaintCarRequest{
+		Car:                    FromModelCarToProtoCar(car),
+		DesiredColor:           request.GetDesiredColor(),
+		CallbackServiceAddress: w.callbackAddress,
+	})
 	if err != nil {
 		w.deps.Logger.WithError(err).Debug(ctx, "calling sub api failed")
 		return nil, err
 	}
-	defer response.Body.Close()
-	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("painting failed with status %d", response.StatusCode)
-	}
 	return &empty.Empty{}, nil
 }
 
@@ -96,19 +100,3 @@ func (w *workshopController) CarPainted(ctx context.Context, request *api.PaintF
 	err := w.deps.DB.PaintCar(ctx, request.GetCarNumber(), request.GetDesiredColor())
 	return &empty.Empty{}, err
 }
-
-func (w *workshopController) makePaintRestRequest(ctx context.Context, car *data.CarEntity, request *api.PaintCarRequest) (httpReq *http.Request, err error) {
-	pbReq := &api.SubPaintCarRequest{
-		Car:                    FromModelCarToProtoCar(car),
-		DesiredColor:           request.GetDesiredColor(),
-		CallbackServiceAddress: fmt.Sprintf(":%s", selfGRPCServerPort),
-	}
-	body := new(bytes.Buffer)
-	if err = w.encoder.Marshal(body, pbReq); err != nil {
-		return nil, err
-	}
-	if httpReq, err = http.NewRequest(http.MethodPost, fmt.Sprintf("http://localhost:%s/v1/subworkshop/paint", subWorkshopRestPort), body); err == nil {
-		httpReq = httpReq.WithContext(ctx)
-	}
-	return
-}
@@ -6,15 +6,21 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync/atomic"
 	"testing"
 	common "workshop-common/mortar"
 
 	"github.com/go-masonry/mortar/http/client"
+	"github.com/go-masonry/mortar/interfaces/cfg"
 	clientInt "github.com/go-masonry/mortar/interfaces/http/client"
+	mock_client "github.com/go-masonry/mortar/interfaces/http/client/mock"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	api "workshop-common/api"
 	"workshop/app/controllers"
 	"workshop/app/data"
@@ -27,6 +33,11 @@ type workshopSuite struct {
 	app        *fxtest.App
 	carDB      data.CarDB
 	controller controllers.WorkshopController
+
+	grpcConnBuilderMock *mock_client.MockGRPCClientConnectionBuilder
+	gRPCWrapperMock     *mock_client.MockGRPCClientConnectionWrapper
+	// returned by the fake sub workshop over REST
+	subWorkshopStatusCode int
 }
 
 func TestWorkshop(t *testing.T) {
@@ -78,6 +89,56 @@ func (s *workshopSuite) TestPaintCar() {
 	s.NoError(err)
 }
 
+func (s *workshopSuite) TestPaintCarOverGRPC() {
+	s.app.RequireStop()
+	s.app = fxtest.New(s.T(),
+		s.fxOptions(fx.Invoke(func(config cfg.Config) {
+			config.Set(controllers.SubWorkshopTransportKey, controllers.SubWorkshopTransportGRPC)
+			config.Set(controllers.SubWorkshopAddressKey, "subworkshop:5480")
+		})),
+	)
+	s.app.RequireStart()
+	fakeConnection := new(fakeGRPCConnection)
+	s.gRPCWrapperMock.EXPECT().Dial(gomock.Any(), "subworkshop:5480", gomock.Any()).Return(fakeConnection, nil)
+	s.grpcConnBuilderMock.EXPECT().Build().Return(s.gRPCWrapperMock)
+
+	_, err := s.controller.AcceptCar(context.Background(), &api.Car{Number: "1234567", Color: "yellow"})
+	s.NoError(err)
+	_, err = s.controller.PaintCar(context.Background(), &api.PaintCarRequest{
+		CarNumber:    "1234567",
+		DesiredColor: "orange",
+	})
+	s.NoError(err)
+	s.Equal(int32(1), atomic.LoadInt32(&fakeConnection.callCounter))
+	// the connection is dialed once and closed when the application stops
+	_, err = s.controller.PaintCar(context.Background(), &api.PaintCarRequest{
+		CarNumber:    "1234567",
+		DesiredColor: "green",
+	})
+	s.NoError(err)
+	s.Equal(int32(2), atomic.LoadInt32(&fakeConnection.callCounter))
+	s.False(fakeConnection.isClosed())
+	s.app.RequireStop()
+	s.True(fakeConnection.isClosed())
+	s.app = fxtest.New(s.T(), s.fxOptions())
+	s.app.RequireStart()
+}
+
+func (s *workshopSuite) TestPaintCarOverRESTFails() {
+	_, err := s.controller.AcceptCar(context.Background(), &api.Car{Number: "1234567", Color: "yellow"})
+	s.Require().NoError(err)
+	for statusCode, code := range map[int]codes.Code{
+		http.StatusNotFound:            codes.NotFound,
+		http.StatusTooManyRequests:     codes.ResourceExhausted,
+		http.StatusServiceUnavailable:  codes.Unavailable,
+		http.StatusInternalServerError: codes.Internal,
+	} {
+		s.subWorkshopStatusCode = statusCode
+		_, err = s.controller.PaintCar(context.Background(), &api.PaintCarRequest{CarNumber: "1234567", DesiredColor: "orange"})
+		s.Equal(code, status.Code(err), "status %d", statusCode)
+	}
+}
+
 func (s *workshopSuite) TestRetrieveCar() {
 	// No car
 	_, err := s.controller.RetrieveCar(context.Background(), &api.RetrieveCarRequest{CarNumber: "not yet there"})
@@ -133,7 +194,10 @@ func (s *workshopSuite) SetupSuite() {
 }
 
 func (s *workshopSuite) SetupTest() {
+	s.subWorkshopStatusCode = http.StatusOK
 	s.ctrl = gomock.NewController(s.T())
+	s.grpcConnBuilderMock = mock_client.NewMockGRPCClientConnectionBuilder(s.ctrl)
+	s.gRPCWrapperMock = mock_client.NewMockGRPCClientConnectionWrapper(s.ctrl)
 	s.app = fxtest.New(s.T(),
 		s.fxOptions(),
 	)
@@ -145,13 +209,15 @@ func (s *workshopSuite) TearDownTest() {
 	s.ctrl.Finish()
 }
 
-func (s *workshopSuite) fxOptions() fx.Option {
+func (s *workshopSuite) fxOptions(options ...fx.Option) fx.Option {
 	return fx.Options(
 		fx.NopLogger, // remove fx debug prints
 		common.ViperFxOption(s.pwd+"/../../config/config.yml", s.pwd+"/../../config/config_test.yml"),
 		common.LoggerFxOption(),
 		//providers.HTTPClientBuildersFxOption(), // uncomment this line to see that TestPaintCar fails
+		fx.Options(options...),
 		fx.Provide(s.specialHTTPClientBuilder),
+		fx.Provide(func() clientInt.GRPCClientConnectionBuilder { return s.grpcConnBuilderMock }),
 		fx.Provide(data.CreateCarDB),
 		fx.Provide(controllers.CreateWorkshopController),
 		fx.Populate(&s.carDB),
@@ -164,8 +230,8 @@ func (s *workshopSuite) specialHTTPClientBuilder() clientInt.NewHTTPClientBuilde
 		return client.HTTPClientBuilder().AddInterceptors(func(*http.Request, clientInt.HTTPpHandler) (*http.Response, error) {
 			// special case, don't go anywhere just return the response
 			return &http.Response{
-				Status:        "200 OK",
-				StatusCode:    200,
+				Status:        http.StatusText(s.subWorkshopStatusCode),
+				StatusCode:    s.subWorkshopStatusCode,
 				Proto:         "HTTP/1.1",
 				ProtoMajor:    1,
 				ProtoMinor:    1,
@@ -175,3 +241,26 @@ func (s *workshopSuite) specialHTTPClientBuilder() clientInt.NewHTTPClientBuilde
 		})
 	}
 }
+
+type fakeGRPCConnection struct {
+	callCounter int32
+	closed      int32
+}
+
+func (f *fakeGRPCConnection) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	atomic.AddInt32(&f.callCounter, 1)
+	return nil // painted
+}
+
+func (f *fakeGRPCConnection) Close() error {
+	atomic.StoreInt32(&f.closed, 1)
+	return nil
+}
+
+func (f *fakeGRPCConnection) isClosed() bool {
+	return atomic.LoadInt32(&f.closed) == 1
+}
+
+func (f *fakeGRPCConnection) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	panic("implement me")
+}
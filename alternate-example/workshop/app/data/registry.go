@@ -0,0 +1,112 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// ErrSubWorkshopNotRegistered is returned for sub workshops that never registered, deregistered or expired
+var ErrSubWorkshopNotRegistered = errors.New("sub workshop is not registered")
+
+// SubWorkshopEntity is a sub workshop that registered with the workshop
+type SubWorkshopEntity struct {
+	Name          string
+	Address       string
+	Transport     string
+	Capacity      int32
+	Colors        []string
+	RegisterTime  time.Time
+	LastHeartbeat time.Time
+}
+
+// This interface will represent the sub workshops registry
+type SubWorkshopDB interface {
+	// UpsertSubWorkshop adds a sub workshop or replaces the one with the same name
+	UpsertSubWorkshop(ctx context.Context, subWorkshop *SubWorkshopEntity) error
+	// HeartbeatSubWorkshop updates the capacity, colors and last heartbeat of a registered sub workshop
+	HeartbeatSubWorkshop(ctx context.Context, name string, capacity int32, colors []string, now time.Time) error
+	RemoveSubWorkshop(ctx context.Context, name string) error
+	// RemoveSubWorkshopsBefore removes sub workshops without a heartbeat since before and returns them
+	RemoveSubWorkshopsBefore(ctx context.Context, before time.Time) ([]*SubWorkshopEntity, error)
+	// ListSubWorkshops returns all sub workshops sorted by name
+	ListSubWorkshops(ctx context.Context) ([]*SubWorkshopEntity, error)
+}
+
+type subWorkshopDBDeps struct {
+	fx.In
+}
+
+func CreateSubWorkshopDB(deps subWorkshopDBDeps) SubWorkshopDB {
+	return &subWorkshopDB{
+		deps:         deps,
+		subWorkshops: make(map[string]*SubWorkshopEntity),
+	}
+}
+
+// subWorkshopDB is called by several sub workshops at the same time, unlike carDB it's locked
+type subWorkshopDB struct {
+	sync.Mutex
+	deps         subWorkshopDBDeps
+	subWorkshops map[string]*SubWorkshopEntity
+}
+
+func (s *subWorkshopDB) UpsertSubWorkshop(ctx context.Context, subWorkshop *SubWorkshopEntity) error {
+	s.Lock()
+	defer s.Unlock()
+	clone := *subWorkshop
+	s.subWorkshops[subWorkshop.Name] = &clone
+	return nil
+}
+
+func (s *subWorkshopDB) HeartbeatSubWorkshop(ctx context.Context, name string, capacity int32, colors []string, now time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+	subWorkshop, exists := s.subWorkshops[name]
+	if !exists {
+		return ErrSubWorkshopNotRegistered
+	}
+	subWorkshop.Capacity = capacity
+	subWorkshop.Colors = colors
+	subWorkshop.LastHeartbeat = now
+	return nil
+}
+
+func (s *subWorkshopDB) RemoveSubWorkshop(ctx context.Context, name string) error {
+	s.Lock()
+	defer s.Unlock()
+	if _, exists := s.subWorkshops[name]; !exists {
+		return ErrSubWorkshopNotRegistered
+	}
+	delete(s.subWorkshops, name)
+	return nil
+}
+
+func (s *subWorkshopDB) RemoveSubWorkshopsBefore(ctx context.Context, before time.Time) ([]*SubWorkshopEntity, error) {
+	s.Lock()
+	defer s.Unlock()
+	var removed []*SubWorkshopEntity
+	for name, subWorkshop := range s.subWorkshops {
+		if subWorkshop.LastHeartbeat.Before(before) {
+			removed = append(removed, subWorkshop)
+			delete(s.subWorkshops, name)
+		}
+	}
+	return removed, nil
+}
+
+func (s *subWorkshopDB) ListSubWorkshops(ctx context.Context) ([]*SubWorkshopEntity, error) {
+	s.Lock()
+	defer s.Unlock()
+	subWorkshops := make([]*SubWorkshopEntity, 0, len(s.subWorkshops))
+	for _, subWorkshop := range s.subWorkshops {
+		clone := *subWorkshop
+		subWorkshops = append(subWorkshops, &clone)
+	}
+	sort.Slice(subWorkshops, func(i, j int) bool { return subWorkshops[i].Name < subWorkshops[j].Name })
+	return subWorkshops, nil
+}
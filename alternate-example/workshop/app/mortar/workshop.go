@@ -19,7 +19,8 @@ type tutorialServiceDeps struct {
 	fx.In
 
 	// API Implementations
-	Workshop api.WorkshopServer
+	Workshop            api.WorkshopServer
+	SubWorkshopRegistry api.SubWorkshopRegistryServer
 }
 
 func WorkshopService() fx.Option {
@@ -34,6 +35,11 @@ func WorkshopService() fx.Option {
 			Group:  groups.GRPCGatewayGeneratedHandlers + ",flatten", // "flatten" does this [][]serverInt.GRPCGatewayGeneratedHandlers -> []serverInt.GRPCGatewayGeneratedHandlers
 			Target: gRPCGatewayHandlers,
 		}),
+		// Internal HTTP handlers, served on the internal port only
+		fx.Provide(fx.Annotated{
+			Group:  groups.InternalHTTPHandlers + ",flatten",
+			Target: internalHTTPHandlers,
+		}),
 		// All other dependencies
 		tutorialDependencies(),
 	)
@@ -42,6 +48,7 @@ func WorkshopService() fx.Option {
 func gRPCHandlers(deps tutorialServiceDeps) serverInt.GRPCServerAPI {
 	return func(srv *grpc.Server) {
 		api.RegisterWorkshopServer(srv, deps.Workshop)
+		api.RegisterSubWorkshopRegistryServer(srv, deps.SubWorkshopRegistry)
 		// Any additional gRPC Implementations should be called here
 	}
 }
@@ -56,11 +63,21 @@ func gRPCGatewayHandlers() []serverInt.GRPCGatewayGeneratedHandlers {
 	}
 }
 
+func internalHTTPHandlers(deps tutorialServiceDeps) []serverInt.HTTPHandlerPatternPair {
+	return []serverInt.HTTPHandlerPatternPair{
+		{Pattern: "/admin/subworkshops", Handler: services.SubWorkshopsHandler(deps.SubWorkshopRegistry)},
+	}
+}
+
 func tutorialDependencies() fx.Option {
 	return fx.Provide(
 		services.CreateWorkshopService,
 		controllers.CreateWorkshopController,
 		data.CreateCarDB,
 		validations.CreateWorkshopValidations,
+		services.CreateSubWorkshopRegistryService,
+		controllers.CreateSubWorkshopRegistryController,
+		data.CreateSubWorkshopDB,
+		validations.CreateSubWorkshopRegistryValidations,
 	)
 }
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"net/http"
+
+	"github.com/golang/protobuf/jsonpb"
+	api "workshop-common/api"
+)
+
+// SubWorkshopsHandler serves the live sub workshops as JSON, it's meant for the internal port only
+func SubWorkshopsHandler(registry api.SubWorkshopRegistryServer) http.Handler {
+	marshaler := &jsonpb.Marshaler{OrigName: true, EmitDefaults: true}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		response, err := registry.ListSubWorkshops(r.Context(), &api.ListSubWorkshopsRequest{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err = marshaler.Marshal(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
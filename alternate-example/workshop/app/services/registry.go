@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+
+	"github.com/go-masonry/mortar/interfaces/log"
+	"github.com/golang/protobuf/ptypes/empty"
+	"go.uber.org/fx"
+	api "workshop-common/api"
+	"workshop/app/controllers"
+	"workshop/app/validations"
+)
+
+type subWorkshopRegistryServiceDeps struct {
+	fx.In
+
+	Logger      log.Logger
+	Controller  controllers.SubWorkshopRegistryController
+	Validations validations.SubWorkshopRegistryValidations
+}
+
+type subWorkshopRegistryImpl struct {
+	deps subWorkshopRegistryServiceDeps
+	api.UnimplementedSubWorkshopRegistryServer
+}
+
+func CreateSubWorkshopRegistryService(deps subWorkshopRegistryServiceDeps) api.SubWorkshopRegistryServer {
+	return &subWorkshopRegistryImpl{
+		deps: deps,
+	}
+}
+
+func (r *subWorkshopRegistryImpl) Register(ctx context.Context, request *api.SubWorkshopRegistration) (*api.RegisterResponse, error) {
+	if err := r.deps.Validations.Register(ctx, request); err != nil {
+		return nil, err
+	}
+	r.deps.Logger.WithField("subWorkshop", request).Debug(ctx, "registering sub workshop")
+	return r.deps.Controller.Register(ctx, request)
+}
+
+func (r *subWorkshopRegistryImpl) Heartbeat(ctx context.Context, request *api.HeartbeatRequest) (*empty.Empty, error) {
+	if err := r.deps.Validations.Heartbeat(ctx, request); err != nil {
+		return nil, err
+	}
+	r.deps.Logger.Debug(ctx, "sub workshop heartbeat")
+	return r.deps.Controller.Heartbeat(ctx, request)
+}
+
+func (r *subWorkshopRegistryImpl) Deregister(ctx context.Context, request *api.DeregisterRequest) (*empty.Empty, error) {
+	if err := r.deps.Validations.Deregister(ctx, request); err != nil {
+		return nil, err
+	}
+	r.deps.Logger.Debug(ctx, "deregistering sub workshop")
+	return r.deps.Controller.Deregister(ctx, request)
+}
+
+func (r *subWorkshopRegistryImpl) ListSubWorkshops(ctx context.Context, request *api.ListSubWorkshopsRequest) (*api.ListSubWorkshopsResponse, error) {
+	r.deps.Logger.Debug(ctx, "listing sub workshops")
+	return r.deps.Controller.ListSubWorkshops(ctx, request)
+}
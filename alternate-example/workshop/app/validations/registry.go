@@ -0,0 +1,61 @@
+package validations
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	api "workshop-common/api"
+)
+
+type SubWorkshopRegistryValidations interface {
+	Register(ctx context.Context, request *api.SubWorkshopRegistration) error
+	Heartbeat(ctx context.Context, request *api.HeartbeatRequest) error
+	Deregister(ctx context.Context, request *api.DeregisterRequest) error
+}
+
+type subWorkshopRegistryValidations struct {
+}
+
+func CreateSubWorkshopRegistryValidations() SubWorkshopRegistryValidations {
+	return new(subWorkshopRegistryValidations)
+}
+
+func (r *subWorkshopRegistryValidations) Register(ctx context.Context, request *api.SubWorkshopRegistration) error {
+	if err := subWorkshopNameValidation(request.GetName()); err != nil {
+		return err
+	}
+	if _, _, err := net.SplitHostPort(request.GetAddress()); err != nil {
+		return status.Errorf(codes.InvalidArgument, "sub workshop address %s should be host:port", request.GetAddress())
+	}
+	if _, known := api.SubWorkshopRegistrationTransport_name[int32(request.GetTransportType())]; !known {
+		return status.Errorf(codes.InvalidArgument, "unknown transport %d", request.GetTransportType())
+	}
+	return capacityValidation(request.GetCapacity())
+}
+
+func (r *subWorkshopRegistryValidations) Heartbeat(ctx context.Context, request *api.HeartbeatRequest) error {
+	if err := subWorkshopNameValidation(request.GetName()); err != nil {
+		return err
+	}
+	return capacityValidation(request.GetCapacity())
+}
+
+func (r *subWorkshopRegistryValidations) Deregister(ctx context.Context, request *api.DeregisterRequest) error {
+	return subWorkshopNameValidation(request.GetName())
+}
+
+func subWorkshopNameValidation(name string) error {
+	if len(name) == 0 {
+		return status.Errorf(codes.InvalidArgument, "sub workshop name can't be empty")
+	}
+	return nil
+}
+
+func capacityValidation(capacity int32) error {
+	if capacity < 0 {
+		return status.Errorf(codes.InvalidArgument, "capacity %d can't be negative", capacity)
+	}
+	return nil
+}